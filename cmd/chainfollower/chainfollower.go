@@ -1,47 +1,306 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
 
+	"github.com/dogecoinfoundation/chainfollower/pkg/archive"
+	"github.com/dogecoinfoundation/chainfollower/pkg/broker"
 	"github.com/dogecoinfoundation/chainfollower/pkg/chainfollower"
+	"github.com/dogecoinfoundation/chainfollower/pkg/chainparams"
 	"github.com/dogecoinfoundation/chainfollower/pkg/config"
+	"github.com/dogecoinfoundation/chainfollower/pkg/health"
+	"github.com/dogecoinfoundation/chainfollower/pkg/indexer"
 	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/metrics"
 	"github.com/dogecoinfoundation/chainfollower/pkg/rpc"
+	"github.com/dogecoinfoundation/chainfollower/pkg/sink"
 	"github.com/dogecoinfoundation/chainfollower/pkg/store"
+	"github.com/dogecoinfoundation/chainfollower/pkg/stream"
 )
 
+const positionFile = "position.json"
+
+// Usage: chainfollower [serve]
+//
+//	chainfollower export -from <height> -to <height> [-hex] [-chunk <blocks>] <dir>
+//
+// With "serve", events are also streamed to remote clients (see [serve]).
+// Events are also delivered to each configured [[sink]], and blocks are
+// indexed into the db_url database. "export" writes a range of blocks to an
+// archive, which can replace the Core node (archive_dir).
 func main() {
+	serve := len(os.Args) > 1 && os.Args[1] == "serve"
+	export := len(os.Args) > 1 && os.Args[1] == "export"
+
 	config, err := config.LoadConfig("config.toml")
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.LogLevel)); config.LogLevel != "" && err != nil {
+		log.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+	positions := store.New()
+	positions.Logger = logger
+
+	if _, err := chainparams.RegisterFromConfig(config.Chains); err != nil {
+		log.Fatal(err)
+	}
+
+	opts, err := chainfollower.OptionsFromConfig(config.Follower)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	rpcClient := rpc.NewRpcTransport(config)
-	chainfollower := chainfollower.NewChainFollower(rpcClient)
+	rpcClient.Logger = logger
+	var transport rpc.RpcTransportInterface = rpcClient
+	if config.ArchiveDir != "" {
+		blocks, err := archive.Open(config.ArchiveDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		from, to := blocks.Range()
+		logger.Info("Following a block archive", "dir", config.ArchiveDir, "from", from, "to", to)
+		transport = blocks
+	} else if config.BlockCacheSize > 0 || config.BlockCacheDir != "" {
+		cache := rpc.NewCachingTransport(rpcClient, config.BlockCacheSize, config.BlockCacheDir)
+		cache.Logger = logger
+		transport = cache
+	}
+	shutdownTimeout := config.ShutdownTimeout
+	if shutdownTimeout == 0 {
+		shutdownTimeout = chainfollower.DEFAULT_SHUTDOWN_TIMEOUT
+	}
+	opts = append(opts, chainfollower.WithLogger(logger))
+	replay := stream.FollowerReplayer(transport, opts...)
+	opts = append(opts, chainfollower.WithSignalHandling(shutdownTimeout))
+	chainfollower, err := chainfollower.New(transport, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if export {
+		if err := exportArchive(chainfollower, transport, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-	chainPos, err := store.LoadChainPos("position.json")
+	if config.HttpListen != "" {
+		m := metrics.New()
+		rpcClient.Metrics = m
+		chainfollower.Metrics = m
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", m.Handler())
+		health.NewChecker(chainfollower, config.ReadyMaxLag).Register(mux)
+		go func() {
+			logger.Info("Serving metrics and health checks", "listen", config.HttpListen)
+			log.Fatal(http.ListenAndServe(config.HttpListen, mux))
+		}()
+	}
+
+	var server *stream.Server
+	if serve {
+		server, err = startStreamServer(config.Serve, replay, logger)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer server.Close()
+	}
+
+	chainPos, err := positions.LoadChainPos(positionFile)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	messageChan := chainfollower.Start(chainPos)
+	// On SIGINT or SIGTERM the follower shuts down, delivering the messages
+	// in flight, and closes its channel; the sinks and the indexer then
+	// finish what the broker has queued for them.
+	var workers sync.WaitGroup
+	var messageChan <-chan messages.Message
+	if len(config.Sinks) > 0 || config.DbUrl != "" {
+		// Fan out to the sinks and the indexer, each resuming from its own position.
+		b := broker.NewBroker()
+		b.Logger = logger
+		local := b.Subscribe("main", broker.WithCheckpoint(chainPos))
+		if err := startSinks(b, config.Sinks, &workers, logger); err != nil {
+			log.Fatal(err)
+		}
+		if config.DbUrl != "" {
+			ix, err := startIndexer(b, config.DbUrl, &workers, logger)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer ix.Close()
+		}
+		if err := b.RewindOrphaned(transport); err != nil {
+			log.Fatal(err)
+		}
+		go b.Run(chainfollower.Start(b.StartPos()))
+		messageChan = local.Messages()
+	} else {
+		messageChan = chainfollower.Start(chainPos)
+	}
 
 	for message := range messageChan {
+		if server != nil {
+			server.Publish(message)
+		}
 		switch msg := message.(type) {
 		case messages.BlockMessage:
-			log.Println("Received message from chainfollower:")
-			log.Println(msg.Block)
-			log.Println(msg.ChainPos)
+			logger.Info("Received block from chainfollower", "height", msg.Block.Height, "hash", msg.Block.Hash, "txns", len(msg.Block.Tx))
 
-			store.SaveChainPos("data.json", msg.ChainPos)
+			positions.SaveChainPos(positionFile, msg.ChainPos)
 		case messages.RollbackMessage:
-			log.Println("Received rollback message from chainfollower:")
-			log.Println(msg.OldChainPos)
-			log.Println(msg.NewChainPos)
+			logger.Info("Received rollback from chainfollower",
+				"from_height", msg.OldChainPos.BlockHeight, "from_hash", msg.OldChainPos.BlockHash,
+				"height", msg.NewChainPos.BlockHeight, "hash", msg.NewChainPos.BlockHash)
 
-			store.SaveChainPos("data.json", msg.NewChainPos)
+			positions.SaveChainPos(positionFile, msg.NewChainPos)
+		case messages.DataCarrierMessage:
+			logger.Info("Received OP_RETURN payload from chainfollower", "height", msg.BlockHeight, "txid", msg.TxID, "vout", msg.VOut, "bytes", len(msg.Data))
+		case messages.ChainMismatchMessage:
+			logger.Error("Core node is on the wrong chain", "error", msg.Err)
+		case messages.CheckpointAlertMessage:
+			logger.Error("Core node failed a checkpoint", "error", msg.Err)
+		case messages.InvalidBlockMessage:
+			logger.Error("Core node sent an invalid block", "error", msg.Err)
+		case messages.StallMessage, messages.LagMessage:
+			// logged by the follower's watchdog; sinks and stream clients receive them as alerts.
 		default:
-			log.Println("Received unknown message from chainfollower:")
+			logger.Warn("Received unknown message from chainfollower")
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownTimeout):
+		logger.Warn("Sinks and indexer did not finish before the shutdown timeout")
+	}
+}
+
+// startSinks subscribes each [[sink]] to the broker and runs it. A sink that
+// gives up is unsubscribed so that it does not hold up the others.
+func startSinks(b *broker.Broker, sinks []config.SinkConfig, workers *sync.WaitGroup, logger *slog.Logger) error {
+	for _, cfg := range sinks {
+		runner, err := sink.FromConfig(cfg)
+		if err != nil {
+			return err
+		}
+		runner.PositionFile = "position-" + cfg.Name + ".json"
+		runner.Logger = logger
+		sub, err := runner.Subscribe(b)
+		if err != nil {
+			return err
+		}
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			if err := runner.Run(context.Background(), sub); err != nil {
+				logger.Error("Sink stopped", "sink", runner.Name, "error", err)
+				b.Unsubscribe(sub)
+			}
+		}()
+	}
+	return nil
+}
+
+// startIndexer writes blocks into the db_url database.
+func startIndexer(b *broker.Broker, dbURL string, workers *sync.WaitGroup, logger *slog.Logger) (*indexer.Indexer, error) {
+	ix, err := indexer.Open(dbURL)
+	if err != nil {
+		return nil, err
+	}
+	ix.Logger = logger
+	sub, err := ix.Subscribe(context.Background(), b)
+	if err != nil {
+		ix.Close()
+		return nil, err
+	}
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		if err := ix.Run(context.Background(), sub); err != nil {
+			logger.Error("Indexer stopped", "error", err)
+			b.Unsubscribe(sub)
+		}
+	}()
+	return ix, nil
+}
+
+// exportArchive writes the blocks of a backfill to a new archive.
+func exportArchive(follower *chainfollower.ChainFollower, transport rpc.RpcTransportInterface, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	from := flags.Int64("from", 0, "first block height")
+	to := flags.Int64("to", -1, "last block height")
+	hex := flags.Bool("hex", false, "also store serialized blocks (needed for verify_blocks)")
+	chunk := flags.Int("chunk", archive.DEFAULT_CHUNK_SIZE, "blocks per chunk file")
+	flags.Parse(args)
+	if flags.NArg() != 1 || *to < *from {
+		return errors.New("usage: chainfollower export -from <height> -to <height> [-hex] [-chunk <blocks>] <dir>")
+	}
+	w, err := archive.Create(flags.Arg(0), transport)
+	if err != nil {
+		return err
+	}
+	w.Raw, w.ChunkSize = *hex, *chunk
+	err = w.WriteBackfill(follower.Backfill(*from, *to, 1))
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// startStreamServer serves the WebSocket and gRPC streams; clients behind
+// the history are replayed with replay.
+func startStreamServer(cfg config.ServeConfig, replay stream.Replayer, logger *slog.Logger) (*stream.Server, error) {
+	if cfg.WebSocketListen == "" && cfg.GRPCListen == "" {
+		return nil, errors.New("serve: set websocket_listen and/or grpc_listen in [serve]")
+	}
+	server := stream.NewServer()
+	server.Logger = logger
+	server.Replay = replay
+	if cfg.History > 0 {
+		server.History = cfg.History
+	}
+	if cfg.MaxReplays > 0 {
+		server.MaxReplays = cfg.MaxReplays
+	}
+	if cfg.WebSocketListen != "" {
+		mux := http.NewServeMux()
+		server.Register(mux)
+		go func() {
+			logger.Info("Serving WebSocket stream", "listen", cfg.WebSocketListen)
+			log.Fatal(http.ListenAndServe(cfg.WebSocketListen, mux))
+		}()
+	}
+	if cfg.GRPCListen != "" {
+		lis, err := net.Listen("tcp", cfg.GRPCListen)
+		if err != nil {
+			return nil, err
 		}
+		go func() {
+			logger.Info("Serving gRPC stream", "listen", cfg.GRPCListen)
+			log.Fatal(server.NewGRPCServer().Serve(lis))
+		}()
 	}
+	return server, nil
 }
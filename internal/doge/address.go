@@ -0,0 +1,136 @@
+package doge
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/ripemd160"
+)
+
+type AddressType int
+
+const (
+	AddressUnknown AddressType = iota
+	AddressP2PKH               // pay to public key hash ("D..." on mainnet)
+	AddressP2SH                // pay to script hash ("9..." or "A..." on mainnet)
+)
+
+const Hash160Len = 20
+
+var ErrWrongNetwork = errors.New("address is not valid for this network")
+
+func (t AddressType) String() string {
+	switch t {
+	case AddressP2PKH:
+		return "p2pkh"
+	case AddressP2SH:
+		return "p2sh"
+	}
+	return "unknown"
+}
+
+// Hash160 is RIPEMD160(SHA256(data)), used for public key and script hashes.
+func Hash160(data []byte) []byte {
+	sha := sha256.Sum256(data)
+	h := ripemd160.New()
+	h.Write(sha[:])
+	return h.Sum(nil)
+}
+
+func EncodeP2PKH(chain *ChainParams, pubKeyHash []byte) (string, error) {
+	if len(pubKeyHash) != Hash160Len {
+		return "", fmt.Errorf("EncodeP2PKH: hash must be %d bytes", Hash160Len)
+	}
+	return Base58CheckEncode(append([]byte{chain.p2pkh_address_prefix}, pubKeyHash...)), nil
+}
+
+func EncodeP2SH(chain *ChainParams, scriptHash []byte) (string, error) {
+	if len(scriptHash) != Hash160Len {
+		return "", fmt.Errorf("EncodeP2SH: hash must be %d bytes", Hash160Len)
+	}
+	return Base58CheckEncode(append([]byte{chain.p2sh_address_prefix}, scriptHash...)), nil
+}
+
+// P2PKHFromPubKey derives the P2PKH address of a compressed or uncompressed
+// public key (e.g. the output of ECPubKeyFromECPrivKey).
+func P2PKHFromPubKey(chain *ChainParams, pubKey []byte) (string, error) {
+	if !isPubKey(pubKey) {
+		return "", errors.New("P2PKHFromPubKey: invalid public key")
+	}
+	return EncodeP2PKH(chain, Hash160(pubKey))
+}
+
+// P2SHFromScript derives the P2SH address of a redeem script.
+func P2SHFromScript(chain *ChainParams, redeemScript []byte) (string, error) {
+	return EncodeP2SH(chain, Hash160(redeemScript))
+}
+
+// DecodeAddress decodes a base58check address and checks that it belongs to
+// the given network, returning the address type and the 20-byte hash.
+func DecodeAddress(address string, chain *ChainParams) (AddressType, []byte, error) {
+	payload, err := Base58CheckDecode(address)
+	if err != nil {
+		return AddressUnknown, nil, fmt.Errorf("DecodeAddress: %w", err)
+	}
+	if len(payload) != 1+Hash160Len {
+		return AddressUnknown, nil, errors.New("DecodeAddress: wrong length")
+	}
+	hash := payload[1:]
+	switch payload[0] {
+	case chain.p2pkh_address_prefix:
+		return AddressP2PKH, hash, nil
+	case chain.p2sh_address_prefix:
+		return AddressP2SH, hash, nil
+	}
+	return AddressUnknown, nil, ErrWrongNetwork
+}
+
+// ValidateAddress reports whether the address is valid for the network.
+func ValidateAddress(address string, chain *ChainParams) error {
+	_, _, err := DecodeAddress(address, chain)
+	return err
+}
+
+// AddressFromScript derives the address paid by a standard scriptPubKey:
+// P2PKH, P2SH or P2PK (reported as the P2PKH address of the public key).
+func AddressFromScript(chain *ChainParams, script []byte) (string, error) {
+	switch {
+	case len(script) == 25 && script[0] == 0x76 && script[1] == 0xa9 && script[2] == 0x14 && script[23] == 0x88 && script[24] == 0xac:
+		// OP_DUP OP_HASH160 <20> OP_EQUALVERIFY OP_CHECKSIG
+		return EncodeP2PKH(chain, script[3:23])
+	case len(script) == 23 && script[0] == 0xa9 && script[1] == 0x14 && script[22] == 0x87:
+		// OP_HASH160 <20> OP_EQUAL
+		return EncodeP2SH(chain, script[2:22])
+	case len(script) == 35 && script[0] == 33 && script[34] == 0xac,
+		len(script) == 67 && script[0] == 65 && script[66] == 0xac:
+		// <pubkey> OP_CHECKSIG
+		return P2PKHFromPubKey(chain, script[1:len(script)-1])
+	}
+	return "", errors.New("AddressFromScript: not a standard P2PKH, P2SH or P2PK script")
+}
+
+// ScriptForAddress returns the scriptPubKey that pays a P2PKH or P2SH address
+// on the network (the inverse of AddressFromScript).
+func ScriptForAddress(chain *ChainParams, address string) ([]byte, error) {
+	addrType, hash, err := DecodeAddress(address, chain)
+	if err != nil {
+		return nil, err
+	}
+	if addrType == AddressP2SH {
+		// OP_HASH160 <20> OP_EQUAL
+		return append(append([]byte{0xa9, 0x14}, hash...), 0x87), nil
+	}
+	// OP_DUP OP_HASH160 <20> OP_EQUALVERIFY OP_CHECKSIG
+	return append(append([]byte{0x76, 0xa9, 0x14}, hash...), 0x88, 0xac), nil
+}
+
+func isPubKey(pubKey []byte) bool {
+	switch len(pubKey) {
+	case ECPubKeyCompressedLen:
+		return pubKey[0] == 0x02 || pubKey[0] == 0x03
+	case ECPubKeyUncompressedLen:
+		return pubKey[0] == 0x04
+	}
+	return false
+}
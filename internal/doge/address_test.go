@@ -0,0 +1,70 @@
+package doge
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestAddressVectors(t *testing.T) {
+	// https://en.bitcoin.it/wiki/Technical_background_of_version_1_Bitcoin_addresses
+	pubKey, _ := hex.DecodeString("0250863ad64a87ae8a2fe83c1af1a8403cb53f53e486d8511dad8a04887e5b2352")
+	if h := hex.EncodeToString(Hash160(pubKey)); h != "f54a5851e9372b87810a8e60cdd2e7cfd80b6e31" {
+		t.Errorf("Hash160: got %v", h)
+	}
+	addr, err := P2PKHFromPubKey(&BitcoinMainChain, pubKey)
+	if err != nil || addr != "1PMycacnJaSqwwJqjawXBErnLsZ7RkXUAs" {
+		t.Errorf("P2PKHFromPubKey: got %v %v", addr, err)
+	}
+
+	burn, _ := EncodeP2PKH(&BitcoinMainChain, make([]byte, 20))
+	if burn != "1111111111111111111114oLvT2" {
+		t.Errorf("EncodeP2PKH(zero): got %v", burn)
+	}
+}
+
+func TestAddressRoundTrip(t *testing.T) {
+	pub := ECPubKeyFromECPrivKey(bytes.Repeat([]byte{1}, 32))
+	hash := Hash160(pub)
+
+	for _, tc := range []struct {
+		chain  *ChainParams
+		p2pkh  string // expected first character
+		p2shes string
+	}{
+		{&DogeMainNetChain, "D", "9A"},
+		{&DogeTestNetChain, "n", "2"},
+		{&DogeRegTestChain, "mn", "2"},
+	} {
+		addr, err := P2PKHFromPubKey(tc.chain, pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.ContainsAny([]byte(tc.p2pkh), addr[:1]) {
+			t.Errorf("%v: unexpected P2PKH address %v", tc.chain.ChainName, addr)
+		}
+		typ, decoded, err := DecodeAddress(addr, tc.chain)
+		if err != nil || typ != AddressP2PKH || !bytes.Equal(decoded, hash) {
+			t.Errorf("%v: DecodeAddress(%v) = %v %x %v", tc.chain.ChainName, addr, typ, decoded, err)
+		}
+
+		script := append(append([]byte{0xa9, 0x14}, hash...), 0x87)
+		p2sh, err := AddressFromScript(tc.chain, script)
+		if err != nil || !bytes.ContainsAny([]byte(tc.p2shes), p2sh[:1]) {
+			t.Errorf("%v: unexpected P2SH address %v %v", tc.chain.ChainName, p2sh, err)
+		}
+		if typ, _, err := DecodeAddress(p2sh, tc.chain); err != nil || typ != AddressP2SH {
+			t.Errorf("%v: DecodeAddress(%v) = %v %v", tc.chain.ChainName, p2sh, typ, err)
+		}
+	}
+
+	mainAddr, _ := P2PKHFromPubKey(&DogeMainNetChain, pub)
+	if err := ValidateAddress(mainAddr, &DogeTestNetChain); err != ErrWrongNetwork {
+		t.Errorf("expected ErrWrongNetwork, got %v", err)
+	}
+	corrupt := []byte(mainAddr)
+	corrupt[5] ^= 1
+	if err := ValidateAddress(string(corrupt), &DogeMainNetChain); err == nil {
+		t.Errorf("expected checksum error for %s", corrupt)
+	}
+}
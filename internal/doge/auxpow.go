@@ -0,0 +1,229 @@
+package doge
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Merged-mining (AuxPoW) proofs, following Dogecoin Core's src/auxpow.cpp.
+// A merged-mined block's proof-of-work is done on a parent chain block
+// (usually Litecoin) whose coinbase commits to our block hash.
+
+var mergedMiningHeader = []byte{0xfa, 0xbe, 'm', 'm'}
+
+const maxChainMerkleBranch = 30
+
+type AuxPoW struct {
+	CoinbaseTx         []byte   // parent chain coinbase transaction (serialized)
+	CoinbaseScriptSig  []byte   // scriptSig of the coinbase input
+	ParentBlockHash    []byte   // unused by validation
+	MerkleBranch       [][]byte // coinbase -> parent merkle root
+	Index              int32    // must be 0 (coinbase)
+	ChainMerkleBranch  [][]byte // our block hash -> merged-mining root
+	ChainIndex         int32
+	ParentHeader       []byte // 80-byte parent block header
+	parentMerkleRoot   []byte
+	parentChainVersion uint32
+}
+
+// ParseAuxPoW parses the AuxPoW that follows the 80-byte header in a
+// serialized merged-mined block, returning the number of bytes consumed.
+func ParseAuxPoW(data []byte) (*AuxPoW, int, error) {
+	r := &byteReader{data: data}
+	aux := &AuxPoW{}
+
+	txStart := r.pos
+	scriptSig, err := r.skipTransaction()
+	if err != nil {
+		return nil, 0, fmt.Errorf("ParseAuxPoW: coinbase: %w", err)
+	}
+	aux.CoinbaseTx = data[txStart:r.pos]
+	aux.CoinbaseScriptSig = scriptSig
+	aux.ParentBlockHash = r.bytes(32)
+	aux.MerkleBranch = r.hashes()
+	aux.Index = int32(r.uint32())
+	aux.ChainMerkleBranch = r.hashes()
+	aux.ChainIndex = int32(r.uint32())
+	aux.ParentHeader = r.bytes(BlockHeaderLen)
+	if r.err != nil {
+		return nil, 0, fmt.Errorf("ParseAuxPoW: %w", r.err)
+	}
+	aux.parentChainVersion = binary.LittleEndian.Uint32(aux.ParentHeader[0:4])
+	aux.parentMerkleRoot = aux.ParentHeader[36:68]
+	return aux, r.pos, nil
+}
+
+// Check verifies that the AuxPoW commits to `blockHash` (internal byte order)
+// for the given chain ID. The caller must check the block's own chain ID
+// (see BlockVerifier.VerifyBlock) and the parent header's PoW.
+func (a *AuxPoW) Check(chain *ChainParams, blockHash []byte, chainID uint32) error {
+	if a.Index != 0 {
+		return errors.New("AuxPoW: parent transaction is not a coinbase")
+	}
+	if chain.AuxPoWStrictChainID && a.parentChainVersion>>16 == chainID {
+		return errors.New("AuxPoW: parent block has our chain ID")
+	}
+	if len(a.ChainMerkleBranch) > maxChainMerkleBranch {
+		return errors.New("AuxPoW: chain merkle branch too long")
+	}
+
+	rootHash := Reverse(checkMerkleBranch(blockHash, a.ChainMerkleBranch, a.ChainIndex))
+	if !bytes.Equal(checkMerkleBranch(DoubleSha256(a.CoinbaseTx), a.MerkleBranch, a.Index), a.parentMerkleRoot) {
+		return errors.New("AuxPoW: merkle root incorrect")
+	}
+
+	script := a.CoinbaseScriptSig
+	head := bytes.Index(script, mergedMiningHeader)
+	pc := bytes.Index(script, rootHash)
+	if pc < 0 {
+		return errors.New("AuxPoW: missing chain merkle root in parent coinbase")
+	}
+	if head >= 0 {
+		if bytes.Contains(script[head+1:], mergedMiningHeader) {
+			return errors.New("AuxPoW: multiple merged mining headers in coinbase")
+		}
+		if head+len(mergedMiningHeader) != pc {
+			return errors.New("AuxPoW: merged mining header is not just before chain merkle root")
+		}
+	} else if pc > 20 {
+		return errors.New("AuxPoW: chain merkle root must start in the first 20 bytes of the parent coinbase")
+	}
+
+	pc += len(rootHash)
+	if len(script)-pc < 8 {
+		return errors.New("AuxPoW: missing chain merkle tree size and nonce in parent coinbase")
+	}
+	size := binary.LittleEndian.Uint32(script[pc:])
+	height := uint(len(a.ChainMerkleBranch))
+	if size != 1<<height {
+		return errors.New("AuxPoW: merkle branch size does not match parent coinbase")
+	}
+	nonce := binary.LittleEndian.Uint32(script[pc+4:])
+	if uint32(a.ChainIndex) != expectedChainIndex(nonce, chainID, height) {
+		return errors.New("AuxPoW: wrong chain index")
+	}
+	return nil
+}
+
+func checkMerkleBranch(hash []byte, branch [][]byte, index int32) []byte {
+	if index == -1 {
+		return make([]byte, 32)
+	}
+	for _, other := range branch {
+		if index&1 != 0 {
+			hash = DoubleSha256(append(append([]byte(nil), other...), hash...))
+		} else {
+			hash = DoubleSha256(append(append([]byte(nil), hash...), other...))
+		}
+		index >>= 1
+	}
+	return hash
+}
+
+// expectedChainIndex chooses the slot in the merged-mining merkle tree
+// (a fixed pseudo-random function of the nonce and chain ID).
+func expectedChainIndex(nonce uint32, chainID uint32, height uint) uint32 {
+	rand := nonce
+	rand = rand*1103515245 + 12345
+	rand += chainID
+	rand = rand*1103515245 + 12345
+	return rand % (1 << height)
+}
+
+// byteReader reads Core's serialization format; the first error sticks.
+type byteReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *byteReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.pos+n > len(r.data) {
+		r.err = errors.New("unexpected end of data")
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *byteReader) uint32() uint32 {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (r *byteReader) varint() uint64 {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	switch b[0] {
+	case 0xfd:
+		if v := r.bytes(2); v != nil {
+			return uint64(binary.LittleEndian.Uint16(v))
+		}
+	case 0xfe:
+		if v := r.bytes(4); v != nil {
+			return uint64(binary.LittleEndian.Uint32(v))
+		}
+	case 0xff:
+		if v := r.bytes(8); v != nil {
+			return binary.LittleEndian.Uint64(v)
+		}
+	default:
+		return uint64(b[0])
+	}
+	return 0
+}
+
+func (r *byteReader) count() int {
+	n := r.varint()
+	if r.err == nil && n > uint64(len(r.data)-r.pos) {
+		r.err = errors.New("count exceeds data")
+		return 0
+	}
+	return int(n)
+}
+
+func (r *byteReader) hashes() [][]byte {
+	n := r.count()
+	hashes := make([][]byte, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		hashes = append(hashes, r.bytes(32))
+	}
+	return hashes
+}
+
+// skipTransaction reads a (non-witness) transaction, returning the scriptSig
+// of its first input.
+func (r *byteReader) skipTransaction() ([]byte, error) {
+	var firstScript []byte
+	r.bytes(4) // version
+	nIn := r.count()
+	if r.err == nil && nIn == 0 {
+		return nil, errors.New("transaction has no inputs")
+	}
+	for i := 0; i < nIn && r.err == nil; i++ {
+		r.bytes(36) // prevout
+		script := r.bytes(r.count())
+		if i == 0 {
+			firstScript = script
+		}
+		r.bytes(4) // sequence
+	}
+	nOut := r.count()
+	for i := 0; i < nOut && r.err == nil; i++ {
+		r.bytes(8) // value
+		r.bytes(r.count())
+	}
+	r.bytes(4) // locktime
+	return firstScript, r.err
+}
@@ -0,0 +1,90 @@
+package doge
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var ErrBadChecksum = errors.New("base58check: checksum mismatch")
+
+var base58Index = func() (index [256]int8) {
+	for i := range index {
+		index[i] = -1
+	}
+	for i, ch := range base58Alphabet {
+		index[ch] = int8(i)
+	}
+	return
+}()
+
+func Base58Encode(data []byte) string {
+	zeros := 0
+	for zeros < len(data) && data[zeros] == 0 {
+		zeros++
+	}
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	out := make([]byte, 0, len(data)*138/100+1)
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for i := 0; i < zeros; i++ {
+		out = append(out, '1')
+	}
+	// reverse (digits were produced least-significant first)
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func Base58Decode(s string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for i := 0; i < len(s); i++ {
+		digit := base58Index[s[i]]
+		if digit < 0 {
+			return nil, errors.New("base58: invalid character")
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+func checksum(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:4]
+}
+
+// Base58CheckEncode appends a 4-byte double-SHA256 checksum and encodes.
+func Base58CheckEncode(payload []byte) string {
+	return Base58Encode(append(append([]byte(nil), payload...), checksum(payload)...))
+}
+
+// Base58CheckDecode decodes and verifies the checksum, returning the payload.
+func Base58CheckDecode(s string) ([]byte, error) {
+	data, err := Base58Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 5 {
+		return nil, errors.New("base58check: too short")
+	}
+	payload, sum := data[:len(data)-4], data[len(data)-4:]
+	if !bytes.Equal(checksum(payload), sum) {
+		return nil, ErrBadChecksum
+	}
+	return payload, nil
+}
@@ -0,0 +1,231 @@
+package doge
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// BIP32 hierarchical deterministic keys (dgpv/dgub on mainnet, tprv/tpub on
+// testnet and regtest).
+
+const (
+	HardenedKeyStart   = 0x80000000 // child numbers >= this are hardened
+	bip32SerializedLen = 78
+)
+
+var (
+	ErrHardenedFromPublic = errors.New("bip32: cannot derive a hardened child from a public key")
+	ErrInvalidChild       = errors.New("bip32: invalid child key (try the next index)")
+	ErrDeriveTooDeep      = errors.New("bip32: depth limit exceeded")
+)
+
+type ExtendedKey struct {
+	Chain       *ChainParams
+	Depth       byte
+	ParentFP    [4]byte
+	ChildNumber uint32
+	ChainCode   [32]byte
+	Key         []byte // 32-byte private key, or 33-byte compressed public key
+	IsPrivate   bool
+}
+
+// NewMasterKey derives the master key from a seed (16 to 64 bytes).
+func NewMasterKey(chain *ChainParams, seed []byte) (*ExtendedKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, errors.New("bip32: seed must be 16 to 64 bytes")
+	}
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	I := mac.Sum(nil)
+	if !ECKeyIsValid(I[:32]) {
+		return nil, ErrInvalidChild
+	}
+	key := &ExtendedKey{Chain: chain, Key: append([]byte(nil), I[:32]...), IsPrivate: true}
+	copy(key.ChainCode[:], I[32:])
+	return key, nil
+}
+
+// DecodeExtendedKey parses a base58check-encoded extended key. The version
+// bytes must match one of the known Dogecoin networks; testnet and regtest
+// share tprv/tpub, so `chain` selects between networks with the same version
+// (pass nil to accept the first match).
+func DecodeExtendedKey(s string, chain *ChainParams) (*ExtendedKey, error) {
+	data, err := Base58CheckDecode(s)
+	if err != nil {
+		return nil, fmt.Errorf("bip32: %w", err)
+	}
+	if len(data) != bip32SerializedLen {
+		return nil, errors.New("bip32: wrong length")
+	}
+	version := binary.BigEndian.Uint32(data[0:4])
+	key := &ExtendedKey{
+		Depth:       data[4],
+		ChildNumber: binary.BigEndian.Uint32(data[9:13]),
+	}
+	copy(key.ParentFP[:], data[5:9])
+	copy(key.ChainCode[:], data[13:45])
+
+	candidates := []*ChainParams{chain}
+	if chain == nil {
+		candidates = Chains()
+	}
+	for _, c := range candidates {
+		if version == c.bip32_privkey_prefix || version == c.bip32_pubkey_prefix {
+			key.Chain, key.IsPrivate = c, version == c.bip32_privkey_prefix
+			break
+		}
+	}
+	if key.Chain == nil {
+		return nil, fmt.Errorf("bip32: unknown version bytes %08x", version)
+	}
+
+	if key.IsPrivate {
+		if data[45] != 0 || !ECKeyIsValid(data[46:78]) {
+			return nil, errors.New("bip32: invalid private key")
+		}
+		key.Key = append([]byte(nil), data[46:78]...)
+	} else {
+		if _, err := secp256k1.ParsePubKey(data[45:78]); err != nil {
+			return nil, fmt.Errorf("bip32: invalid public key: %v", err)
+		}
+		key.Key = append([]byte(nil), data[45:78]...)
+	}
+	if key.Depth == 0 && (key.ParentFP != [4]byte{} || key.ChildNumber != 0) {
+		return nil, errors.New("bip32: master key with non-zero parent or child number")
+	}
+	return key, nil
+}
+
+// String encodes the key in base58check (dgpv/dgub, tprv/tpub).
+func (k *ExtendedKey) String() string {
+	data := make([]byte, 0, bip32SerializedLen)
+	if k.IsPrivate {
+		data = binary.BigEndian.AppendUint32(data, k.Chain.bip32_privkey_prefix)
+	} else {
+		data = binary.BigEndian.AppendUint32(data, k.Chain.bip32_pubkey_prefix)
+	}
+	data = append(data, k.Depth)
+	data = append(data, k.ParentFP[:]...)
+	data = binary.BigEndian.AppendUint32(data, k.ChildNumber)
+	data = append(data, k.ChainCode[:]...)
+	if k.IsPrivate {
+		data = append(data, 0)
+	}
+	data = append(data, k.Key...)
+	return Base58CheckEncode(data)
+}
+
+// PubKey returns the 33-byte compressed public key.
+func (k *ExtendedKey) PubKey() ECPubKeyCompressed {
+	if k.IsPrivate {
+		return ECPubKeyFromECPrivKey(k.Key)
+	}
+	return k.Key
+}
+
+// Fingerprint is the first 4 bytes of Hash160(public key).
+func (k *ExtendedKey) Fingerprint() [4]byte {
+	var fp [4]byte
+	copy(fp[:], Hash160(k.PubKey()))
+	return fp
+}
+
+// Neuter returns the public (watch-only) version of the key.
+func (k *ExtendedKey) Neuter() *ExtendedKey {
+	if !k.IsPrivate {
+		return k
+	}
+	n := *k
+	n.IsPrivate = false
+	n.Key = k.PubKey()
+	return &n
+}
+
+// Address returns the P2PKH address of the key.
+func (k *ExtendedKey) Address() (string, error) {
+	return P2PKHFromPubKey(k.Chain, k.PubKey())
+}
+
+// Child derives child key `index`. Public keys can only derive non-hardened
+// children (index < HardenedKeyStart).
+func (k *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	if k.Depth == 255 {
+		return nil, ErrDeriveTooDeep
+	}
+	hardened := index >= HardenedKeyStart
+	if hardened && !k.IsPrivate {
+		return nil, ErrHardenedFromPublic
+	}
+
+	mac := hmac.New(sha512.New, k.ChainCode[:])
+	if hardened {
+		mac.Write([]byte{0})
+		mac.Write(k.Key)
+	} else {
+		mac.Write(k.PubKey())
+	}
+	mac.Write(binary.BigEndian.AppendUint32(nil, index))
+	I := mac.Sum(nil)
+
+	var il secp256k1.ModNScalar
+	if overflow := il.SetByteSlice(I[:32]); overflow {
+		return nil, ErrInvalidChild
+	}
+
+	child := &ExtendedKey{
+		Chain:       k.Chain,
+		Depth:       k.Depth + 1,
+		ParentFP:    k.Fingerprint(),
+		ChildNumber: index,
+		IsPrivate:   k.IsPrivate,
+	}
+	copy(child.ChainCode[:], I[32:])
+
+	if k.IsPrivate {
+		// child = IL + parent (mod n)
+		var parent secp256k1.ModNScalar
+		parent.SetByteSlice(k.Key)
+		il.Add(&parent)
+		parent.Zero() // clear key for security.
+		if il.IsZero() {
+			return nil, ErrInvalidChild
+		}
+		key := il.Bytes()
+		child.Key = key[:]
+		il.Zero()
+	} else {
+		// child = point(IL) + parent
+		pub, err := secp256k1.ParsePubKey(k.Key)
+		if err != nil {
+			return nil, err
+		}
+		var parentPoint, ilPoint, sum secp256k1.JacobianPoint
+		pub.AsJacobian(&parentPoint)
+		secp256k1.ScalarBaseMultNonConst(&il, &ilPoint)
+		secp256k1.AddNonConst(&ilPoint, &parentPoint, &sum)
+		if (sum.X.IsZero() && sum.Y.IsZero()) || sum.Z.IsZero() {
+			return nil, ErrInvalidChild
+		}
+		sum.ToAffine()
+		child.Key = secp256k1.NewPublicKey(&sum.X, &sum.Y).SerializeCompressed()
+	}
+	return child, nil
+}
+
+// DerivePath derives a sequence of child indexes, e.g. {0, 5} for m/0/5.
+func (k *ExtendedKey) DerivePath(path ...uint32) (*ExtendedKey, error) {
+	key := k
+	for _, index := range path {
+		var err error
+		key, err = key.Child(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,80 @@
+package doge
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// BIP32 test vector 1 (https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki)
+func TestBip32Vector1(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := NewMasterKey(&BitcoinMainChain, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := master.String(); s != "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi" {
+		t.Errorf("master xprv: got %v", s)
+	}
+	if s := master.Neuter().String(); s != "xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8" {
+		t.Errorf("master xpub: got %v", s)
+	}
+
+	// m/0H (hardened, private derivation)
+	child, err := master.Child(HardenedKeyStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := child.String(); s != "xprv9uHRZZhk6KAJC1avXpDAp4MDc3sQKNxDiPvvkX8Br5ngLNv1TxvUxt4cV1rGL5hj6KCesnDYUhd7oWgT11eZG7XnxHrnYeSvkzY7d2bhkJ7" {
+		t.Errorf("m/0H xprv: got %v", s)
+	}
+
+	// m/0H/1 via public derivation from the m/0H xpub.
+	xpub, err := DecodeExtendedKey("xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw", &BitcoinMainChain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubChild, err := xpub.Child(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := pubChild.String(); s != "xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ" {
+		t.Errorf("m/0H/1 xpub: got %v", s)
+	}
+
+	// private and public derivation agree.
+	privChild, _ := child.Child(1)
+	if privChild.Neuter().String() != pubChild.String() {
+		t.Errorf("private and public derivation disagree")
+	}
+
+	if _, err := xpub.Child(HardenedKeyStart); err != ErrHardenedFromPublic {
+		t.Errorf("expected ErrHardenedFromPublic, got %v", err)
+	}
+}
+
+func TestBip32DogeRoundTrip(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, _ := NewMasterKey(&DogeMainNetChain, seed)
+
+	dgpv := master.String()
+	dgub := master.Neuter().String()
+	if dgpv[:4] != "dgpv" || dgub[:4] != "dgub" {
+		t.Fatalf("unexpected prefixes: %v %v", dgpv, dgub)
+	}
+	key, err := DecodeExtendedKey(dgub, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Chain != &DogeMainNetChain || key.IsPrivate || key.String() != dgub {
+		t.Errorf("dgub did not round-trip")
+	}
+
+	test, _ := NewMasterKey(&DogeTestNetChain, seed)
+	tpub := test.Neuter().String()
+	if tpub[:4] != "tpub" {
+		t.Fatalf("unexpected prefix: %v", tpub)
+	}
+	if _, err := DecodeExtendedKey(tpub, &DogeMainNetChain); err == nil {
+		t.Errorf("expected error decoding tpub as mainnet")
+	}
+}
@@ -2,10 +2,13 @@ package doge
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 )
 
 type ChainParams struct {
 	ChainName                string
+	Network                  string // Core network name reported by getblockchaininfo
 	GenesisBlock             string
 	p2pkh_address_prefix     byte
 	p2sh_address_prefix      byte
@@ -14,10 +17,14 @@ type ChainParams struct {
 	bip32_pubkey_prefix      uint32
 	Bip32_WIF_PrivKey_Prefix string
 	Bip32_WIF_PubKey_Prefix  string
+	Checkpoints              map[int64]string // known block hashes by height (see checkpoints.go)
+	PowLimitBits             uint32           // easiest allowed proof-of-work target (compact form)
+	AuxPoWStrictChainID      bool             // blocks (other than version 1 and 2) must use our chain ID
 }
 
 var DogeMainNetChain ChainParams = ChainParams{
 	ChainName:                "doge_main",
+	Network:                  "main",
 	GenesisBlock:             "1a91e3dace36e2be3bf030a65679fe821aa1d6ef92e7c9902eb318182c355691",
 	p2pkh_address_prefix:     0x1e,       // D
 	p2sh_address_prefix:      0x16,       // 9 or A
@@ -26,10 +33,14 @@ var DogeMainNetChain ChainParams = ChainParams{
 	bip32_pubkey_prefix:      0x02facafd, // dgub
 	Bip32_WIF_PrivKey_Prefix: "dgpv",
 	Bip32_WIF_PubKey_Prefix:  "dgub",
+	Checkpoints:              dogeMainNetCheckpoints,
+	PowLimitBits:             0x1e0fffff,
+	AuxPoWStrictChainID:      true,
 }
 
 var DogeTestNetChain ChainParams = ChainParams{
 	ChainName:                "doge_test",
+	Network:                  "test",
 	GenesisBlock:             "bb0a78264637406b6360aad926284d544d7049f45189db5664f3c4d07350559e",
 	p2pkh_address_prefix:     0x71,       // n
 	p2sh_address_prefix:      0xc4,       // 2
@@ -38,18 +49,25 @@ var DogeTestNetChain ChainParams = ChainParams{
 	bip32_pubkey_prefix:      0x043587cf, // tpub
 	Bip32_WIF_PrivKey_Prefix: "tprv",
 	Bip32_WIF_PubKey_Prefix:  "tpub",
+	Checkpoints:              dogeTestNetCheckpoints,
+	PowLimitBits:             0x1e0fffff,
+	AuxPoWStrictChainID:      false,
 }
 
 var DogeRegTestChain ChainParams = ChainParams{
 	ChainName:                "doge_regtest",
+	Network:                  "regtest",
 	GenesisBlock:             "3d2160a3b5dc4a9d62e7e66a295f70313ac808440ef7400d6c0772171ce973a5",
 	p2pkh_address_prefix:     0x6f,       // n
 	p2sh_address_prefix:      0xc4,       // 2
-	pkey_prefix:              0xef,       //
+	pkey_prefix:              0xef,       // 9 or c
 	bip32_privkey_prefix:     0x04358394, // tprv
 	bip32_pubkey_prefix:      0x043587cf, // tpub
 	Bip32_WIF_PrivKey_Prefix: "tprv",
 	Bip32_WIF_PubKey_Prefix:  "tpub",
+	Checkpoints:              dogeRegTestCheckpoints,
+	PowLimitBits:             0x207fffff,
+	AuxPoWStrictChainID:      true,
 }
 
 // Used in tests only.
@@ -59,10 +77,10 @@ var BitcoinMainChain ChainParams = ChainParams{
 	p2pkh_address_prefix:     0x00,       // 1
 	p2sh_address_prefix:      0x05,       // 3
 	pkey_prefix:              0x80,       // 5H,5J,5K
-	bip32_privkey_prefix:     0x0488ADE4, //
-	bip32_pubkey_prefix:      0x0488B21E, //
-	Bip32_WIF_PrivKey_Prefix: "xxxx",     // TODO
-	Bip32_WIF_PubKey_Prefix:  "xxxx",     // TODO
+	bip32_privkey_prefix:     0x0488ADE4, // xprv
+	bip32_pubkey_prefix:      0x0488B21E, // xpub
+	Bip32_WIF_PrivKey_Prefix: "xprv",
+	Bip32_WIF_PubKey_Prefix:  "xpub",
 }
 
 func ChainFromTestNetFlag(isTestNet bool) *ChainParams {
@@ -94,78 +112,67 @@ func KeyBitsForChain(chain *ChainParams) KeyBits {
 	return bits
 }
 
-// CAUTION: the result is a best-guess based on the 'version byte' in
-// the WIF string. Do not rely on the returned ChainParams alone
-// for validation: it will fall back on DogeTestNetChain for unknown
-// version bytes (so verify the version byte or bip32-prefix as well)
-func ChainFromWIFString(wif string) *ChainParams {
-	switch wif[0] {
-	case 'D', '9', 'A', 'Q', '6', 'd':
-		// FIXME: '9' is ambiguous, check 2nd character over the entire range.
-		return &DogeMainNetChain
-	case 'n', '2', 'c', 't': // also '9'
-		return &DogeTestNetChain
-	case '1', '3', '5':
-		return &BitcoinMainChain
-	default:
-		return &DogeTestNetChain
+// ChainFromWIFString decodes a WIF private key (checking its checksum)
+// and returns the network identified by its version byte.
+func ChainFromWIFString(wif string) (*ChainParams, error) {
+	key, err := DecodeWIF(wif, nil)
+	if err != nil {
+		return nil, err
 	}
+	return key.Chain, nil
 }
 
-// CAUTION: the result is a best-guess based on the 'version byte' in
-// the decoded WIF data. Do not rely on the returned ChainParams alone
-// for validation: it will fall back on DogeTestNetChain for unknown
-// version bytes (so verify the version byte or bip32-prefix as well)
-func ChainFromWIFPrefix(bytes []byte, allowNonDoge bool) *ChainParams {
+// ChainFromWIFPrefix returns the network of decoded WIF data from its
+// version byte. Unknown version bytes (and Bitcoin's unless allowNonDoge)
+// are an error. Networks sharing a version byte resolve to the first
+// registered (pass the chain to DecodeWIF instead).
+func ChainFromWIFPrefix(bytes []byte, allowNonDoge bool) (*ChainParams, error) {
 	if len(bytes) == 0 {
-		return &DogeTestNetChain // fallback
+		return nil, fmt.Errorf("wif: %w", ErrUnknownVersion)
 	}
-	switch bytes[0] {
-	case 0x1e, 0x16, 0x9e, 0x02:
-		return &DogeMainNetChain
-	case 0x71, 0xc4, 0xf1:
-		return &DogeTestNetChain
-	case 0x04:
-		if allowNonDoge {
-			// 0x04 is ambigous (DogeTestNetChain vs BitcoinMainChain)
-			if len(bytes) > 1 && bytes[1] == 0x88 {
-				return &BitcoinMainChain
-			}
+	for _, chain := range Chains() {
+		if bytes[0] == chain.pkey_prefix {
+			return chain, nil
 		}
-		return &DogeTestNetChain
-	case 0x6f, 0xef:
-		return &DogeRegTestChain
-	case 0x00, 0x05, 0x80:
-		if allowNonDoge {
-			return &BitcoinMainChain
+	}
+	if allowNonDoge && bytes[0] == BitcoinMainChain.pkey_prefix {
+		return &BitcoinMainChain, nil
+	}
+	return nil, fmt.Errorf("wif: %w %#02x", ErrUnknownVersion, bytes[0])
+}
+
+// ChainFromBip32Version returns the network of a BIP32 extended key version.
+// Testnet and regtest share tprv/tpub; those are reported as testnet.
+func ChainFromBip32Version(version uint32, allowNonDoge bool) (*ChainParams, error) {
+	for _, chain := range Chains() {
+		if version == chain.bip32_privkey_prefix || version == chain.bip32_pubkey_prefix {
+			return chain, nil
 		}
 	}
-	return &DogeTestNetChain // fallback
+	if allowNonDoge && (version == BitcoinMainChain.bip32_privkey_prefix || version == BitcoinMainChain.bip32_pubkey_prefix) {
+		return &BitcoinMainChain, nil
+	}
+	return nil, fmt.Errorf("bip32: %w %08x", ErrUnknownVersion, version)
 }
 
-func ChainFromBip32Version(version uint32, allowNonDoge bool) *ChainParams {
-	switch version {
-	case 0x02fac398, 0x02facafd: // dgpv, dgub
-		return &DogeMainNetChain
-	case 0x04358394, 0x043587cf: // tprv, tpub
-		return &DogeMainNetChain
-	case 0x0488ADE4, 0x0488B21E: // bitcoin mainnet
-		if allowNonDoge {
-			return &BitcoinMainChain
+// ChainFromName accepts a ChainName or a Core network name (main, test, regtest)
+// as reported by getblockchaininfo. Custom chains sharing a Core network name
+// with a built-in chain are only found by ChainName.
+func ChainFromName(name string) (*ChainParams, error) {
+	for _, chain := range Chains() {
+		if name == chain.Network || name == chain.ChainName {
+			return chain, nil
 		}
 	}
-	return &DogeTestNetChain // fallback
+	return nil, errors.New("ChainFromName: unrecognised chain: " + name)
 }
 
 func ChainFromGenesisHash(hash string) (*ChainParams, error) {
-	if hash == DogeMainNetChain.GenesisBlock {
-		return &DogeMainNetChain, nil
-	}
-	if hash == DogeTestNetChain.GenesisBlock {
-		return &DogeTestNetChain, nil
-	}
-	if hash == DogeRegTestChain.GenesisBlock {
-		return &DogeRegTestChain, nil
+	hash = strings.ToLower(hash)
+	for _, chain := range Chains() {
+		if hash == chain.GenesisBlock {
+			return chain, nil
+		}
 	}
 	return nil, errors.New("ChainFromGenesisHash: unrecognised chain: " + hash)
 }
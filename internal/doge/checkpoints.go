@@ -0,0 +1,53 @@
+package doge
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Checkpoints: block hashes at known heights. A Core node that reports a
+// different hash at one of these heights is on a divergent (fake or forked)
+// history. The mainnet table is Dogecoin Core's (src/chainparams.cpp); the
+// testnet and regtest tables only pin the genesis block (add more with
+// chainfollower.WithCheckpoints or [follower.checkpoints]).
+
+var dogeMainNetCheckpoints = map[int64]string{
+	0:       "1a91e3dace36e2be3bf030a65679fe821aa1d6ef92e7c9902eb318182c355691",
+	104679:  "35eb87ae90d44b98898fec8c39577b76cb1eb08e1261cfc10706c8ce9a1d01cf",
+	145000:  "cc47cae70d7c5c92828d3214a266331dde59087d4a39071fa76ddfff9b7bde72",
+	371337:  "60323982f9c5ff1b5a954eac9dc1269352835f47c2c5222691d80f0d50dcf053",
+	450000:  "d279277f8f846a224d776450aa04da3cf978991a182c6f3075db4c48b173bbd7",
+	771275:  "1b7d789ed82cbdc640952e7e7a54966c6488a32eaad54fc39dff83f310dbaaed",
+	1000000: "6aae55bea74235f0c80bd066349d4440c31f2d0f27d54265ecd484d8c1d11b47",
+	1250000: "00c7a442055c1a990e11eea5371ca5c1c02a0677b33cc88ec728c45edc4ec060",
+	1500000: "f1d32d6920de7b617d51e74bdf4e58adccaa582ffdc8657464454f16a952fca6",
+	1750000: "5c8e7327984f0d6f59447d89d143e5f6eafc524c82ad95d176c5cec082ae2001",
+	2000000: "9914f0e82e39bbf21950792e8816620d71b9965bdbbc14e72a95e3ab9618fea8",
+	2031142: "893297d89afb7599a3c571ca31a3b80e8353f4cf39872400ad0f57d26c4c5d42",
+	2510150: "77e3f4a4bcb4a2c15e8015525e3d15b466f6c022f6ca82698f329edef7d9777e",
+}
+
+var dogeTestNetCheckpoints = map[int64]string{
+	0: "bb0a78264637406b6360aad926284d544d7049f45189db5664f3c4d07350559e",
+}
+
+var dogeRegTestCheckpoints = map[int64]string{
+	0: "3d2160a3b5dc4a9d62e7e66a295f70313ac808440ef7400d6c0772171ce973a5",
+}
+
+// MergeCheckpoints returns the chain's checkpoints plus extra checkpoints.
+// Extra checkpoints must not contradict the built-in ones.
+func (c *ChainParams) MergeCheckpoints(extra map[int64]string) (map[int64]string, error) {
+	merged := make(map[int64]string, len(c.Checkpoints)+len(extra))
+	for height, hash := range c.Checkpoints {
+		merged[height] = hash
+	}
+	for height, hash := range extra {
+		hash = strings.ToLower(hash)
+		if known, ok := merged[height]; ok && known != hash {
+			return nil, fmt.Errorf("MergeCheckpoints: checkpoint at height %d contradicts %v: %v vs %v", height, c.ChainName, hash, known)
+		}
+		merged[height] = hash
+	}
+	return merged, nil
+}
@@ -0,0 +1,41 @@
+package doge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// Double-SHA256 as used for block hashes, txids and merkle trees.
+func DoubleSha256(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+// Reverse returns a reversed copy of the bytes (hashes are displayed
+// in reverse byte order by Core).
+func Reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i, v := range b {
+		r[len(b)-1-i] = v
+	}
+	return r
+}
+
+// HexToHash decodes a Core display-order hash into internal byte order.
+func HexToHash(s string) ([]byte, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != 32 {
+		return nil, errors.New("HexToHash: hash must be 32 bytes")
+	}
+	return Reverse(b), nil
+}
+
+// HashToHex encodes an internal byte order hash in Core display order.
+func HashToHex(hash []byte) string {
+	return hex.EncodeToString(Reverse(hash))
+}
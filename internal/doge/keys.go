@@ -56,6 +56,13 @@ func ECPubKeyFromECPrivKey(pk ECPrivKey) ECPubKeyCompressed {
 	return pub
 }
 
+func ECPubKeyUncompressedFromECPrivKey(pk ECPrivKey) ECPubKeyUncompressed {
+	key := secp256k1.PrivKeyFromBytes(pk)
+	pub := key.PubKey().SerializeUncompressed()
+	key.Zero() // clear key for security.
+	return pub
+}
+
 func ECKeyIsValid(pk ECPrivKey) bool {
 	if len(pk) != ECPrivKeyLen {
 		return false
@@ -0,0 +1,110 @@
+package doge
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// Dogecoin Core "signed messages" (signmessage / verifymessage RPCs):
+// a base64 compact recoverable ECDSA signature over
+// DoubleSha256(varstr(MessageMagic) || varstr(message)).
+
+const MessageMagic = "Dogecoin Signed Message:\n"
+
+var ErrMessageSignature = errors.New("message signature does not match address")
+
+// MessageHash returns the hash that is signed for a message.
+func MessageHash(message string) []byte {
+	return messageHash(MessageMagic, message)
+}
+
+// messageHash is MessageHash with another magic (Bitcoin Core's differs).
+func messageHash(magic string, message string) []byte {
+	data := make([]byte, 0, 1+len(magic)+9+len(message))
+	data = AppendVarString(data, magic)
+	data = AppendVarString(data, message)
+	return DoubleSha256(data)
+}
+
+// SignMessage signs a message with a private key, returning the base64
+// signature. `compressed` must match the key's address (see WIFKey.Compressed).
+func SignMessage(privKey ECPrivKey, compressed bool, message string) (string, error) {
+	if !ECKeyIsValid(privKey) {
+		return "", errors.New("SignMessage: invalid private key")
+	}
+	key := secp256k1.PrivKeyFromBytes(privKey)
+	sig := ecdsa.SignCompact(key, MessageHash(message), compressed)
+	key.Zero() // clear key for security.
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// RecoverMessagePubKey recovers the public key that signed a message, in the
+// serialization (compressed or not) the signer used.
+func RecoverMessagePubKey(signature string, message string) ([]byte, error) {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, fmt.Errorf("RecoverMessagePubKey: signature is not base64: %v", err)
+	}
+	pub, compressed, err := ecdsa.RecoverCompact(sig, MessageHash(message))
+	if err != nil {
+		return nil, fmt.Errorf("RecoverMessagePubKey: %w", err)
+	}
+	if compressed {
+		return pub.SerializeCompressed(), nil
+	}
+	return pub.SerializeUncompressed(), nil
+}
+
+// RecoverMessageAddress returns the P2PKH address that signed a message.
+func RecoverMessageAddress(chain *ChainParams, signature string, message string) (string, error) {
+	pub, err := RecoverMessagePubKey(signature, message)
+	if err != nil {
+		return "", err
+	}
+	return P2PKHFromPubKey(chain, pub)
+}
+
+// VerifyMessage checks that `signature` over `message` was made by the key
+// of a P2PKH `address` (like Core's verifymessage).
+func VerifyMessage(chain *ChainParams, address string, signature string, message string) error {
+	addrType, _, err := DecodeAddress(address, chain)
+	if err != nil {
+		return err
+	}
+	if addrType != AddressP2PKH {
+		return errors.New("VerifyMessage: address does not refer to a key")
+	}
+	signer, err := RecoverMessageAddress(chain, signature, message)
+	if err != nil {
+		return err
+	}
+	if signer != address {
+		return ErrMessageSignature
+	}
+	return nil
+}
+
+// AppendVarString appends a Core compact-size length prefix and the string.
+func AppendVarString(data []byte, s string) []byte {
+	data = AppendVarInt(data, uint64(len(s)))
+	return append(data, s...)
+}
+
+// AppendVarInt appends a Core compact-size integer.
+func AppendVarInt(data []byte, n uint64) []byte {
+	switch {
+	case n < 0xfd:
+		return append(data, byte(n))
+	case n <= 0xffff:
+		return binary.LittleEndian.AppendUint16(append(data, 0xfd), uint16(n))
+	case n <= 0xffffffff:
+		return binary.LittleEndian.AppendUint32(append(data, 0xfe), uint32(n))
+	default:
+		return binary.LittleEndian.AppendUint64(append(data, 0xff), n)
+	}
+}
@@ -0,0 +1,102 @@
+package doge
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+func TestSignVerifyMessage(t *testing.T) {
+	privKey, _ := hex.DecodeString(wifTestKey)
+	message := "I control this address"
+
+	for _, compressed := range []bool{true, false} {
+		wif, _ := EncodeWIF(&DogeMainNetChain, privKey, compressed)
+		key, _ := DecodeWIF(wif, nil)
+		addr, _ := key.Address()
+
+		sig, err := SignMessage(key.PrivKey, key.Compressed, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, _ := base64.StdEncoding.DecodeString(sig)
+		if len(raw) != 65 {
+			t.Fatalf("expected a 65-byte compact signature, got %d", len(raw))
+		}
+		if err := VerifyMessage(&DogeMainNetChain, addr, sig, message); err != nil {
+			t.Errorf("compressed=%v: %v", compressed, err)
+		}
+		if err := VerifyMessage(&DogeMainNetChain, addr, sig, message+"!"); err != ErrMessageSignature {
+			t.Errorf("compressed=%v: expected ErrMessageSignature for a different message, got %v", compressed, err)
+		}
+		if signer, err := RecoverMessageAddress(&DogeMainNetChain, sig, message); err != nil || signer != addr {
+			t.Errorf("compressed=%v: recovered %v, %v", compressed, signer, err)
+		}
+	}
+
+	// the compressed and uncompressed addresses are different keys to verifymessage.
+	sig, _ := SignMessage(privKey, true, message)
+	uncompressed, _ := P2PKHFromPubKey(&DogeMainNetChain, ECPubKeyUncompressedFromECPrivKey(privKey))
+	if err := VerifyMessage(&DogeMainNetChain, uncompressed, sig, message); err != ErrMessageSignature {
+		t.Errorf("expected ErrMessageSignature for the uncompressed address, got %v", err)
+	}
+
+	testnet, _ := P2PKHFromPubKey(&DogeTestNetChain, ECPubKeyFromECPrivKey(privKey))
+	if err := VerifyMessage(&DogeMainNetChain, testnet, sig, message); err == nil {
+		t.Error("expected error for a testnet address")
+	}
+	if err := VerifyMessage(&DogeMainNetChain, uncompressed, "not base64!", message); err == nil {
+		t.Error("expected error for a malformed signature")
+	}
+}
+
+// Dogecoin Core's signmessage is Bitcoin Core's with another magic, so a
+// Bitcoin signed-message vector (from bitcoinjs-message, matching Bitcoin
+// Core's output) checks the hash, the deterministic (RFC 6979) signature and
+// the key recovery independently of this package.
+func TestMessageVector(t *testing.T) {
+	const (
+		wif     = "5KYZdUEo39z3FPrtuX2QbbwGnNP5zTd7yyr2SC1j299sBCnWjss"
+		address = "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV" // compressed key
+		message = "This is an example of a signed message."
+		sig     = "H9L5yLFjti0QTHhPyFrZCT1V/MMnBtXKmoiKDZ78NDBjERki6ZTQZdSMCtkgoNmp17By9ItJr8o7ChX0XxY91nk="
+	)
+	hash := messageHash("Bitcoin Signed Message:\n", message)
+	payload, err := Base58CheckDecode(wif)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privKey := payload[1:33]
+
+	signed := ecdsa.SignCompact(secp256k1.PrivKeyFromBytes(privKey), hash, true)
+	if got := base64.StdEncoding.EncodeToString(signed); got != sig {
+		t.Errorf("signature %v, expected %v", got, sig)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(sig)
+	pub, compressed, err := ecdsa.RecoverCompact(raw, hash)
+	if err != nil || !compressed {
+		t.Fatalf("recovery failed: %v (compressed=%v)", err, compressed)
+	}
+	if got := Base58CheckEncode(append([]byte{0x00}, Hash160(pub.SerializeCompressed())...)); got != address {
+		t.Errorf("recovered %v, expected %v", got, address)
+	}
+}
+
+func TestAppendVarInt(t *testing.T) {
+	tests := map[uint64]string{
+		0:             "00",
+		0xfc:          "fc",
+		0xfd:          "fdfd00",
+		0xffff:        "fdffff",
+		0x10000:       "fe00000100",
+		0x1_0000_0000: "ff0000000001000000",
+	}
+	for n, want := range tests {
+		if got := hex.EncodeToString(AppendVarInt(nil, n)); got != want {
+			t.Errorf("%d: got %v, want %v", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package doge
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	BlockHeaderLen  = 80
+	VersionAuxPoW   = 0x100 // block version flag: block is merged-mined (has AuxPoW)
+	DogeAuxPoWChain = 0x62  // Dogecoin's merged-mining chain ID (version >> 16)
+)
+
+var ErrBadProofOfWork = errors.New("proof of work does not meet target")
+
+// SerializeBlockHeader rebuilds the 80-byte block header from Core's JSON fields.
+func SerializeBlockHeader(h *types.BlockHeader) ([]byte, error) {
+	prev := make([]byte, 32) // zero for the genesis block.
+	if h.PreviousBlockHash != "" {
+		var err error
+		prev, err = HexToHash(h.PreviousBlockHash)
+		if err != nil {
+			return nil, fmt.Errorf("SerializeBlockHeader: previousblockhash: %v", err)
+		}
+	}
+	merkle, err := HexToHash(h.MerkleRoot)
+	if err != nil {
+		return nil, fmt.Errorf("SerializeBlockHeader: merkleroot: %v", err)
+	}
+	bits, err := ParseBits(h.Bits)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 0, BlockHeaderLen)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(int32(h.Version)))
+	buf = append(buf, prev...)
+	buf = append(buf, merkle...)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(h.Time))
+	buf = binary.LittleEndian.AppendUint32(buf, bits)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(h.Nonce))
+	return buf, nil
+}
+
+// HeaderFromBlock extracts the header fields of a block.
+func HeaderFromBlock(b *types.Block) *types.BlockHeader {
+	return &types.BlockHeader{
+		Hash:              b.Hash,
+		Confirmations:     b.Confirmations,
+		Height:            b.Height,
+		Version:           b.Version,
+		VersionHex:        b.VersionHex,
+		MerkleRoot:        b.MerkleRoot,
+		Time:              b.Time,
+		MedianTime:        b.MedianTime,
+		Nonce:             b.Nonce,
+		Bits:              b.Bits,
+		Difficulty:        b.Difficulty,
+		ChainWork:         b.ChainWork,
+		PreviousBlockHash: b.PreviousBlockHash,
+		NextBlockHash:     b.NextBlockHash,
+	}
+}
+
+// ParseBits decodes Core's hex "bits" (compact target) field.
+func ParseBits(bits string) (uint32, error) {
+	v, err := strconv.ParseUint(bits, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("ParseBits: invalid bits %q: %v", bits, err)
+	}
+	return uint32(v), nil
+}
+
+// CompactToTarget expands a compact target (nBits); negative or
+// overflowing targets are reported as errors.
+func CompactToTarget(bits uint32) (*big.Int, error) {
+	exponent := uint(bits >> 24)
+	mantissa := int64(bits & 0x007fffff)
+	if bits&0x00800000 != 0 && mantissa != 0 {
+		return nil, fmt.Errorf("CompactToTarget: negative target %08x", bits)
+	}
+	if mantissa != 0 && (exponent > 34 || (mantissa > 0xff && exponent > 33) || (mantissa > 0xffff && exponent > 32)) {
+		return nil, fmt.Errorf("CompactToTarget: target overflow %08x", bits)
+	}
+	target := big.NewInt(mantissa)
+	if exponent <= 3 {
+		target.Rsh(target, 8*(3-exponent))
+	} else {
+		target.Lsh(target, 8*(exponent-3))
+	}
+	return target, nil
+}
+
+// ScryptHash is Dogecoin's proof-of-work hash of an 80-byte header
+// (scrypt N=1024, r=1, p=1 with the header as password and salt).
+func ScryptHash(header []byte) ([]byte, error) {
+	return scrypt.Key(header, header, 1024, 1, 1, 32)
+}
+
+// CheckProofOfWork checks that the scrypt hash of `header` meets the
+// target encoded in `bits`, and that the target is within the chain's limit.
+func CheckProofOfWork(chain *ChainParams, header []byte, bits uint32) error {
+	target, err := CompactToTarget(bits)
+	if err != nil {
+		return err
+	}
+	if target.Sign() <= 0 {
+		return fmt.Errorf("CheckProofOfWork: zero target %08x", bits)
+	}
+	limit, _ := CompactToTarget(chain.PowLimitBits)
+	if limit != nil && limit.Sign() > 0 && target.Cmp(limit) > 0 {
+		return fmt.Errorf("CheckProofOfWork: target %08x above proof-of-work limit", bits)
+	}
+	hash, err := ScryptHash(header)
+	if err != nil {
+		return err
+	}
+	if new(big.Int).SetBytes(Reverse(hash)).Cmp(target) > 0 {
+		return ErrBadProofOfWork
+	}
+	return nil
+}
+
+// IsLegacyVersion reports whether a block version predates merged mining,
+// so carries no chain ID (Core's CPureBlockHeader::IsLegacy): version 1, and
+// version 2 without a chain ID.
+func IsLegacyVersion(version int) bool {
+	return version == 1 || version == 2
+}
+
+// CheckHeaderHash checks that the header serializes to the claimed block hash.
+func CheckHeaderHash(header []byte, hash string) error {
+	actual := HashToHex(DoubleSha256(header))
+	if actual != hash {
+		return fmt.Errorf("CheckHeaderHash: header hashes to %v, not %v", actual, hash)
+	}
+	return nil
+}
+
+// BlockVerifier checks block headers (scrypt PoW, AuxPoW) and merkle roots.
+type BlockVerifier struct {
+	Chain *ChainParams
+}
+
+// VerifyBlock checks a block fetched from Core. If `rawBlock` is provided
+// (the serialized block, see getblock verbosity 0) the header and AuxPoW are
+// taken from it, otherwise the header is rebuilt from the JSON fields and
+// merged-mined blocks cannot be verified.
+func (v *BlockVerifier) VerifyBlock(block *types.Block, rawBlock []byte) error {
+	header, err := SerializeBlockHeader(HeaderFromBlock(block))
+	if err != nil {
+		return err
+	}
+	if len(rawBlock) > 0 {
+		if len(rawBlock) < BlockHeaderLen || !bytes.Equal(rawBlock[:BlockHeaderLen], header) {
+			return errors.New("VerifyBlock: raw block header does not match block fields")
+		}
+	}
+	if err := CheckHeaderHash(header, block.Hash); err != nil {
+		return err
+	}
+	bits, err := ParseBits(block.Bits)
+	if err != nil {
+		return err
+	}
+	chainID := uint32(block.Version) >> 16
+	if v.Chain.AuxPoWStrictChainID && !IsLegacyVersion(block.Version) && chainID != DogeAuxPoWChain {
+		return fmt.Errorf("VerifyBlock: block does not have our chain ID (%#x)", chainID)
+	}
+	if block.Version&VersionAuxPoW != 0 {
+		if len(rawBlock) == 0 {
+			return errors.New("VerifyBlock: merged-mined block requires the raw block to verify AuxPoW")
+		}
+		aux, _, err := ParseAuxPoW(rawBlock[BlockHeaderLen:])
+		if err != nil {
+			return err
+		}
+		if err := aux.Check(v.Chain, DoubleSha256(header), chainID); err != nil {
+			return err
+		}
+		if err := CheckProofOfWork(v.Chain, aux.ParentHeader, bits); err != nil {
+			return fmt.Errorf("VerifyBlock: AuxPoW parent block: %w", err)
+		}
+	} else {
+		if err := CheckProofOfWork(v.Chain, header, bits); err != nil {
+			return fmt.Errorf("VerifyBlock: %w", err)
+		}
+	}
+	return VerifyMerkleRoot(block)
+}
+
+// VerifyMerkleRoot checks the block's merkle root against its transaction ids.
+func VerifyMerkleRoot(block *types.Block) error {
+	if len(block.Tx) == 0 {
+		return errors.New("VerifyMerkleRoot: block has no transactions")
+	}
+	hashes := make([][]byte, len(block.Tx))
+	for i, tx := range block.Tx {
+		h, err := HexToHash(tx.TxID)
+		if err != nil {
+			return fmt.Errorf("VerifyMerkleRoot: txid %q: %v", tx.TxID, err)
+		}
+		hashes[i] = h
+	}
+	root := HashToHex(MerkleRoot(hashes))
+	if root != block.MerkleRoot {
+		return fmt.Errorf("VerifyMerkleRoot: transactions hash to %v, not %v", root, block.MerkleRoot)
+	}
+	return nil
+}
+
+// MerkleRoot computes the merkle root of internal-byte-order hashes.
+func MerkleRoot(hashes [][]byte) []byte {
+	if len(hashes) == 0 {
+		return make([]byte, 32)
+	}
+	level := append([][]byte(nil), hashes...)
+	for len(level) > 1 {
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		next := make([][]byte, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			next = append(next, DoubleSha256(append(append([]byte(nil), level[i]...), level[i+1]...)))
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// DecodeHex is hex.DecodeString with a descriptive error.
+func DecodeHex(s string) ([]byte, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex: %v", err)
+	}
+	return b, nil
+}
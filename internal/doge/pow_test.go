@@ -0,0 +1,143 @@
+package doge
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+)
+
+// Dogecoin mainnet genesis block.
+var genesisBlock = &types.Block{
+	Hash:       "1a91e3dace36e2be3bf030a65679fe821aa1d6ef92e7c9902eb318182c355691",
+	Version:    1,
+	MerkleRoot: "5b2a3f53f605d62c53e62932dac6925e3d74afa5a4b459745c36d42d0ed26a69",
+	Time:       1386325540,
+	Bits:       "1e0ffff0",
+	Nonce:      99943,
+	Tx: []types.RawTxn{
+		{TxID: "5b2a3f53f605d62c53e62932dac6925e3d74afa5a4b459745c36d42d0ed26a69"},
+	},
+}
+
+func TestVerifyGenesisBlock(t *testing.T) {
+	v := &BlockVerifier{Chain: &DogeMainNetChain}
+	if err := v.VerifyBlock(genesisBlock, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyBlockRejectsBadPoW(t *testing.T) {
+	v := &BlockVerifier{Chain: &DogeMainNetChain}
+
+	bad := *genesisBlock
+	bad.Nonce++
+	if err := v.VerifyBlock(&bad, nil); err == nil {
+		t.Error("expected header hash mismatch for modified nonce")
+	}
+
+	header, err := SerializeBlockHeader(HeaderFromBlock(genesisBlock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a much harder target than the genesis block meets.
+	if err := CheckProofOfWork(&DogeMainNetChain, header, 0x1b0fffff); err != ErrBadProofOfWork {
+		t.Errorf("expected ErrBadProofOfWork, got %v", err)
+	}
+	// an easier target than the chain allows.
+	if err := CheckProofOfWork(&DogeMainNetChain, header, 0x207fffff); err == nil {
+		t.Error("expected target above proof-of-work limit")
+	}
+}
+
+func TestVerifyBlockChainID(t *testing.T) {
+	v := &BlockVerifier{Chain: &DogeMainNetChain}
+	for _, c := range []struct {
+		version int
+		ok      bool
+	}{
+		{4, false},            // not merged-mined, but still needs our chain ID
+		{0x14<<16 | 4, false}, // another chain's ID
+		{DogeAuxPoWChain<<16 | 4, true},
+		{2, true}, // legacy: no chain ID
+	} {
+		block := *genesisBlock
+		block.Version = c.version
+		header, err := SerializeBlockHeader(HeaderFromBlock(&block))
+		if err != nil {
+			t.Fatal(err)
+		}
+		block.Hash = HashToHex(DoubleSha256(header))
+		// (the changed header no longer meets the target either.)
+		err = v.VerifyBlock(&block, nil)
+		if rejected := err != nil && strings.Contains(err.Error(), "chain ID"); rejected == c.ok {
+			t.Errorf("version %#x: %v", c.version, err)
+		}
+	}
+}
+
+func TestMerkleRoot(t *testing.T) {
+	bad := *genesisBlock
+	bad.Tx = append(bad.Tx, types.RawTxn{TxID: genesisBlock.Hash})
+	if err := VerifyMerkleRoot(&bad); err == nil {
+		t.Error("expected merkle root mismatch")
+	}
+}
+
+func TestVerifyAuxPoWBlock(t *testing.T) {
+	chain := &DogeRegTestChain
+	txid := "5b2a3f53f605d62c53e62932dac6925e3d74afa5a4b459745c36d42d0ed26a69"
+	block := &types.Block{
+		Version:           DogeAuxPoWChain<<16 | VersionAuxPoW | 4,
+		PreviousBlockHash: genesisBlock.Hash,
+		MerkleRoot:        txid,
+		Time:              1700000000,
+		Bits:              "207fffff",
+		Tx:                []types.RawTxn{{TxID: txid}},
+	}
+	header, err := SerializeBlockHeader(HeaderFromBlock(block))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Hash = HashToHex(DoubleSha256(header))
+
+	// parent coinbase commits to our block hash (no chain merkle branch).
+	scriptSig := append([]byte{0xfa, 0xbe, 'm', 'm'}, Reverse(DoubleSha256(header))...)
+	scriptSig = append(scriptSig, 1, 0, 0, 0, 0, 0, 0, 0) // tree size 1, nonce 0
+	coinbase := []byte{1, 0, 0, 0, 1}
+	coinbase = append(coinbase, make([]byte, 36)...)
+	coinbase = append(coinbase, byte(len(scriptSig)))
+	coinbase = append(coinbase, scriptSig...)
+	coinbase = append(coinbase, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 0)
+
+	parent := make([]byte, BlockHeaderLen)
+	parent[0] = 2
+	copy(parent[36:68], DoubleSha256(coinbase))
+	copy(parent[72:76], []byte{0xff, 0xff, 0x7f, 0x20})
+	for nonce := byte(0); ; nonce++ {
+		parent[76] = nonce
+		if CheckProofOfWork(chain, parent, 0x207fffff) == nil {
+			break
+		}
+	}
+
+	raw := append([]byte(nil), header...)
+	raw = append(raw, coinbase...)
+	raw = append(raw, make([]byte, 32)...) // parent block hash
+	raw = append(raw, 0, 0, 0, 0, 0)       // merkle branch, index 0
+	raw = append(raw, 0, 0, 0, 0, 0)       // chain merkle branch, chain index 0
+	raw = append(raw, parent...)
+
+	v := &BlockVerifier{Chain: chain}
+	if err := v.VerifyBlock(block, raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.VerifyBlock(block, nil); err == nil {
+		t.Error("expected error: AuxPoW cannot be verified without the raw block")
+	}
+
+	raw[BlockHeaderLen+42+4] ^= 0xff // corrupt the committed block hash (in the parent coinbase)
+	if err := v.VerifyBlock(block, raw); err == nil {
+		t.Error("expected error for corrupted AuxPoW commitment")
+	}
+}
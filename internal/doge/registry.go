@@ -0,0 +1,144 @@
+package doge
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Registry of known networks: the built-in Dogecoin chains followed by
+// custom chains (e.g. private regtest-derived networks with their own
+// genesis block) added with RegisterChainParams. Lookups by name, genesis
+// hash and version bytes search the registry in order.
+
+var (
+	chainsMu sync.RWMutex
+	chains   = []*ChainParams{&DogeMainNetChain, &DogeTestNetChain, &DogeRegTestChain}
+)
+
+// ChainDefinition describes a custom network. Nil prefixes and zero values
+// are inherited from Base, which defaults to DogeRegTestChain.
+type ChainDefinition struct {
+	ChainName           string // unique name, e.g. "doge_privnet"
+	Network             string // network name reported by Core's getblockchaininfo (e.g. "regtest")
+	GenesisBlock        string // hash of block #0
+	Base                *ChainParams
+	P2PKHPrefix         *byte
+	P2SHPrefix          *byte
+	WIFPrefix           *byte
+	Bip32PrivKeyPrefix  *uint32
+	Bip32PubKeyPrefix   *uint32
+	PowLimitBits        uint32
+	AuxPoWStrictChainID *bool
+	Checkpoints         map[int64]string // the genesis block is always a checkpoint
+}
+
+// Chains returns the registered networks, built-in chains first.
+func Chains() []*ChainParams {
+	chainsMu.RLock()
+	defer chainsMu.RUnlock()
+	return append([]*ChainParams(nil), chains...)
+}
+
+// RegisterChainParams adds a custom network so that the follower recognises
+// its genesis block and the address, WIF and BIP32 codecs accept its
+// prefixes. The name and genesis block must not already be registered.
+func RegisterChainParams(def ChainDefinition) (*ChainParams, error) {
+	base := def.Base
+	if base == nil {
+		base = &DogeRegTestChain
+	}
+	genesis := strings.ToLower(def.GenesisBlock)
+	if b, err := hex.DecodeString(genesis); err != nil || len(b) != 32 {
+		return nil, fmt.Errorf("RegisterChainParams: genesis block must be 64 hex characters: %q", def.GenesisBlock)
+	}
+	if def.ChainName == "" {
+		return nil, errors.New("RegisterChainParams: chain name is required")
+	}
+
+	chain := &ChainParams{
+		ChainName:                def.ChainName,
+		Network:                  def.Network,
+		GenesisBlock:             genesis,
+		p2pkh_address_prefix:     base.p2pkh_address_prefix,
+		p2sh_address_prefix:      base.p2sh_address_prefix,
+		pkey_prefix:              base.pkey_prefix,
+		bip32_privkey_prefix:     base.bip32_privkey_prefix,
+		bip32_pubkey_prefix:      base.bip32_pubkey_prefix,
+		Bip32_WIF_PrivKey_Prefix: base.Bip32_WIF_PrivKey_Prefix,
+		Bip32_WIF_PubKey_Prefix:  base.Bip32_WIF_PubKey_Prefix,
+		PowLimitBits:             base.PowLimitBits,
+		AuxPoWStrictChainID:      base.AuxPoWStrictChainID,
+	}
+	if chain.Network == "" {
+		chain.Network = base.Network
+	}
+	if def.P2PKHPrefix != nil {
+		chain.p2pkh_address_prefix = *def.P2PKHPrefix
+	}
+	if def.P2SHPrefix != nil {
+		chain.p2sh_address_prefix = *def.P2SHPrefix
+	}
+	if def.WIFPrefix != nil {
+		chain.pkey_prefix = *def.WIFPrefix
+	}
+	if def.Bip32PrivKeyPrefix != nil {
+		chain.bip32_privkey_prefix = *def.Bip32PrivKeyPrefix
+		chain.Bip32_WIF_PrivKey_Prefix = bip32PrefixString(chain.bip32_privkey_prefix)
+	}
+	if def.Bip32PubKeyPrefix != nil {
+		chain.bip32_pubkey_prefix = *def.Bip32PubKeyPrefix
+		chain.Bip32_WIF_PubKey_Prefix = bip32PrefixString(chain.bip32_pubkey_prefix)
+	}
+	if def.PowLimitBits != 0 {
+		chain.PowLimitBits = def.PowLimitBits
+	}
+	if _, err := CompactToTarget(chain.PowLimitBits); err != nil {
+		return nil, fmt.Errorf("RegisterChainParams: invalid proof-of-work limit: %w", err)
+	}
+	if def.AuxPoWStrictChainID != nil {
+		chain.AuxPoWStrictChainID = *def.AuxPoWStrictChainID
+	}
+	if chain.p2pkh_address_prefix == chain.p2sh_address_prefix {
+		return nil, errors.New("RegisterChainParams: P2PKH and P2SH prefixes must differ")
+	}
+	if chain.bip32_privkey_prefix == chain.bip32_pubkey_prefix {
+		return nil, errors.New("RegisterChainParams: BIP32 private and public prefixes must differ")
+	}
+	for height, hash := range def.Checkpoints {
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != 32 || height < 0 {
+			return nil, fmt.Errorf("RegisterChainParams: invalid checkpoint: %d = %q", height, hash)
+		}
+	}
+	var err error
+	chain.Checkpoints, err = chain.MergeCheckpoints(def.Checkpoints)
+	if err == nil {
+		chain.Checkpoints, err = chain.MergeCheckpoints(map[int64]string{0: genesis})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("RegisterChainParams: %w", err)
+	}
+
+	chainsMu.Lock()
+	defer chainsMu.Unlock()
+	for _, c := range chains {
+		if chain.ChainName == c.ChainName || chain.ChainName == c.Network {
+			return nil, fmt.Errorf("RegisterChainParams: chain name %q is already registered", chain.ChainName)
+		}
+		if genesis == c.GenesisBlock {
+			return nil, fmt.Errorf("RegisterChainParams: genesis block %v is already registered as %v", genesis, c.ChainName)
+		}
+	}
+	chains = append(chains, chain)
+	return chain, nil
+}
+
+// bip32PrefixString returns the first four base58 characters of extended
+// keys with this version (e.g. "dgub").
+func bip32PrefixString(version uint32) string {
+	data := make([]byte, bip32SerializedLen)
+	data[0], data[1], data[2], data[3] = byte(version>>24), byte(version>>16), byte(version>>8), byte(version)
+	return Base58CheckEncode(data)[:4]
+}
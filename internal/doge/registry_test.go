@@ -0,0 +1,79 @@
+package doge
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+const privnetGenesis = "7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a7a"
+
+func TestRegisterChainParams(t *testing.T) {
+	p2pkh, p2sh, wif := byte(0x32), byte(0x33), byte(0xb2)
+	privPrefix, pubPrefix := uint32(0x02fa1000), uint32(0x02fa2000)
+	chain, err := RegisterChainParams(ChainDefinition{
+		ChainName:          "doge_registry_test",
+		GenesisBlock:       privnetGenesis,
+		P2PKHPrefix:        &p2pkh,
+		P2SHPrefix:         &p2sh,
+		WIFPrefix:          &wif,
+		Bip32PrivKeyPrefix: &privPrefix,
+		Bip32PubKeyPrefix:  &pubPrefix,
+		Checkpoints:        map[int64]string{10: genesisBlock.Hash},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain.Network != "regtest" || chain.PowLimitBits != DogeRegTestChain.PowLimitBits || len(chain.Bip32_WIF_PubKey_Prefix) != 4 || chain.Bip32_WIF_PubKey_Prefix == "tpub" {
+		t.Errorf("unexpected inherited params %+v", chain)
+	}
+	if chain.Checkpoints[0] != privnetGenesis || chain.Checkpoints[10] != genesisBlock.Hash {
+		t.Errorf("unexpected checkpoints %v", chain.Checkpoints)
+	}
+
+	if found, err := ChainFromGenesisHash(privnetGenesis); err != nil || found != chain {
+		t.Errorf("ChainFromGenesisHash gave %v, %v", found, err)
+	}
+	if found, err := ChainFromName("doge_registry_test"); err != nil || found != chain {
+		t.Errorf("ChainFromName gave %v, %v", found, err)
+	}
+	if found, _ := ChainFromName("regtest"); found != &DogeRegTestChain {
+		t.Errorf("regtest resolved to %v", found.ChainName)
+	}
+
+	// the custom prefixes are used by the address, WIF and BIP32 codecs.
+	privKey, _ := hex.DecodeString(wifTestKey)
+	addr, _ := P2PKHFromPubKey(chain, ECPubKeyFromECPrivKey(privKey))
+	if addrType, _, err := DecodeAddress(addr, chain); err != nil || addrType != AddressP2PKH {
+		t.Errorf("custom address %v: %v %v", addr, addrType, err)
+	}
+	if err := ValidateAddress(addr, &DogeRegTestChain); err != ErrWrongNetwork {
+		t.Errorf("custom address accepted on regtest: %v", err)
+	}
+	encoded, _ := EncodeWIF(chain, privKey, true)
+	if key, err := DecodeWIF(encoded, nil); err != nil || key.Chain != chain {
+		t.Errorf("DecodeWIF gave %v, %v", key, err)
+	}
+	master, _ := NewMasterKey(chain, make([]byte, 16))
+	if key, err := DecodeExtendedKey(master.String(), nil); err != nil || key.Chain != chain {
+		t.Errorf("DecodeExtendedKey gave %v, %v", key, err)
+	}
+}
+
+func TestRegisterChainParamsErrors(t *testing.T) {
+	same := byte(0x6f)
+	for _, def := range []ChainDefinition{
+		{ChainName: "doge_main", GenesisBlock: "7b" + privnetGenesis[2:]},
+		{ChainName: "regtest", GenesisBlock: "7b" + privnetGenesis[2:]},
+		{ChainName: "doge_copy", GenesisBlock: DogeRegTestChain.GenesisBlock},
+		{ChainName: "doge_bad_genesis", GenesisBlock: "7b7b"},
+		{ChainName: "", GenesisBlock: "7b" + privnetGenesis[2:]},
+		{ChainName: "doge_same_prefix", GenesisBlock: "7b" + privnetGenesis[2:], P2SHPrefix: &same},
+		{ChainName: "doge_bad_pow", GenesisBlock: "7b" + privnetGenesis[2:], PowLimitBits: 0x20800001},
+		{ChainName: "doge_bad_checkpoint", GenesisBlock: "7b" + privnetGenesis[2:], Checkpoints: map[int64]string{5: "xyz"}},
+		{ChainName: "doge_bad_genesis_checkpoint", GenesisBlock: "7b" + privnetGenesis[2:], Checkpoints: map[int64]string{0: privnetGenesis}},
+	} {
+		if _, err := RegisterChainParams(def); err == nil {
+			t.Errorf("%q: expected an error", def.ChainName)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package doge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+// Dogecoin mainnet genesis coinbase (txid is the genesis merkle root).
+const (
+	genesisCoinbaseHex    = "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff1004ffff001d0104084e696e746f6e646fffffffff010058850c020000004341040184710fa689ad5023690c80f3a49c8f13f8d45b8c857fbcbc8bc4a8e4d3eb4b10f4d4604fa08dce601aaf0f470216fe1b51850b4acf21b179c45070ac7b03a9ac00000000"
+	genesisCoinbaseScript = "04ffff001d0104084e696e746f6e646f"
+	genesisPubKey         = "040184710fa689ad5023690c80f3a49c8f13f8d45b8c857fbcbc8bc4a8e4d3eb4b10f4d4604fa08dce601aaf0f470216fe1b51850b4acf21b179c45070ac7b03a9"
+)
+
+// Bitcoin block 170: the first payment between keys, spending a P2PK
+// output of 0437cd7f...c997 (Dogecoin uses the same signature hash).
+const (
+	block170TxHex  = "0100000001c997a5e56e104102fa209c6a852dd90660a20b2d9c352423edce25857fcd3704000000004847304402204e45e16932b8af514961a1d3a1a25fdf3f4f7732e9d624c6c61548ab5fb8cd410220181522ec8eca07de4860a4acdd12909d831cc56cbbac4622082221a8768d1d0901ffffffff0200ca9a3b00000000434104ae1a62fe09c5f51b13905f07f06b99a2f7159b2225f374cd378d71302fa28414e7aab37397f554a7df5f142c21c1b7303b8a0626f1baded5c72a704f7e6cd84cac00286bee0000000043410411db93e1dcdb8a016b49840f8c53bc1eb68a382e97b1482ecad7b148a6909a5cb2e0eaddfb84ccf9744464f82e160bfa9b8b64f9d4c03f999b8643f656b412a3ac00000000"
+	block170TxID   = "f4184fc596403b9d638783cf57adfe4c75c605f6356fbc91338530e9831e9e16"
+	block170PubKey = "0411db93e1dcdb8a016b49840f8c53bc1eb68a382e97b1482ecad7b148a6909a5cb2e0eaddfb84ccf9744464f82e160bfa9b8b64f9d4c03f999b8643f656b412a3"
+)
+
+func TestSerializeGenesisCoinbase(t *testing.T) {
+	scriptSig, _ := hex.DecodeString(genesisCoinbaseScript)
+	pub, _ := hex.DecodeString(genesisPubKey)
+	tx := &Tx{
+		Version: TxVersion,
+		Inputs:  []TxIn{{PrevTxID: make([]byte, 32), PrevIndex: 0xffffffff, ScriptSig: scriptSig, Sequence: SequenceFinal}},
+		Outputs: []TxOut{{Value: 88 * KoinuPerDoge, Script: append(append([]byte{65}, pub...), 0xac)}},
+	}
+	if got := hex.EncodeToString(tx.Serialize()); got != genesisCoinbaseHex {
+		t.Errorf("serialized %v", got)
+	}
+	if tx.SerializeSize() != len(genesisCoinbaseHex)/2 {
+		t.Errorf("SerializeSize %d, expected %d", tx.SerializeSize(), len(genesisCoinbaseHex)/2)
+	}
+	if txid := tx.TxID(); txid != genesisBlock.MerkleRoot {
+		t.Errorf("txid %v, expected %v", txid, genesisBlock.MerkleRoot)
+	}
+}
+
+func TestParseTx(t *testing.T) {
+	raw, _ := hex.DecodeString(block170TxHex)
+	tx, err := ParseTx(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.Inputs) != 1 || len(tx.Outputs) != 2 || tx.Outputs[0].Value != 10*KoinuPerDoge {
+		t.Errorf("unexpected transaction %+v", tx)
+	}
+	if txid := tx.TxID(); txid != block170TxID {
+		t.Errorf("txid %v, expected %v", txid, block170TxID)
+	}
+	if _, err := ParseTx(raw[:len(raw)-1]); err == nil {
+		t.Error("expected error for a truncated transaction")
+	}
+	if _, err := ParseTx(append(raw, 0)); err == nil {
+		t.Error("expected error for trailing data")
+	}
+}
+
+func TestSignatureHashVector(t *testing.T) {
+	raw, _ := hex.DecodeString(block170TxHex)
+	tx, _ := ParseTx(raw)
+	pub, _ := hex.DecodeString(block170PubKey)
+	prevScript := append(append([]byte{65}, pub...), 0xac)
+
+	// scriptSig is a single push of the signature.
+	sig := tx.Inputs[0].ScriptSig[1:]
+	hash, err := tx.SignatureHash(0, prevScript, SigHashAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyTxSignature(pub, hash, sig) {
+		t.Error("block 170 signature does not verify")
+	}
+	other, _ := tx.SignatureHash(0, prevScript, SigHashNone)
+	if VerifyTxSignature(pub, other, sig) {
+		t.Error("signature verified against the SIGHASH_NONE hash")
+	}
+	if _, err := tx.SignatureHash(1, prevScript, SigHashAll); err == nil {
+		t.Error("expected error for an out of range input")
+	}
+}
+
+func TestSignatureHashSingleBug(t *testing.T) {
+	tx := &Tx{Inputs: []TxIn{{PrevTxID: make([]byte, 32)}, {PrevTxID: make([]byte, 32)}}, Outputs: []TxOut{{Value: 1}}}
+	hash, _ := tx.SignatureHash(1, nil, SigHashSingle)
+	if hex.EncodeToString(hash) != "0100000000000000000000000000000000000000000000000000000000000000" {
+		t.Errorf("expected the SIGHASH_SINGLE 'one' hash, got %x", hash)
+	}
+}
+
+func TestSignTxHash(t *testing.T) {
+	// RFC6979 vector: private key 1, SHA256("Satoshi Nakamoto").
+	privKey := make([]byte, 32)
+	privKey[31] = 1
+	hash := sha256.Sum256([]byte("Satoshi Nakamoto"))
+	sig, err := SignTxHash(privKey, hash[:], SigHashAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "3045022100934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d802202442ce9d2b916064108014783e923ec36b49743e2ffa1c4496f01a512aafd9e501"
+	if hex.EncodeToString(sig) != expected {
+		t.Errorf("signature %x", sig)
+	}
+	if !VerifyTxSignature(ECPubKeyFromECPrivKey(privKey), hash[:], sig) {
+		t.Error("signature does not verify")
+	}
+	if _, err := SignTxHash(make([]byte, 32), hash[:], SigHashAll); err == nil {
+		t.Error("expected error for the zero key")
+	}
+}
@@ -0,0 +1,100 @@
+package doge
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Wallet Import Format (WIF) private keys: base58check of
+// [version byte][32-byte key] with an optional 0x01 suffix meaning the
+// key's public key is used in compressed form.
+
+var (
+	ErrUnknownVersion   = errors.New("unknown version bytes")
+	ErrWIFWrongNetwork  = errors.New("wif: key is not valid for this network")
+	ErrWIFInvalidLength = errors.New("wif: invalid length")
+)
+
+const wifCompressedFlag = 0x01
+
+type WIFKey struct {
+	Chain      *ChainParams
+	PrivKey    ECPrivKey
+	Compressed bool // the address uses the compressed public key
+}
+
+// EncodeWIF encodes a 32-byte private key for the chain.
+func EncodeWIF(chain *ChainParams, privKey ECPrivKey, compressed bool) (string, error) {
+	if !ECKeyIsValid(privKey) {
+		return "", errors.New("wif: invalid private key")
+	}
+	data := make([]byte, 0, 1+ECPrivKeyLen+1)
+	data = append(data, chain.pkey_prefix)
+	data = append(data, privKey...)
+	if compressed {
+		data = append(data, wifCompressedFlag)
+	}
+	wif := Base58CheckEncode(data)
+	clear(data)
+	return wif, nil
+}
+
+// DecodeWIF decodes a WIF private key, verifying the checksum. If chain is
+// non-nil the key must belong to it; otherwise the network is identified
+// from the version byte, and unknown version bytes are an error.
+func DecodeWIF(wif string, chain *ChainParams) (*WIFKey, error) {
+	data, err := Base58CheckDecode(wif)
+	if err != nil {
+		return nil, fmt.Errorf("wif: %w", err)
+	}
+	defer clear(data)
+
+	key := &WIFKey{}
+	switch {
+	case len(data) == 1+ECPrivKeyLen:
+	case len(data) == 1+ECPrivKeyLen+1 && data[len(data)-1] == wifCompressedFlag:
+		key.Compressed = true
+	case len(data) == 1+ECPrivKeyLen+1:
+		return nil, fmt.Errorf("wif: invalid compressed flag %#02x", data[len(data)-1])
+	default:
+		return nil, ErrWIFInvalidLength
+	}
+
+	if chain != nil {
+		if data[0] != chain.pkey_prefix {
+			return nil, ErrWIFWrongNetwork
+		}
+		key.Chain = chain
+	} else {
+		key.Chain, err = ChainFromWIFPrefix(data, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	privKey := data[1 : 1+ECPrivKeyLen]
+	if !ECKeyIsValid(privKey) {
+		return nil, errors.New("wif: invalid private key")
+	}
+	key.PrivKey = append(ECPrivKey(nil), privKey...)
+	return key, nil
+}
+
+// PubKey returns the public key in the form the key was exported with.
+func (k *WIFKey) PubKey() []byte {
+	if k.Compressed {
+		return ECPubKeyFromECPrivKey(k.PrivKey)
+	}
+	return ECPubKeyUncompressedFromECPrivKey(k.PrivKey)
+}
+
+// Address returns the P2PKH address of the key.
+func (k *WIFKey) Address() (string, error) {
+	return P2PKHFromPubKey(k.Chain, k.PubKey())
+}
+
+// String re-encodes the key.
+func (k *WIFKey) String() string {
+	wif, _ := EncodeWIF(k.Chain, k.PrivKey, k.Compressed)
+	return wif
+}
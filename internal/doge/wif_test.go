@@ -0,0 +1,133 @@
+package doge
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const wifTestKey = "0c28fca386c7a227600b2fe50b7cae11ec86d3bf1fbe471be89827e19d72aa1d"
+
+func TestWIFBitcoinVector(t *testing.T) {
+	// https://en.bitcoin.it/wiki/Wallet_import_format
+	key, err := DecodeWIF("5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ", &BitcoinMainChain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(key.PrivKey) != wifTestKey || key.Compressed {
+		t.Errorf("unexpected key: %x compressed=%v", key.PrivKey, key.Compressed)
+	}
+}
+
+func TestWIFRoundTrip(t *testing.T) {
+	privKey, _ := hex.DecodeString(wifTestKey)
+	tests := []struct {
+		chain      *ChainParams
+		compressed bool
+		prefixes   string // possible first characters
+	}{
+		{&DogeMainNetChain, false, "6"},
+		{&DogeMainNetChain, true, "Q"},
+		{&DogeTestNetChain, false, "9"},
+		{&DogeTestNetChain, true, "c"},
+		{&DogeRegTestChain, false, "9"},
+		{&DogeRegTestChain, true, "c"},
+	}
+	for _, test := range tests {
+		wif, err := EncodeWIF(test.chain, privKey, test.compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.ContainsRune(test.prefixes, rune(wif[0])) {
+			t.Errorf("%v compressed=%v: unexpected prefix %q", test.chain.ChainName, test.compressed, wif)
+		}
+
+		// identified from the version byte alone.
+		key, err := DecodeWIF(wif, nil)
+		if err != nil {
+			t.Fatalf("%v: %v", test.chain.ChainName, err)
+		}
+		if key.Chain != test.chain || key.Compressed != test.compressed || hex.EncodeToString(key.PrivKey) != wifTestKey {
+			t.Errorf("%v compressed=%v: round trip gave %v compressed=%v", test.chain.ChainName, test.compressed, key.Chain.ChainName, key.Compressed)
+		}
+		if key.String() != wif {
+			t.Errorf("%v: re-encoding gave %v", test.chain.ChainName, key.String())
+		}
+		if chain, err := ChainFromWIFString(wif); err != nil || chain != test.chain {
+			t.Errorf("%v: ChainFromWIFString gave %v, %v", test.chain.ChainName, chain, err)
+		}
+
+		// rejected on every network with another WIF prefix.
+		for _, other := range Chains() {
+			if other.pkey_prefix == test.chain.pkey_prefix {
+				continue
+			}
+			if _, err := DecodeWIF(wif, other); err != ErrWIFWrongNetwork {
+				t.Errorf("%v decoded as %v: expected ErrWIFWrongNetwork, got %v", test.chain.ChainName, other.ChainName, err)
+			}
+		}
+	}
+}
+
+func TestWIFAddress(t *testing.T) {
+	privKey, _ := hex.DecodeString(wifTestKey)
+	for _, compressed := range []bool{false, true} {
+		wif, _ := EncodeWIF(&DogeMainNetChain, privKey, compressed)
+		key, _ := DecodeWIF(wif, &DogeMainNetChain)
+		addr, err := key.Address()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := P2PKHFromPubKey(&DogeMainNetChain, key.PubKey())
+		if addr != want || addr[0] != 'D' {
+			t.Errorf("compressed=%v: unexpected address %v", compressed, addr)
+		}
+		if len(key.PubKey()) != map[bool]int{false: ECPubKeyUncompressedLen, true: ECPubKeyCompressedLen}[compressed] {
+			t.Errorf("compressed=%v: wrong public key length", compressed)
+		}
+	}
+}
+
+func TestWIFErrors(t *testing.T) {
+	privKey, _ := hex.DecodeString(wifTestKey)
+	valid, _ := EncodeWIF(&DogeMainNetChain, privKey, true)
+	corrupt := valid[:len(valid)-1] + "1"
+	if corrupt == valid {
+		corrupt = valid[:len(valid)-1] + "2"
+	}
+
+	withVersion := func(version byte, suffix ...byte) string {
+		data := append([]byte{version}, privKey...)
+		return Base58CheckEncode(append(data, suffix...))
+	}
+	tests := []struct {
+		name string
+		wif  string
+		want error
+	}{
+		{"bad checksum", corrupt, ErrBadChecksum},
+		{"bitcoin version", withVersion(0x80), ErrUnknownVersion},
+		{"address version", withVersion(0x1e), ErrUnknownVersion},
+		{"too short", Base58CheckEncode([]byte{0x9e, 1, 2, 3}), ErrWIFInvalidLength},
+		{"too long", withVersion(0x9e, 1, 1), ErrWIFInvalidLength},
+		{"bad compressed flag", withVersion(0x9e, 2), nil},
+		{"zero key", Base58CheckEncode(append([]byte{0x9e}, make([]byte, 32)...)), nil},
+	}
+	for _, test := range tests {
+		_, err := DecodeWIF(test.wif, nil)
+		if err == nil {
+			t.Errorf("%v: expected an error", test.name)
+			continue
+		}
+		if test.want != nil && !errors.Is(err, test.want) {
+			t.Errorf("%v: expected %v, got %v", test.name, test.want, err)
+		}
+	}
+	if _, err := ChainFromBip32Version(0x0488B21E, false); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("expected xpub to be rejected, got %v", err)
+	}
+	if chain, err := ChainFromBip32Version(DogeTestNetChain.bip32_pubkey_prefix, false); err != nil || chain != &DogeTestNetChain {
+		t.Errorf("expected tpub to map to testnet, got %v %v", chain, err)
+	}
+}
@@ -0,0 +1,81 @@
+package address
+
+import (
+	"github.com/dogecoinfoundation/chainfollower/internal/doge"
+)
+
+// Dogecoin address encoding and decoding (base58check P2PKH and P2SH).
+
+type Network = doge.ChainParams
+
+var (
+	MainNet = &doge.DogeMainNetChain
+	TestNet = &doge.DogeTestNetChain
+	RegTest = &doge.DogeRegTestChain
+)
+
+type Type = doge.AddressType
+
+const (
+	Unknown = doge.AddressUnknown
+	P2PKH   = doge.AddressP2PKH
+	P2SH    = doge.AddressP2SH
+)
+
+// ErrWrongNetwork is returned when an address belongs to another network.
+var ErrWrongNetwork = doge.ErrWrongNetwork
+
+// NetworkByName accepts main, test, regtest or a ChainName (e.g. doge_main,
+// or a chain added with chainparams.RegisterChainParams).
+func NetworkByName(name string) (*Network, error) {
+	return doge.ChainFromName(name)
+}
+
+// EncodeP2PKH encodes a 20-byte public key hash.
+func EncodeP2PKH(net *Network, pubKeyHash []byte) (string, error) {
+	return doge.EncodeP2PKH(net, pubKeyHash)
+}
+
+// EncodeP2SH encodes a 20-byte script hash.
+func EncodeP2SH(net *Network, scriptHash []byte) (string, error) {
+	return doge.EncodeP2SH(net, scriptHash)
+}
+
+// Decode returns the address type and 20-byte hash, checking the checksum
+// and that the address belongs to the network.
+func Decode(addr string, net *Network) (Type, []byte, error) {
+	return doge.DecodeAddress(addr, net)
+}
+
+// Validate returns an error if the address is not valid for the network.
+func Validate(addr string, net *Network) error {
+	return doge.ValidateAddress(addr, net)
+}
+
+// FromPubKey derives the P2PKH address of a 33-byte compressed or
+// 65-byte uncompressed public key.
+func FromPubKey(net *Network, pubKey []byte) (string, error) {
+	return doge.P2PKHFromPubKey(net, pubKey)
+}
+
+// FromRedeemScript derives the P2SH address of a redeem script.
+func FromRedeemScript(net *Network, redeemScript []byte) (string, error) {
+	return doge.P2SHFromScript(net, redeemScript)
+}
+
+// FromScript derives the address paid by a standard P2PKH, P2SH or P2PK
+// scriptPubKey (e.g. decoded from types.RawTxnScriptPubKey.Hex).
+func FromScript(net *Network, scriptPubKey []byte) (string, error) {
+	return doge.AddressFromScript(net, scriptPubKey)
+}
+
+// ToScript returns the scriptPubKey that pays the address (for building
+// transaction outputs).
+func ToScript(net *Network, addr string) ([]byte, error) {
+	return doge.ScriptForAddress(net, addr)
+}
+
+// Hash160 is RIPEMD160(SHA256(data)).
+func Hash160(data []byte) []byte {
+	return doge.Hash160(data)
+}
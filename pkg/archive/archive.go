@@ -0,0 +1,204 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/rpc"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+)
+
+// Block archives: a height range of blocks in compressed, chunked flat
+// files, exported from a follower and served back as a transport (see
+// Transport), e.g. to bootstrap a fresh environment or CI without a Core
+// node.
+//
+// An archive is a directory holding index.json and the chunk files
+// blocks-<from>-<to>.jsonl.gz: gzipped JSON lines, one Record per block in
+// height order, up to ChunkSize blocks per chunk. The index lists the chunks
+// with the hash of every block, so a lookup reads a single chunk.
+
+const DEFAULT_CHUNK_SIZE = 1000
+const IndexFile = "index.json"
+
+var ErrArchiveExists = errors.New("archive: directory already contains an archive")
+var ErrNotContiguous = errors.New("archive: blocks must be added in height order without gaps")
+
+// Record is one line of a chunk file.
+type Record struct {
+	Block *types.Block `json:"block"`
+	Hex   string       `json:"hex,omitempty"` // serialized block (with AuxPoW), if exported with Raw
+}
+
+type Index struct {
+	Chain       string  `json:"chain"`        // network name reported by Core (main, test, regtest)
+	GenesisHash string  `json:"genesis_hash"` // hash of block #0, used to identify the chain
+	Chunks      []Chunk `json:"chunks"`
+}
+
+type Chunk struct {
+	File   string   `json:"file"`
+	From   int64    `json:"from"`
+	To     int64    `json:"to"`
+	Hashes []string `json:"hashes"` // hashes of blocks From..To
+}
+
+// Writer exports blocks to a new archive.
+type Writer struct {
+	Dir       string
+	ChunkSize int  // blocks per chunk file
+	Raw       bool // also store serialized blocks (needed to verify AuxPoW from the archive)
+
+	src      rpc.RpcTransportInterface
+	index    Index
+	chunk    *Chunk
+	file     *os.File
+	gz       *gzip.Writer
+	buf      *bufio.Writer
+	lastHash string // last block added
+	next     int64  // height of the next block
+}
+
+// Create starts an archive in dir, recording the chain of src (which also
+// provides serialized blocks when Raw is set).
+func Create(dir string, src rpc.RpcTransportInterface) (*Writer, error) {
+	if _, err := os.Stat(filepath.Join(dir, IndexFile)); err == nil {
+		return nil, ErrArchiveExists
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	genesis, err := src.GetBlockHash(0)
+	if err != nil {
+		return nil, err
+	}
+	info, err := src.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{
+		Dir:       dir,
+		ChunkSize: DEFAULT_CHUNK_SIZE,
+		src:       src,
+		index:     Index{Chain: info.Chain, GenesisHash: genesis},
+	}, nil
+}
+
+// Add appends the next block (at the height after the previous one).
+func (w *Writer) Add(block *types.Block) error {
+	if w.lastHash != "" && (block.Height != w.next || (block.PreviousBlockHash != "" && block.PreviousBlockHash != w.lastHash)) {
+		return fmt.Errorf("%w: expected height %d after %v, got %d", ErrNotContiguous, w.next, w.lastHash, block.Height)
+	}
+	rec := Record{Block: block}
+	if w.Raw {
+		source, ok := w.src.(rpc.RawBlockTransport)
+		if !ok {
+			return rpc.ErrNoRawBlocks
+		}
+		var err error
+		if rec.Hex, err = source.GetBlockHex(block.Hash); err != nil {
+			return err
+		}
+	}
+	if w.chunk == nil {
+		if err := w.openChunk(block.Height); err != nil {
+			return err
+		}
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	w.buf.Write(line)
+	if err := w.buf.WriteByte('\n'); err != nil {
+		return err
+	}
+	w.chunk.To = block.Height
+	w.chunk.Hashes = append(w.chunk.Hashes, block.Hash)
+	w.lastHash, w.next = block.Hash, block.Height+1
+	if len(w.chunk.Hashes) >= w.ChunkSize {
+		return w.closeChunk()
+	}
+	return nil
+}
+
+// WriteBackfill adds the blocks of a single-shard ChainFollower.Backfill
+// and returns the backfill's error.
+func (w *Writer) WriteBackfill(msgs <-chan messages.Message) error {
+	var err error
+	for msg := range msgs {
+		switch m := msg.(type) {
+		case messages.BackfillBlockMessage:
+			if err == nil {
+				err = w.Add(m.Block)
+			}
+		case messages.BackfillCompleteMessage:
+			if err == nil {
+				err = m.Err
+			}
+		}
+	}
+	return err
+}
+
+// Close finishes the last chunk and writes the index.
+func (w *Writer) Close() error {
+	if w.chunk != nil {
+		return w.closeChunk()
+	}
+	return w.writeIndex()
+}
+
+func (w *Writer) openChunk(height int64) error {
+	w.chunk = &Chunk{From: height, To: height - 1}
+	var err error
+	w.file, err = os.CreateTemp(w.Dir, "chunk-*.tmp")
+	if err != nil {
+		return err
+	}
+	w.gz = gzip.NewWriter(w.file)
+	w.buf = bufio.NewWriter(w.gz)
+	return nil
+}
+
+func (w *Writer) closeChunk() error {
+	err := w.buf.Flush()
+	if err == nil {
+		err = w.gz.Close()
+	}
+	if err == nil {
+		err = w.file.Close()
+	} else {
+		w.file.Close()
+	}
+	w.chunk.File = fmt.Sprintf("blocks-%09d-%09d.jsonl.gz", w.chunk.From, w.chunk.To)
+	if err == nil {
+		err = os.Rename(w.file.Name(), filepath.Join(w.Dir, w.chunk.File))
+	}
+	if err != nil {
+		os.Remove(w.file.Name())
+		return err
+	}
+	w.index.Chunks = append(w.index.Chunks, *w.chunk)
+	w.chunk, w.file, w.gz, w.buf = nil, nil, nil, nil
+	return w.writeIndex()
+}
+
+// writeIndex replaces the index, listing the chunks written so far.
+func (w *Writer) writeIndex() error {
+	data, err := json.MarshalIndent(w.index, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(w.Dir, IndexFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(w.Dir, IndexFile))
+}
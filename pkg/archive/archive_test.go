@@ -0,0 +1,152 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/chainfollower"
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/rpc"
+	"github.com/dogecoinfoundation/chainfollower/pkg/state"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+)
+
+// export writes blocks from..to of a 10-block chain to an archive.
+func export(t *testing.T, dir string, from, to int64) {
+	t.Helper()
+	src := rpc.NewTestChain(10)
+	w, err := Create(dir, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.ChunkSize = 4
+	follower := chainfollower.NewChainFollower(src)
+	if err := w.WriteBackfill(follower.Backfill(from, to, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	dir := t.TempDir()
+	export(t, dir, 1, 9)
+	chunks, _ := filepath.Glob(filepath.Join(dir, "blocks-*.jsonl.gz"))
+	if len(chunks) != 3 {
+		t.Errorf("expected 3 chunks, got %v", chunks)
+	}
+	if _, err := Create(dir, rpc.NewTestChain(10)); !errors.Is(err, ErrArchiveExists) {
+		t.Errorf("expected ErrArchiveExists, got %v", err)
+	}
+
+	archive, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from, to := archive.Range(); from != 1 || to != 9 {
+		t.Errorf("range %d..%d", from, to)
+	}
+	for h := int64(1); h <= 9; h++ {
+		b, err := archive.GetBlock(rpc.TestBlockHash(h))
+		if err != nil || b.Height != h || b.Confirmations != 10-h || len(b.Tx) != 1 {
+			t.Fatalf("block %d: %+v, %v", h, b, err)
+		}
+		if h < 9 && b.NextBlockHash != rpc.TestBlockHash(h+1) || h == 9 && b.NextBlockHash != "" {
+			t.Errorf("block %d: next hash %q", h, b.NextBlockHash)
+		}
+	}
+	if _, err := archive.GetBlockHex(rpc.TestBlockHash(3)); !errors.Is(err, rpc.ErrNoRawBlocks) {
+		t.Errorf("expected ErrNoRawBlocks, got %v", err)
+	}
+	if _, err := archive.GetBlockHash(10); err == nil {
+		t.Error("expected an error above the archive")
+	}
+
+	// a follower runs from the archive: a backfill, and following from a height.
+	follower := chainfollower.NewChainFollower(archive)
+	var heights []int64
+	for msg := range follower.Backfill(2, 9, 1) {
+		switch m := msg.(type) {
+		case messages.BackfillBlockMessage:
+			heights = append(heights, m.Block.Height)
+		case messages.BackfillCompleteMessage:
+			if m.Err != nil {
+				t.Fatal(m.Err)
+			}
+		}
+	}
+	if fmt.Sprint(heights) != "[2 3 4 5 6 7 8 9]" {
+		t.Errorf("backfill from archive: %v", heights)
+	}
+
+	follower = chainfollower.NewChainFollower(archive, chainfollower.WithStartHeight(6), chainfollower.WithPollInterval(time.Millisecond))
+	defer follower.Stop()
+	msgs := follower.Start(&state.ChainPos{})
+	heights = nil
+	for len(heights) < 4 {
+		if m, ok := (<-msgs).(messages.BlockMessage); ok {
+			heights = append(heights, m.Block.Height)
+		}
+	}
+	if fmt.Sprint(heights) != "[6 7 8 9]" {
+		t.Errorf("following the archive: %v", heights)
+	}
+}
+
+// A mid-chain snapshot can be followed: checkpoints below it are skipped.
+func TestArchiveAwayFromGenesis(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Create(dir, rpc.NewTestChain(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for h := int64(200000); h <= 200010; h++ {
+		b := &types.Block{Hash: rpc.TestBlockHash(h), Height: h, PreviousBlockHash: rpc.TestBlockHash(h - 1)}
+		if err := w.Add(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	follower := chainfollower.NewChainFollower(archive, chainfollower.WithStartHeight(200000),
+		chainfollower.WithPollInterval(time.Millisecond), chainfollower.WithRetryPolicy(chainfollower.RetryPolicy{
+			MaxStartAttempts: 1, BaseDelay: time.Millisecond, RetryDelay: time.Millisecond, WrongChainDelay: time.Millisecond, IBDDelay: time.Millisecond,
+		}))
+	defer follower.Stop()
+	msgs := follower.Start(&state.ChainPos{})
+	select {
+	case msg := <-msgs:
+		if m, ok := msg.(messages.BlockMessage); !ok || m.Block.Height != 200000 {
+			t.Errorf("expected block 200000, got %+v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no block delivered: %v", follower.Status().LastError)
+	}
+}
+
+func TestCorruptArchive(t *testing.T) {
+	dir := t.TempDir()
+	export(t, dir, 1, 5)
+	os.WriteFile(filepath.Join(dir, "blocks-000000005-000000005.jsonl.gz"), []byte("not gzip"), 0o644)
+	archive, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := archive.GetBlock(rpc.TestBlockHash(2)); err != nil {
+		t.Errorf("block from an intact chunk: %v", err)
+	}
+	if _, err := archive.GetBlock(rpc.TestBlockHash(5)); err == nil {
+		t.Error("expected an error reading a corrupt chunk")
+	}
+}
@@ -0,0 +1,198 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/dogecoinfoundation/chainfollower/internal/doge"
+	"github.com/dogecoinfoundation/chainfollower/pkg/rpc"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+)
+
+// Transport serves an archive as an rpc.RpcTransportInterface, so a
+// ChainFollower can run from it instead of a Core node. The archive is a
+// single chain whose tip is its last block; every block is reported as on
+// the main chain, so there are no rollbacks. Blocks can only be verified
+// (verify_blocks) if the archive was exported with Raw.
+type Transport struct {
+	Index
+	Dir string
+
+	heights map[string]int64 // block hash -> height
+	from    int64
+	to      int64
+
+	mu      sync.Mutex
+	chunk   int      // index of the cached chunk (-1 = none)
+	records []Record // the cached chunk
+}
+
+var ErrEmptyArchive = errors.New("archive: archive has no blocks")
+
+// Open reads an archive's index.
+func Open(dir string) (*Transport, error) {
+	data, err := os.ReadFile(filepath.Join(dir, IndexFile))
+	if err != nil {
+		return nil, err
+	}
+	t := &Transport{Dir: dir, heights: map[string]int64{}, chunk: -1}
+	if err := json.Unmarshal(data, &t.Index); err != nil {
+		return nil, fmt.Errorf("archive: reading index: %w", err)
+	}
+	if len(t.Chunks) == 0 {
+		return nil, ErrEmptyArchive
+	}
+	t.from, t.to = t.Chunks[0].From, t.Chunks[len(t.Chunks)-1].To
+	for i, c := range t.Chunks {
+		if int64(len(c.Hashes)) != c.To-c.From+1 || (i > 0 && c.From != t.Chunks[i-1].To+1) {
+			return nil, fmt.Errorf("archive: chunk %v does not follow on from the previous chunk", c.File)
+		}
+		for j, hash := range c.Hashes {
+			t.heights[hash] = c.From + int64(j)
+		}
+	}
+	return t, nil
+}
+
+// Range returns the heights of the first and last blocks in the archive
+// (rpc.RangeTransport); a follower skips checkpoints outside it.
+func (t *Transport) Range() (from, to int64) {
+	return t.from, t.to
+}
+
+func (t *Transport) GetBlock(hash string) (*types.Block, error) {
+	rec, err := t.record(hash)
+	if err != nil {
+		return nil, err
+	}
+	block := *rec.Block
+	block.Confirmations = t.to - block.Height + 1
+	block.NextBlockHash = t.hashAt(block.Height + 1)
+	return &block, nil
+}
+
+func (t *Transport) GetBlockHeader(hash string) (*types.BlockHeader, error) {
+	block, err := t.GetBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	return doge.HeaderFromBlock(block), nil
+}
+
+// GetBlockHex returns the serialized block, if the archive has it.
+func (t *Transport) GetBlockHex(hash string) (string, error) {
+	rec, err := t.record(hash)
+	if err != nil {
+		return "", err
+	}
+	if rec.Hex == "" {
+		return "", rpc.ErrNoRawBlocks
+	}
+	return rec.Hex, nil
+}
+
+func (t *Transport) GetBlockCount() (int64, error) {
+	return t.to, nil
+}
+
+func (t *Transport) GetBestBlockHash() (string, error) {
+	return t.hashAt(t.to), nil
+}
+
+func (t *Transport) GetBlockchainInfo() (*types.BlockchainInfo, error) {
+	return &types.BlockchainInfo{
+		Chain:                t.Chain,
+		Blocks:               t.to,
+		Headers:              t.to,
+		BestBlockHash:        t.hashAt(t.to),
+		VerificationProgress: 1,
+	}, nil
+}
+
+func (t *Transport) GetBlockHash(height int64) (string, error) {
+	if height == 0 {
+		return t.GenesisHash, nil
+	}
+	if hash := t.hashAt(height); hash != "" {
+		return hash, nil
+	}
+	return "", fmt.Errorf("archive: height %d is not in the archive (%d..%d)", height, t.from, t.to)
+}
+
+// hashAt returns the hash of the block at height ("" if not archived).
+func (t *Transport) hashAt(height int64) string {
+	if height < t.from || height > t.to {
+		return ""
+	}
+	i := t.chunkFor(height)
+	c := t.Chunks[i]
+	return c.Hashes[height-c.From]
+}
+
+// chunkFor returns the index of the chunk holding height (within the range).
+func (t *Transport) chunkFor(height int64) int {
+	lo, hi := 0, len(t.Chunks)-1
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if t.Chunks[mid].To < height {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// record reads the record of a block, caching its chunk.
+func (t *Transport) record(hash string) (*Record, error) {
+	height, ok := t.heights[hash]
+	if !ok {
+		return nil, fmt.Errorf("archive: block not found: %s", hash)
+	}
+	i := t.chunkFor(height)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.chunk != i {
+		records, err := t.readChunk(t.Chunks[i])
+		if err != nil {
+			return nil, err
+		}
+		t.chunk, t.records = i, records
+	}
+	rec := &t.records[height-t.Chunks[i].From]
+	if rec.Block == nil || rec.Block.Hash != hash {
+		return nil, fmt.Errorf("archive: chunk %v does not match the index at height %d", t.Chunks[i].File, height)
+	}
+	return rec, nil
+}
+
+func (t *Transport) readChunk(c Chunk) ([]Record, error) {
+	f, err := os.Open(filepath.Join(t.Dir, c.File))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("archive: %v: %w", c.File, err)
+	}
+	records := make([]Record, 0, len(c.Hashes))
+	dec := json.NewDecoder(bufio.NewReader(gz))
+	for dec.More() {
+		var rec Record
+		if err := dec.Decode(&rec); err != nil {
+			return nil, fmt.Errorf("archive: %v: %w", c.File, err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != len(c.Hashes) {
+		return nil, fmt.Errorf("archive: %v has %d blocks, expected %d", c.File, len(records), len(c.Hashes))
+	}
+	return records, nil
+}
@@ -0,0 +1,460 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/chainfollower"
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/state"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+)
+
+// Fans out the messages of one ChainFollower to many subscribers, each with
+// its own filter, buffer, checkpoint and backpressure policy. Every
+// subscriber has its own queue and delivery goroutine, so a slow subscriber
+// only holds up the others if its policy is Block and its buffer is full.
+//
+// Messages are shared between subscribers and must not be modified.
+
+const DEFAULT_BUFFER_SIZE = 100 // messages queued per subscriber.
+
+var ErrSlowConsumer = errors.New("broker: subscriber disconnected for falling behind")
+var ErrUnsubscribed = errors.New("broker: unsubscribed")
+
+// Policy decides what happens when a subscriber's buffer is full.
+type Policy int
+
+const (
+	Block      Policy = iota // wait for the subscriber (stalls the follower and, in turn, every subscriber)
+	DropOldest               // discard the oldest queued message
+	Disconnect               // close the subscriber with ErrSlowConsumer
+)
+
+func (p Policy) String() string {
+	switch p {
+	case Block:
+		return "block"
+	case DropOldest:
+		return "drop-oldest"
+	case Disconnect:
+		return "disconnect"
+	}
+	return "unknown"
+}
+
+// Filter returns the message to deliver to a subscriber (possibly a
+// filtered copy), or nil to skip it.
+type Filter func(msg messages.Message) messages.Message
+
+// BlockFilter adapts a chainfollower.BlockFilter (e.g. a watchlist) to
+// filter the block in each BlockMessage.
+func BlockFilter(f chainfollower.BlockFilter) Filter {
+	return func(msg messages.Message) messages.Message {
+		if m, ok := msg.(messages.BlockMessage); ok {
+			m.Block = f.FilterBlock(m.Block)
+			return m
+		}
+		return msg
+	}
+}
+
+// Types returns a filter that passes only messages of the same types as the
+// examples, e.g. Types(messages.BlockMessage{}, messages.RollbackMessage{}).
+func Types(examples ...messages.Message) Filter {
+	return func(msg messages.Message) messages.Message {
+		for _, e := range examples {
+			if reflect.TypeOf(msg) == reflect.TypeOf(e) {
+				return msg
+			}
+		}
+		return nil
+	}
+}
+
+type SubscribeOption func(s *Subscriber)
+
+// Queue up to n messages for the subscriber (default DEFAULT_BUFFER_SIZE).
+func WithBuffer(n int) SubscribeOption {
+	return func(s *Subscriber) { s.bufferSize = max(n, 1) }
+}
+
+// What to do when the subscriber's buffer is full (default Block).
+func WithPolicy(p Policy) SubscribeOption {
+	return func(s *Subscriber) { s.policy = p }
+}
+
+// Deliver only what the filter returns.
+func WithFilter(f Filter) SubscribeOption {
+	return func(s *Subscriber) { s.filter = f }
+}
+
+// Resume from the subscriber's saved position: blocks at or below it are
+// not delivered again. See Broker.StartPos.
+func WithCheckpoint(pos *state.ChainPos) SubscribeOption {
+	return func(s *Subscriber) {
+		if pos != nil && pos.BlockHash != "" {
+			cp := *pos
+			s.checkpoint, s.queuedPos = &cp, &cp
+		}
+	}
+}
+
+type Broker struct {
+	mu     sync.Mutex
+	subs   []*Subscriber
+	closed bool
+	Logger *slog.Logger // diagnostics (silent by default).
+}
+
+func NewBroker() *Broker {
+	return &Broker{Logger: slog.New(slog.DiscardHandler)}
+}
+
+// Subscribe adds a subscriber. Subscribers added while the broker is running
+// receive messages from that point on.
+func (b *Broker) Subscribe(name string, opts ...SubscribeOption) *Subscriber {
+	s := &Subscriber{
+		Name:       name,
+		bufferSize: DEFAULT_BUFFER_SIZE,
+		out:        make(chan messages.Message),
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.cond = sync.NewCond(&s.mu)
+	go s.deliver()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		s.close(nil)
+		return s
+	}
+	b.subs = append(b.subs, s)
+	b.Logger.Info("broker: subscribed", "name", name, "buffer", s.bufferSize, "policy", s.policy.String())
+	return s
+}
+
+// StartPos returns the position to start the follower from so that every
+// subscriber's checkpoint is covered: the lowest checkpoint (or the block
+// RewindOrphaned rolled it back to), or an empty ChainPos (use the
+// follower's start options) if any subscriber has none.
+func (b *Broker) StartPos() *state.ChainPos {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var start *state.ChainPos
+	for _, s := range b.subs {
+		cp := s.resumePos()
+		if cp == nil {
+			return &state.ChainPos{}
+		}
+		if start == nil || cp.BlockHeight < start.BlockHeight {
+			start = cp
+		}
+	}
+	if start == nil {
+		return &state.ChainPos{}
+	}
+	return start
+}
+
+// HeaderSource looks up block headers; an rpc.RpcTransportInterface is one.
+type HeaderSource interface {
+	GetBlockHeader(blockHash string) (*types.BlockHeader, error)
+}
+
+// RewindOrphaned checks each subscriber's checkpoint against the main chain.
+// A checkpoint on an orphaned block is walked back (Core keeps the headers
+// of orphaned blocks) to the last block still on the main chain, and a
+// rollback to that block is queued for the subscriber. Call it before
+// StartPos, so that the follower starts low enough to deliver the blocks
+// that replaced the orphaned ones.
+func (b *Broker) RewindOrphaned(headers HeaderSource) error {
+	for _, s := range b.Subscribers() {
+		pos := s.resumePos()
+		if pos == nil {
+			continue
+		}
+		hash := pos.BlockHash
+		for {
+			header, err := headers.GetBlockHeader(hash)
+			if err != nil {
+				return fmt.Errorf("broker: checking the checkpoint of %v: %w", s.Name, err)
+			}
+			if header.Confirmations != -1 {
+				if header.Hash != pos.BlockHash {
+					b.Logger.Warn("broker: checkpoint orphaned, rolling back", "name", s.Name, "height", pos.BlockHeight, "to", header.Height)
+					s.publish(messages.RollbackMessage{OldChainPos: pos, NewChainPos: &state.ChainPos{
+						BlockHash:   header.Hash,
+						BlockHeight: header.Height,
+						ChainName:   pos.ChainName,
+					}})
+				}
+				break
+			}
+			hash = header.PreviousBlockHash
+		}
+	}
+	return nil
+}
+
+// Subscribers returns the current subscribers.
+func (b *Broker) Subscribers() []*Subscriber {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*Subscriber(nil), b.subs...)
+}
+
+// Run delivers messages from the follower (the channel returned by Start)
+// to the subscribers until the channel is closed, then closes every
+// subscriber once it has received its queued messages.
+func (b *Broker) Run(msgs <-chan messages.Message) {
+	for msg := range msgs {
+		b.Publish(msg)
+	}
+	b.Close()
+}
+
+// Publish delivers one message to every subscriber, according to each
+// subscriber's filter and policy.
+func (b *Broker) Publish(msg messages.Message) {
+	for _, s := range b.Subscribers() {
+		if err := s.publish(msg); err != nil {
+			b.Logger.Warn("broker: subscriber disconnected", "name", s.Name, "error", err)
+			b.remove(s)
+		}
+	}
+}
+
+// Unsubscribe closes a subscriber; queued messages are discarded.
+func (b *Broker) Unsubscribe(s *Subscriber) {
+	b.remove(s)
+	s.close(ErrUnsubscribed)
+}
+
+// Close closes every subscriber after its queued messages are delivered.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	subs := b.subs
+	b.subs = nil
+	b.closed = true
+	b.mu.Unlock()
+	for _, s := range subs {
+		s.close(nil)
+	}
+}
+
+func (b *Broker) remove(s *Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, sub := range b.subs {
+		if sub == s {
+			b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Subscriber receives the follower's messages on Messages().
+type Subscriber struct {
+	Name       string
+	bufferSize int
+	policy     Policy
+	filter     Filter
+	out        chan messages.Message
+	done       chan struct{}
+
+	mu         sync.Mutex
+	cond       *sync.Cond // signals queue changes.
+	queue      []queued
+	seq        uint64 // sequence number of the last queued message
+	closing    bool   // no more messages; close out once the queue is empty.
+	err        error
+	checkpoint *state.ChainPos // position of the last block or rollback received
+	queuedPos  *state.ChainPos // position of the last block or rollback queued
+	dropped    int64
+}
+
+type queued struct {
+	seq uint64
+	msg messages.Message // nil if filtered out (only moves the checkpoint)
+	pos *state.ChainPos  // new checkpoint once received (nil = unchanged)
+}
+
+// Messages returns the subscriber's channel; it is closed when the broker
+// closes or the subscriber is disconnected (see Err).
+func (s *Subscriber) Messages() <-chan messages.Message {
+	return s.out
+}
+
+// Checkpoint returns the position of the last block (or rollback) the
+// subscriber has received, including blocks its filter skipped; save it to
+// resume with WithCheckpoint. Nil until the first block.
+func (s *Subscriber) Checkpoint() *state.ChainPos {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.checkpoint == nil {
+		return nil
+	}
+	cp := *s.checkpoint
+	return &cp
+}
+
+// resumePos returns the position of the last block or rollback queued.
+func (s *Subscriber) resumePos() *state.ChainPos {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.queuedPos == nil {
+		return nil
+	}
+	pos := *s.queuedPos
+	return &pos
+}
+
+// Err returns why the subscriber was closed early (ErrSlowConsumer or
+// ErrUnsubscribed), or nil.
+func (s *Subscriber) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Dropped returns the number of messages discarded by the DropOldest policy.
+func (s *Subscriber) Dropped() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Backlog returns the number of queued messages.
+func (s *Subscriber) Backlog() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+func (s *Subscriber) publish(msg messages.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		return nil
+	}
+	for _, item := range s.prepare(msg) {
+		for len(s.queue) >= s.bufferSize {
+			switch s.policy {
+			case DropOldest:
+				s.queue = s.queue[1:]
+				s.dropped++
+			case Disconnect:
+				s.queue = nil
+				s.closeLocked(ErrSlowConsumer)
+				return ErrSlowConsumer
+			default:
+				s.cond.Wait()
+				if s.closing {
+					return nil
+				}
+			}
+		}
+		s.seq++
+		item.seq = s.seq
+		s.queue = append(s.queue, item)
+		s.cond.Broadcast()
+	}
+	return nil
+}
+
+// prepare applies the checkpoint and filter to a message (with s.mu held). A
+// block at the checkpoint's height with a different hash means the
+// checkpoint's block was orphaned while the subscriber was away: it is
+// preceded by a rollback to the block's parent.
+func (s *Subscriber) prepare(msg messages.Message) []queued {
+	var items []queued
+	if m, ok := msg.(messages.BlockMessage); ok && s.queuedPos != nil && m.Block.Height <= s.queuedPos.BlockHeight {
+		if m.Block.Height < s.queuedPos.BlockHeight || m.Block.Hash == s.queuedPos.BlockHash {
+			return nil // already received before the checkpoint was saved.
+		}
+		old := *s.queuedPos
+		items = s.apply(items, messages.RollbackMessage{OldChainPos: &old, NewChainPos: &state.ChainPos{
+			BlockHash:   m.Block.PreviousBlockHash,
+			BlockHeight: m.Block.Height - 1,
+			ChainName:   old.ChainName,
+		}})
+	}
+	return s.apply(items, msg)
+}
+
+// apply filters msg and adds it to items, unless nothing is left of it.
+func (s *Subscriber) apply(items []queued, msg messages.Message) []queued {
+	item := queued{msg: msg, pos: messages.Position(msg)}
+	if s.filter != nil {
+		item.msg = s.filter(msg)
+	}
+	if item.msg == nil && item.pos == nil {
+		return items
+	}
+	if item.pos != nil {
+		s.queuedPos = item.pos
+	}
+	return append(items, item)
+}
+
+// deliver sends queued messages to the subscriber.
+func (s *Subscriber) deliver() {
+	defer close(s.out)
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && !s.closing {
+			s.cond.Wait()
+		}
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		item := s.queue[0]
+		s.mu.Unlock()
+
+		if item.msg != nil {
+			select {
+			case s.out <- item.msg:
+			case <-s.done:
+				return
+			}
+		}
+
+		s.mu.Lock()
+		if len(s.queue) > 0 && s.queue[0].seq == item.seq { // (unless dropped meanwhile)
+			s.queue = s.queue[1:]
+		}
+		if item.pos != nil {
+			s.checkpoint = item.pos
+		}
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	}
+}
+
+// close stops the subscriber; with an error, queued messages are discarded.
+func (s *Subscriber) close(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeLocked(err)
+}
+
+func (s *Subscriber) closeLocked(err error) {
+	if s.closing {
+		return
+	}
+	s.closing = true
+	if err != nil {
+		s.err = err
+		s.queue = nil
+		close(s.done)
+	}
+	s.cond.Broadcast()
+}
@@ -0,0 +1,202 @@
+package broker
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/rpc"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+)
+
+func receive(t *testing.T, s *Subscriber) messages.Message {
+	t.Helper()
+	select {
+	case msg := <-s.Messages():
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%v: timed out", s.Name)
+		return nil
+	}
+}
+
+func heights(s *Subscriber) []int64 {
+	var hs []int64
+	for msg := range s.Messages() {
+		if m, ok := msg.(messages.BlockMessage); ok {
+			hs = append(hs, m.Block.Height)
+		}
+	}
+	return hs
+}
+
+func TestSlowSubscribers(t *testing.T) {
+	b := NewBroker()
+	fast := b.Subscribe("fast", WithBuffer(1))
+	dropping := b.Subscribe("dropping", WithBuffer(3), WithPolicy(DropOldest))
+	disconnecting := b.Subscribe("disconnecting", WithBuffer(3), WithPolicy(Disconnect))
+
+	received := make(chan []int64)
+	go func() { received <- heights(fast) }()
+
+	msgs := make(chan messages.Message)
+	go b.Run(msgs)
+	for h := int64(1); h <= 10; h++ {
+		msgs <- rpc.TestBlockMessage(h)
+	}
+	close(msgs)
+
+	if got := <-received; len(got) != 10 || got[0] != 1 || got[9] != 10 {
+		t.Errorf("fast subscriber got %v", got)
+	}
+	if cp := fast.Checkpoint(); cp == nil || cp.BlockHeight != 10 || cp.ChainName != "doge_main" {
+		t.Errorf("fast checkpoint %+v", cp)
+	}
+
+	// the dropping subscriber sees the newest messages (its first message
+	// may already be in flight), the disconnected one sees nothing more.
+	got := heights(dropping)
+	if len(got) < 3 || got[len(got)-1] != 10 || dropping.Dropped() == 0 {
+		t.Errorf("dropping subscriber got %v (dropped %d)", got, dropping.Dropped())
+	}
+	for range disconnecting.Messages() {
+	}
+	if disconnecting.Err() != ErrSlowConsumer {
+		t.Errorf("expected ErrSlowConsumer, got %v", disconnecting.Err())
+	}
+	if len(b.Subscribers()) != 0 {
+		t.Errorf("subscribers remain after close")
+	}
+}
+
+func TestBlockPolicy(t *testing.T) {
+	b := NewBroker()
+	s := b.Subscribe("blocking", WithBuffer(2))
+	published := make(chan struct{})
+	go func() {
+		for h := int64(1); h <= 5; h++ {
+			b.Publish(rpc.TestBlockMessage(h))
+		}
+		close(published)
+	}()
+	select {
+	case <-published:
+		t.Fatal("publish did not wait for a full subscriber")
+	case <-time.After(50 * time.Millisecond):
+	}
+	for h := int64(1); h <= 5; h++ {
+		if m := receive(t, s).(messages.BlockMessage); m.Block.Height != h {
+			t.Fatalf("got height %d, expected %d", m.Block.Height, h)
+		}
+	}
+	<-published
+	b.Unsubscribe(s)
+	if _, ok := <-s.Messages(); ok || s.Err() != ErrUnsubscribed {
+		t.Errorf("expected closed subscriber, got %v", s.Err())
+	}
+}
+
+func TestCheckpointsAndFilters(t *testing.T) {
+	b := NewBroker()
+	ahead := b.Subscribe("ahead", WithCheckpoint(rpc.TestBlockMessage(5).ChainPos))
+	behind := b.Subscribe("behind", WithCheckpoint(rpc.TestBlockMessage(2).ChainPos))
+	rollbacks := b.Subscribe("rollbacks", WithFilter(Types(messages.RollbackMessage{})))
+	if pos := b.StartPos(); pos.BlockHash != "" {
+		t.Errorf("expected an empty start position, got %+v", pos)
+	}
+	b.Unsubscribe(rollbacks)
+	if pos := b.StartPos(); pos.BlockHeight != 2 {
+		t.Errorf("expected start at height 2, got %+v", pos)
+	}
+	rollbacks = b.Subscribe("rollbacks", WithFilter(Types(messages.RollbackMessage{})))
+
+	go func() {
+		for h := int64(2); h <= 7; h++ { // the follower redelivers the start block
+			b.Publish(rpc.TestBlockMessage(h))
+		}
+		b.Publish(rpc.TestRollbackMessage(7, 4))
+		b.Publish(rpc.TestBlockMessage(5))
+		b.Close()
+	}()
+	results := map[string]chan []int64{}
+	for _, s := range []*Subscriber{ahead, behind} {
+		result := make(chan []int64)
+		results[s.Name] = result
+		go func() { result <- heights(s) }()
+	}
+	if m, ok := receive(t, rollbacks).(messages.RollbackMessage); !ok || m.NewChainPos.BlockHeight != 4 {
+		t.Errorf("unexpected message %+v", m)
+	}
+	for range rollbacks.Messages() {
+	}
+	if cp := rollbacks.Checkpoint(); cp.BlockHeight != 5 {
+		t.Errorf("filtered blocks should move the checkpoint: %+v", cp)
+	}
+	if got := fmt.Sprint(<-results["ahead"]); got != "[6 7 5]" {
+		t.Errorf("ahead got %v", got)
+	}
+	if got := fmt.Sprint(<-results["behind"]); got != "[3 4 5 6 7 5]" {
+		t.Errorf("behind got %v", got)
+	}
+}
+
+func TestOrphanedCheckpoints(t *testing.T) {
+	// the chain forked after block 4; blocks 5 and 6 of the old branch
+	// are orphaned.
+	headers := rpc.NewTestRpcTransport()
+	for h := int64(1); h <= 8; h++ {
+		block := rpc.TestBlockMessage(h).Block
+		headers.AddBlockAndHeader(block, &types.BlockHeader{Hash: block.Hash, Height: h, Confirmations: 9 - h, PreviousBlockHash: block.PreviousBlockHash})
+	}
+	orphan5, orphan6 := rpc.TestForkBlockMessage(1, 5), rpc.TestForkBlockMessage(1, 6)
+	orphan5.Block.PreviousBlockHash = rpc.TestBlockHash(4)
+	for _, m := range []messages.BlockMessage{orphan5, orphan6} {
+		headers.AddBlockAndHeader(m.Block, &types.BlockHeader{Hash: m.Block.Hash, Height: m.Block.Height, Confirmations: -1, PreviousBlockHash: m.Block.PreviousBlockHash})
+	}
+
+	b := NewBroker()
+	current := b.Subscribe("current", WithCheckpoint(rpc.TestBlockMessage(3).ChainPos))
+	rewound := b.Subscribe("rewound", WithCheckpoint(orphan6.ChainPos))
+	if err := b.RewindOrphaned(headers); err != nil {
+		t.Fatal(err)
+	}
+	// only the checkpoint's own block shows that it was orphaned.
+	unchecked := b.Subscribe("unchecked", WithCheckpoint(orphan6.ChainPos))
+	if pos := b.StartPos(); pos.BlockHeight != 3 {
+		t.Errorf("expected start at height 3, got %+v", pos)
+	}
+
+	go func() {
+		for h := int64(3); h <= 8; h++ {
+			b.Publish(rpc.TestBlockMessage(h))
+		}
+		b.Close()
+	}()
+	results := map[string]chan string{}
+	for _, s := range []*Subscriber{current, rewound, unchecked} {
+		result := make(chan string)
+		results[s.Name] = result
+		go func() {
+			var got []string
+			for msg := range s.Messages() {
+				switch m := msg.(type) {
+				case messages.BlockMessage:
+					got = append(got, fmt.Sprint(m.Block.Height))
+				case messages.RollbackMessage:
+					got = append(got, fmt.Sprintf("rollback %d-%d", m.OldChainPos.BlockHeight, m.NewChainPos.BlockHeight))
+				}
+			}
+			result <- fmt.Sprint(got)
+		}()
+	}
+	for name, expect := range map[string]string{
+		"current":   "[4 5 6 7 8]",
+		"rewound":   "[rollback 6-4 5 6 7 8]",
+		"unchecked": "[rollback 6-5 6 7 8]",
+	} {
+		if got := <-results[name]; got != expect {
+			t.Errorf("%v got %v, expected %v", name, got, expect)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package chainfollower
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/state"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+)
+
+// Backfill mode: deliver an exact range of historical blocks, optionally
+// split into shards fetched concurrently, then close the channel. Blocks
+// are fetched by height, so the range should be well below the tip (there
+// are no RollbackMessages); use Start to follow the tip afterwards.
+
+// SplitRange divides the heights from..to (inclusive) into n contiguous shards.
+func SplitRange(from, to int64, n int) []state.BackfillShard {
+	total := to - from + 1
+	if n < 1 || total < 1 {
+		return nil
+	}
+	if int64(n) > total {
+		n = int(total)
+	}
+	shards := make([]state.BackfillShard, 0, n)
+	start := from
+	for i := 0; i < n; i++ {
+		size := total / int64(n)
+		if int64(i) < total%int64(n) {
+			size++
+		}
+		shards = append(shards, state.BackfillShard{Index: i, From: start, To: start + size - 1, Next: start})
+		start += size
+	}
+	return shards
+}
+
+// Backfill delivers blocks from..to (inclusive) as BackfillBlockMessages,
+// fetched by `shards` concurrent goroutines, then sends a
+// BackfillCompleteMessage and closes the channel.
+func (c *ChainFollower) Backfill(from, to int64, shards int) chan messages.Message {
+	if shards < 1 || from < 0 || to < from {
+		return c.failBackfill(from, to, fmt.Errorf("chainfollower: invalid backfill range %d..%d with %d shards", from, to, shards))
+	}
+	return c.ResumeBackfill(SplitRange(from, to, shards))
+}
+
+// ResumeBackfill continues a backfill from saved shard progress (see
+// store.LoadBackfillShards); finished shards are skipped.
+func (c *ChainFollower) ResumeBackfill(shards []state.BackfillShard) chan messages.Message {
+	if len(shards) == 0 {
+		return c.failBackfill(0, -1, errors.New("chainfollower: no backfill shards"))
+	}
+	from, to := shards[0].From, shards[0].To
+	for _, s := range shards {
+		if s.From < 0 || s.To < s.From || s.Next < s.From || s.Next > s.To+1 {
+			return c.failBackfill(from, to, fmt.Errorf("chainfollower: invalid backfill shard %+v", s))
+		}
+		if s.ChainName != shards[0].ChainName {
+			return c.failBackfill(from, to, fmt.Errorf("chainfollower: backfill shards are from different chains: %q and %q", shards[0].ChainName, s.ChainName))
+		}
+		from, to = min(from, s.From), max(to, s.To)
+	}
+
+	c.Messages = make(chan messages.Message, c.MessageChannelSize)
+	shards = append([]state.BackfillShard(nil), shards...)
+	go c.serviceBackfill(from, to, shards)
+	return c.Messages
+}
+
+func (c *ChainFollower) failBackfill(from, to int64, err error) chan messages.Message {
+	c.Messages = make(chan messages.Message, 1)
+	c.Messages <- messages.BackfillCompleteMessage{From: from, To: to, Err: err}
+	close(c.Messages)
+	return c.Messages
+}
+
+func (c *ChainFollower) serviceBackfill(from, to int64, shards []state.BackfillShard) {
+	started := time.Now()
+	c.updateStatus(func(s *Status) { s.Running = true })
+
+	var blocks, txns atomic.Int64
+	err := c.runBackfill(to, shards, &blocks, &txns)
+	if err != nil {
+		c.Logger.Error("ChainFollower: backfill failed", "from", from, "to", to, "error", err)
+	} else {
+		c.Logger.Info("ChainFollower: backfill complete", "from", from, "to", to, "blocks", blocks.Load(), "elapsed", time.Since(started))
+	}
+	c.setFatal(err)
+
+	c.send(messages.BackfillCompleteMessage{
+		From:         from,
+		To:           to,
+		Blocks:       blocks.Load(),
+		Transactions: txns.Load(),
+		Shards:       shards,
+		Elapsed:      time.Since(started),
+		Err:          err,
+	})
+	close(c.Messages)
+}
+
+func (c *ChainFollower) runBackfill(to int64, shards []state.BackfillShard, blocks, txns *atomic.Int64) error {
+	info, err := c.identifyChain(&state.ChainPos{ChainName: shards[0].ChainName}, false)
+	if err != nil {
+		return err
+	}
+	if to > info.Blocks {
+		return fmt.Errorf("chainfollower: backfill to height %d is above the Core node's tip %d", to, info.Blocks)
+	}
+	c.Logger.Info("ChainFollower: BACKFILL", "chain", c.chainName(), "shards", len(shards))
+
+	ctx, cancel := context.WithCancel(c.context)
+	defer cancel()
+	var wg sync.WaitGroup
+	errs := make([]error, len(shards))
+	for i := range shards {
+		shards[i].ChainName = c.chainName()
+		wg.Add(1)
+		go func(shard *state.BackfillShard) {
+			defer wg.Done()
+			if err := c.backfillShard(ctx, shard, blocks, txns); err != nil && !errors.Is(err, context.Canceled) {
+				errs[i] = fmt.Errorf("shard %d at height %d: %w", shard.Index, shard.Next, err)
+				cancel() // stop the other shards.
+			}
+		}(&shards[i])
+	}
+	wg.Wait()
+
+	if err := c.context.Err(); err != nil {
+		return err // stopped.
+	}
+	return errors.Join(errs...)
+}
+
+// backfillShard delivers the shard's remaining blocks in height order.
+func (c *ChainFollower) backfillShard(ctx context.Context, shard *state.BackfillShard, blocks, txns *atomic.Int64) error {
+	for !shard.Done() {
+		block, err := c.fetchBlockAtHeight(ctx, shard.Next)
+		if err != nil {
+			return err
+		}
+		if mismatch := c.verifyCheckpoint(block.Height, block.Hash); mismatch != nil {
+			c.alertCheckpoint(mismatch)
+			return mismatch
+		}
+		if err := c.verifyBlock(block); err != nil {
+			c.Logger.Error("ChainFollower: INVALID BLOCK! Refusing block from the Core Node", "height", block.Height, "hash", block.Hash, "error", err.Err)
+			c.send(messages.InvalidBlockMessage{Err: err})
+			return err
+		}
+
+		delivered := block
+		if c.opts.BlockFilter != nil {
+			delivered = c.opts.BlockFilter.FilterBlock(block)
+		}
+		shard.Next++
+		c.send(messages.BackfillBlockMessage{Block: delivered, Shard: *shard})
+		if c.opts.DataCarrier {
+			c.sendDataCarriers(block)
+		}
+		blocks.Add(1)
+		txns.Add(int64(len(block.Tx)))
+	}
+	return nil
+}
+
+// fetchBlockAtHeight fetches a block on the main chain, retrying RPC errors
+// up to Retry.MaxStartAttempts times.
+func (c *ChainFollower) fetchBlockAtHeight(ctx context.Context, height int64) (*types.Block, error) {
+	var err error
+	for attempt := 0; attempt < c.opts.Retry.MaxStartAttempts; attempt++ {
+		if attempt > 0 {
+			c.Logger.Warn("ChainFollower: backfill fetch failed", "height", height, "attempt", attempt, "error", err)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(c.opts.Retry.RetryDelay):
+			}
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		var hash string
+		hash, err = c.rpc.GetBlockHash(height)
+		if err != nil {
+			continue
+		}
+		var block *types.Block
+		block, err = c.rpc.GetBlock(hash)
+		if err == nil {
+			return block, nil
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,113 @@
+package chainfollower
+
+import (
+	"testing"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/rpc"
+	"github.com/dogecoinfoundation/chainfollower/pkg/state"
+)
+
+func TestSplitRange(t *testing.T) {
+	shards := SplitRange(10, 19, 3)
+	expected := [][2]int64{{10, 13}, {14, 16}, {17, 19}}
+	if len(shards) != len(expected) {
+		t.Fatalf("got %d shards", len(shards))
+	}
+	for i, s := range shards {
+		if s.Index != i || s.From != expected[i][0] || s.To != expected[i][1] || s.Next != s.From {
+			t.Errorf("shard %d: %+v", i, s)
+		}
+	}
+	if shards := SplitRange(5, 6, 4); len(shards) != 2 {
+		t.Errorf("expected at most one shard per block, got %d", len(shards))
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	follower := NewChainFollower(rpc.NewTestChain(20))
+	defer follower.Stop()
+
+	seen := map[int64]bool{}
+	next := map[int]int64{}
+	var complete messages.BackfillCompleteMessage
+	for msg := range follower.Backfill(3, 17, 4) {
+		switch m := msg.(type) {
+		case messages.BackfillBlockMessage:
+			if seen[m.Block.Height] {
+				t.Errorf("height %d delivered twice", m.Block.Height)
+			}
+			seen[m.Block.Height] = true
+			if last, ok := next[m.Shard.Index]; ok && m.Block.Height != last {
+				t.Errorf("shard %d: height %d out of order (expected %d)", m.Shard.Index, m.Block.Height, last)
+			}
+			if m.Shard.Next != m.Block.Height+1 || m.Shard.ChainName != "doge_main" {
+				t.Errorf("unexpected shard progress %+v", m.Shard)
+			}
+			next[m.Shard.Index] = m.Shard.Next
+		case messages.BackfillCompleteMessage:
+			complete = m
+		default:
+			t.Fatalf("unexpected message %T", msg)
+		}
+	}
+	if len(seen) != 15 || !seen[3] || !seen[17] {
+		t.Errorf("expected heights 3..17, got %v", seen)
+	}
+	if complete.Err != nil || complete.Blocks != 15 || complete.Transactions != 15 || len(complete.Shards) != 4 {
+		t.Errorf("unexpected summary %+v", complete)
+	}
+	for _, s := range complete.Shards {
+		if !s.Done() {
+			t.Errorf("shard not done: %+v", s)
+		}
+	}
+}
+
+func TestResumeBackfill(t *testing.T) {
+	follower := NewChainFollower(rpc.NewTestChain(10))
+	defer follower.Stop()
+
+	shards := []state.BackfillShard{
+		{Index: 0, From: 0, To: 4, Next: 5, ChainName: "doge_main"},
+		{Index: 1, From: 5, To: 9, Next: 8, ChainName: "doge_main"},
+	}
+	var heights []int64
+	var complete messages.BackfillCompleteMessage
+	for msg := range follower.ResumeBackfill(shards) {
+		switch m := msg.(type) {
+		case messages.BackfillBlockMessage:
+			heights = append(heights, m.Block.Height)
+		case messages.BackfillCompleteMessage:
+			complete = m
+		}
+	}
+	if len(heights) != 2 || heights[0] != 8 || heights[1] != 9 || complete.Err != nil {
+		t.Errorf("resumed heights %v, summary %+v", heights, complete)
+	}
+}
+
+func TestBackfillErrors(t *testing.T) {
+	for name, run := range map[string]func(f *ChainFollower) chan messages.Message{
+		"inverted range": func(f *ChainFollower) chan messages.Message { return f.Backfill(5, 4, 1) },
+		"above tip":      func(f *ChainFollower) chan messages.Message { return f.Backfill(0, 10, 1) },
+		"other chain": func(f *ChainFollower) chan messages.Message {
+			return f.ResumeBackfill([]state.BackfillShard{{From: 0, To: 1, ChainName: "doge_test"}})
+		},
+		"mixed chains": func(f *ChainFollower) chan messages.Message {
+			return f.ResumeBackfill([]state.BackfillShard{{From: 0, To: 1, Next: 0, ChainName: "doge_main"}, {Index: 1, From: 2, To: 3, Next: 2, ChainName: "doge_test"}})
+		},
+	} {
+		follower := NewChainFollower(rpc.NewTestChain(5))
+		var complete *messages.BackfillCompleteMessage
+		for msg := range run(follower) {
+			if m, ok := msg.(messages.BackfillCompleteMessage); ok {
+				complete = &m
+			}
+		}
+		if complete == nil || complete.Err == nil {
+			t.Errorf("%s: expected a failed summary, got %+v", name, complete)
+		}
+		follower.Stop()
+	}
+}
@@ -1,21 +1,25 @@
 package chainfollower
 
 import (
+	"bytes"
 	"context"
-	"fmt"
-	"log"
+	"log/slog"
+	"maps"
 	"math"
 	"math/rand"
-	"os"
-	"os/signal"
-	"syscall"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/dogecoinfoundation/chainfollower/internal/commands"
 	"github.com/dogecoinfoundation/chainfollower/internal/doge"
 	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/metrics"
 	"github.com/dogecoinfoundation/chainfollower/pkg/rpc"
+	"github.com/dogecoinfoundation/chainfollower/pkg/script"
 	"github.com/dogecoinfoundation/chainfollower/pkg/state"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
 )
 
 const (
@@ -29,109 +33,206 @@ const (
 type ChainFollowerInterface interface {
 	Start(chainState *state.ChainPos) chan messages.Message
 	Stop()
+	Shutdown(ctx context.Context) error
 }
 
 type ChainFollower struct {
 	ChainFollowerInterface
 	rpc                rpc.RpcTransportInterface
 	chain              *doge.ChainParams
+	checkpoints        map[int64]string                 // chain checkpoints plus Options.Checkpoints
+	verifiedTo         int64                            // checkpoints up to this height were verified against Core
+	verifier           *doge.BlockVerifier              // if Options.VerifyBlocks
 	Commands           chan any                         // receive ReSyncChainFollowerCmd etc.
-	stopping           bool                             // set to exit the main loop.
 	SetSync            *commands.ReSyncChainFollowerCmd // pending ReSync command.
 	Messages           chan messages.Message            // send messages to the main loop.
 	MessageChannelSize int
+	Metrics            *metrics.Metrics // optional: progress metrics (nil to disable).
+	Logger             *slog.Logger     // diagnostics (silent by default).
 	context            context.Context
 	cancel             context.CancelFunc
+	statusMu           sync.Mutex
+	status             Status
+	opts               Options
+	started            bool           // Start was called (Messages is closed by Shutdown)
+	stopped            chan struct{}  // closed by Stop: abandon blocked sends
+	stopOnce           sync.Once      // closes stopped
+	shutdownOnce       sync.Once      // starts closing Messages
+	closed             chan struct{}  // closed after Messages is closed by Shutdown
+	running            sync.WaitGroup // the main loop and the watchdog (senders on Messages)
+	acks               ackState       // positions sent and acknowledged (see Shutdown)
 
 	// receive signals from the main loop.
 }
 
-func NewChainFollower(rpc rpc.RpcTransportInterface) *ChainFollower {
+// New creates a follower using the given transport, or returns the errors
+// in its options (see Options.Validate).
+func New(rpc rpc.RpcTransportInterface, opts ...Option) (*ChainFollower, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
 	ctx, cancel := context.WithCancel(context.Background())
-	return &ChainFollower{rpc: rpc, MessageChannelSize: 0, context: ctx, cancel: cancel}
+	return &ChainFollower{
+		rpc:                rpc,
+		MessageChannelSize: o.ChannelSize,
+		Metrics:            o.Metrics,
+		Logger:             o.Logger,
+		context:            ctx,
+		cancel:             cancel,
+		verifiedTo:         -1,
+		status:             Status{Height: -1, TipHeight: -1, Confirmations: o.Confirmations},
+		opts:               o,
+		stopped:            make(chan struct{}),
+		closed:             make(chan struct{}),
+	}, nil
+}
+
+// NewChainFollower is like New but panics if the options are invalid.
+func NewChainFollower(rpc rpc.RpcTransportInterface, opts ...Option) *ChainFollower {
+	c, err := New(rpc, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return c
 }
 
 func (c *ChainFollower) Start(chainState *state.ChainPos) chan messages.Message {
-	go c.handleSignals()
+	if c.opts.HandleSignals {
+		c.handleSignals()
+	}
 
 	c.Messages = make(chan messages.Message, c.MessageChannelSize)
+	c.started = true
 
-	go c.serviceMain(chainState)
+	c.running.Add(1)
+	go func() {
+		defer c.running.Done()
+		c.serviceMain(chainState)
+	}()
+	if c.opts.Watchdog.enabled() {
+		c.running.Add(1)
+		go func() {
+			defer c.running.Done()
+			c.runWatchdog()
+		}()
+	}
 
 	return c.Messages
 }
 
+// Stop stops the follower at once: a message the consumer has not yet
+// received may be dropped, and Messages is left open. Use Shutdown to
+// deliver in-flight messages first.
 func (c *ChainFollower) Stop() {
-	if !c.stopping {
+	c.stopOnce.Do(func() {
 		c.cancel()
-		c.stopping = true
-	}
-}
-
-func (c *ChainFollower) handleSignals() {
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
-	go func() {
-		for sig := range sigCh {
-			fmt.Printf("Caught %v signal, shutting down\n", sig)
-			c.Stop()
-		}
-	}()
+		close(c.stopped)
+	})
 }
 
 func (c *ChainFollower) serviceMain(chainState *state.ChainPos) {
-	const maxRetries = 5
-	const baseDelay = time.Second
+	maxRetries := c.opts.Retry.MaxStartAttempts
+	baseDelay := c.opts.Retry.BaseDelay
 
 	var chainPos *state.ChainPos
 	var err error
 
+	c.updateStatus(func(s *Status) { s.Running = true })
+
 	for attempt := 0; attempt < maxRetries; attempt++ {
 		chainPos, err = c.fetchStartingPos(chainState)
 		if err == nil {
 			break
 		}
 
-		log.Printf("ChainFollower: fetchStartingPos failed (attempt %d/%d): %v", attempt+1, maxRetries, err)
+		c.Logger.Warn("ChainFollower: fetchStartingPos failed", "attempt", attempt+1, "max_attempts", maxRetries, "error", err)
 
 		// Exponential backoff with jitter
 		backoff := time.Duration(float64(baseDelay) * math.Pow(2, float64(attempt)))
 		jitter := time.Duration(rand.Int63n(int64(backoff / 2)))
 		sleep := backoff + jitter
 
-		time.Sleep(sleep)
+		if !c.sleep(sleep) {
+			break
+		}
 	}
 
+	if c.context.Err() != nil {
+		c.setFatal(nil)
+		return
+	}
 	if err != nil {
-		log.Println("ChainFollower: fetchStartingPos failed:", err)
+		c.Logger.Error("ChainFollower: fetchStartingPos failed", "error", err)
+		c.setFatal(err)
 		return
 	}
 
 	for {
 		select {
 		case <-c.context.Done():
+			c.setFatal(nil)
 			return
 		default:
 			blockHeader, err := c.rpc.GetBlockHeader(chainPos.BlockHash)
 			if err != nil {
-				log.Println("ChainFollower: GetBlockHeader failed:", err)
+				c.Logger.Error("ChainFollower: GetBlockHeader failed", "hash", chainPos.BlockHash, "error", err)
+				c.setFatal(err)
 				return
 			}
 
 			if blockHeader.IsOnChain() {
+				if !chainPos.WaitingForNextHash && blockHeader.Confirmations < c.opts.Confirmations {
+					// wait until the block is buried deeply enough.
+					c.sleep(c.opts.PollInterval)
+					continue
+				}
+
 				if !chainPos.WaitingForNextHash {
-					// fmt.Println("ChainFollower: GetBlock", chainPos.BlockHash)
+					c.Logger.Debug("ChainFollower: GetBlock", "height", blockHeader.Height, "hash", blockHeader.Hash)
 					block, err := c.rpc.GetBlock(blockHeader.Hash)
 					if err != nil {
-						log.Println("ChainFollower: GetBlock failed:", err)
+						c.Logger.Error("ChainFollower: GetBlock failed", "height", blockHeader.Height, "hash", blockHeader.Hash, "error", err)
+						c.setFatal(err)
 						return
 					}
 
+					// (the genesis block was already checked by fetchStartingPos)
+					if err := c.verifyCheckpoint(block.Height, block.Hash); block.Height > 0 && err != nil {
+						c.alertCheckpoint(err)
+						c.sleepForRetry(c.opts.Retry.WrongChainDelay)
+						continue
+					}
+
+					if err := c.verifyBlock(block); err != nil {
+						c.Logger.Error("ChainFollower: INVALID BLOCK! Refusing block from the Core Node", "height", block.Height, "hash", block.Hash, "error", err.Err)
+						c.send(messages.InvalidBlockMessage{Err: err})
+						c.sleepForRetry(c.opts.Retry.RetryDelay)
+						continue
+					}
+
 					chainPos.WaitingForNextHash = true
 
-					c.Messages <- messages.BlockMessage{
-						Block:    block,
-						ChainPos: chainPos,
+					c.Metrics.ObserveBlock(block.Height, int64(block.Time), tipHeight(block))
+					c.updateStatus(func(s *Status) {
+						s.Height = block.Height
+						s.TipHeight = max(s.TipHeight, tipHeight(block))
+						s.LastBlockSeen = time.Now()
+					})
+					delivered := block
+					if c.opts.BlockFilter != nil {
+						delivered = c.opts.BlockFilter.FilterBlock(block)
+					}
+					pos := *chainPos // the consumer's copy; chainPos keeps moving.
+					c.send(messages.BlockMessage{
+						Block:    delivered,
+						ChainPos: &pos,
+					})
+					if c.opts.DataCarrier {
+						c.sendDataCarriers(block)
 					}
 				}
 
@@ -144,36 +245,102 @@ func (c *ChainFollower) serviceMain(chainState *state.ChainPos) {
 
 				// TODO : Rethink this
 				if chainPos.WaitingForNextHash {
-					time.Sleep(1 * time.Second)
+					c.updateStatus(func(s *Status) { s.TipHeight = blockHeader.Height })
+					c.sleep(c.opts.PollInterval)
 				}
 			} else {
 
 				oldChainPos := chainPos
 				chainPos, err = c.rollbackToOnChainBlock(blockHeader.PreviousBlockHash)
 				if err != nil {
-					log.Println("ChainFollower: rollbackToOnChainBlock failed:", err)
+					c.Logger.Error("ChainFollower: rollbackToOnChainBlock failed", "hash", blockHeader.PreviousBlockHash, "error", err)
+					c.setFatal(err)
 					return
 				}
 
 				oldChainPos.WaitingForNextHash = false
 				chainPos.WaitingForNextHash = false
 
-				c.Messages <- messages.RollbackMessage{
+				c.Logger.Info("ChainFollower: ROLLBACK", "from_height", oldChainPos.BlockHeight, "from_hash", oldChainPos.BlockHash, "height", chainPos.BlockHeight, "hash", chainPos.BlockHash)
+				c.Metrics.ObserveReorg(max(oldChainPos.BlockHeight-chainPos.BlockHeight, 1))
+				newPos := *chainPos // the consumer's copy; chainPos keeps moving.
+				c.send(messages.RollbackMessage{
 					OldChainPos: oldChainPos,
-					NewChainPos: chainPos,
-				}
+					NewChainPos: &newPos,
+				})
+			}
+		}
+	}
+}
+
+// send delivers a message to the consumer, recording the channel backlog.
+// It gives up if the follower is stopped, but not while shutting down, so
+// that in-flight messages are delivered.
+func (c *ChainFollower) send(msg messages.Message) {
+	select {
+	case c.Messages <- msg:
+	case <-c.stopped:
+		return
+	}
+	c.acks.record(msg)
+	c.Metrics.ObserveBacklog(len(c.Messages))
+}
+
+// sendDataCarriers sends a DataCarrierMessage for each matching OP_RETURN output.
+func (c *ChainFollower) sendDataCarriers(block *types.Block) {
+	for txIndex, tx := range block.Tx {
+		for _, vout := range tx.VOut {
+			if !strings.HasPrefix(vout.ScriptPubKey.Hex, "6a") { // OP_RETURN
+				continue
+			}
+			s, err := script.ClassifyScriptPubKey(vout.ScriptPubKey)
+			if err != nil || s.Type != script.NullData {
+				continue
 			}
+			data := bytes.Join(s.Data, nil)
+			if !hasAnyPrefix(data, c.opts.DataPrefixes) {
+				continue
+			}
+			c.send(messages.DataCarrierMessage{
+				BlockHash:   block.Hash,
+				BlockHeight: block.Height,
+				TxIndex:     txIndex,
+				TxID:        tx.TxID,
+				VOut:        vout.N,
+				Pushes:      s.Data,
+				Data:        data,
+			})
+		}
+	}
+}
+
+func hasAnyPrefix(data []byte, prefixes [][]byte) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if bytes.HasPrefix(data, prefix) {
+			return true
 		}
 	}
+	return false
+}
+
+// tipHeight derives the Core node's best height from a block's confirmations.
+func tipHeight(block *types.Block) int64 {
+	if block.Confirmations <= 0 {
+		return -1
+	}
+	return block.Height + block.Confirmations - 1
 }
 
 func (c *ChainFollower) rollbackToOnChainBlock(fromHash string) (*state.ChainPos, error) {
 	for {
 		// Fetch the block header for the previous block.
-		log.Println("ChainFollower: fetching previous header:", fromHash)
+		c.Logger.Info("ChainFollower: fetching previous header", "hash", fromHash)
 		block, err := c.rpc.GetBlockHeader(fromHash)
 		if err != nil {
-			log.Println("ChainFollower: GetBlockHeader failed:", err)
+			c.Logger.Error("ChainFollower: GetBlockHeader failed", "hash", fromHash, "error", err)
 			return nil, err
 		}
 
@@ -187,15 +354,23 @@ func (c *ChainFollower) rollbackToOnChainBlock(fromHash string) (*state.ChainPos
 				BlockHash:          block.Hash,
 				BlockHeight:        block.Height,
 				WaitingForNextHash: false,
+				ChainName:          c.chainName(),
 			}
 			return pos, nil
 		}
 	}
 }
 
-func (c *ChainFollower) fetchStartingPos(initialChainPos *state.ChainPos) (*state.ChainPos, error) {
+// identifyChain checks that the Core node is on a recognised and expected
+// chain that passes the checkpoints, recording the chain. If wait is set,
+// it waits (and retries) while the node is on the wrong chain or in initial
+// block download; otherwise those are errors.
+func (c *ChainFollower) identifyChain(initialChainPos *state.ChainPos, wait bool) (*types.BlockchainInfo, error) {
 	// Retry loop for transaction error or wrong-chain error.
 	for {
+		if err := c.context.Err(); err != nil {
+			return nil, err // stopped.
+		}
 		genesisHash, err := c.rpc.GetBlockHash(0)
 		if err != nil {
 			return nil, err
@@ -203,70 +378,186 @@ func (c *ChainFollower) fetchStartingPos(initialChainPos *state.ChainPos) (*stat
 
 		chain, err := doge.ChainFromGenesisHash(genesisHash)
 		if err != nil {
-			log.Println("ChainFollower: UNRECOGNISED CHAIN!")
-			log.Println("ChainFollower: Block#0 on Core Node:", genesisHash)
-			log.Println("ChainFollower: The Genesis block does not match any of our ChainParams")
-			log.Println("ChainFollower: Please connect to a Dogecoin Core Node")
-			c.sleepForRetry(WRONG_CHAIN_DELAY)
+			c.Logger.Error("ChainFollower: UNRECOGNISED CHAIN! The Genesis block does not match any of our ChainParams; please connect to a Dogecoin Core Node or register the chain (chainparams.RegisterChainParams)", "genesis_hash", genesisHash)
+			if !wait {
+				return nil, err
+			}
+			c.sleepForRetry(c.opts.Retry.WrongChainDelay)
 			continue
 		}
-		c.chain = chain
 
 		info, err := c.rpc.GetBlockchainInfo()
 		if err != nil {
 			return nil, err
 		}
 
-		if info.InitialBlockDownload {
-			log.Println("ChainFollower: waiting for Core initial block download")
-			c.sleepForRetry(WAIT_INITIAL_BLOCK)
+		if mismatch := c.checkChain(chain, genesisHash, info.Chain, initialChainPos); mismatch != nil {
+			c.Logger.Error("ChainFollower: WRONG CHAIN! Refusing to follow the Core Node", "expected", mismatch.Expected, "detected", mismatch.Detected, "network", mismatch.CoreNetwork, "reason", mismatch.Reason)
+			c.send(messages.ChainMismatchMessage{Err: mismatch})
+			if !wait {
+				return nil, mismatch
+			}
+			c.sleepForRetry(c.opts.Retry.WrongChainDelay)
 			continue
 		}
+		c.chain = chain
+		c.updateStatus(func(s *Status) { s.Chain = chain.ChainName })
 
-		if initialChainPos.BlockHash != "" {
-			log.Println("ChainFollower: RESUME SYNC :", initialChainPos.BlockHeight)
+		if c.opts.VerifyBlocks {
+			c.verifier = &doge.BlockVerifier{Chain: chain}
+		}
 
-			return &state.ChainPos{
-				BlockHash:          initialChainPos.BlockHash,
-				BlockHeight:        initialChainPos.BlockHeight,
-				WaitingForNextHash: false,
-			}, nil
-		} else {
-			firstHeight, err := c.rpc.GetBlockCount()
-			if err != nil {
-				return nil, err
+		c.checkpoints, err = chain.MergeCheckpoints(c.opts.Checkpoints)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.verifyCheckpointsBelow(info.Blocks); err != nil {
+			if mismatch, ok := err.(*CheckpointMismatchError); ok {
+				c.alertCheckpoint(mismatch)
+				if !wait {
+					return nil, mismatch
+				}
+				c.sleepForRetry(c.opts.Retry.WrongChainDelay)
+				continue
 			}
+			return nil, err
+		}
 
-			if firstHeight > 100 {
-				firstHeight -= 100
-			} else {
-				firstHeight = 0
-			}
+		c.updateStatus(func(s *Status) {
+			s.InitialBlockDownload = info.InitialBlockDownload
+			s.TipHeight = info.Blocks
+		})
 
-			firstBlockHash, err := c.rpc.GetBlockHash(firstHeight)
-			if err != nil {
-				return nil, err
-			}
+		if info.InitialBlockDownload && wait {
+			c.Logger.Info("ChainFollower: waiting for Core initial block download", "blocks", info.Blocks, "headers", info.Headers)
+			c.sleepForRetry(c.opts.Retry.IBDDelay)
+			continue
+		}
+		return info, nil
+	}
+}
 
-			return &state.ChainPos{
-				BlockHash:          firstBlockHash,
-				BlockHeight:        firstHeight,
-				WaitingForNextHash: false,
-			}, nil
+func (c *ChainFollower) fetchStartingPos(initialChainPos *state.ChainPos) (*state.ChainPos, error) {
+	if _, err := c.identifyChain(initialChainPos, true); err != nil {
+		return nil, err
+	}
+
+	if initialChainPos.BlockHash != "" {
+		c.Logger.Info("ChainFollower: RESUME SYNC", "height", initialChainPos.BlockHeight, "hash", initialChainPos.BlockHash)
+
+		return &state.ChainPos{
+			BlockHash:          initialChainPos.BlockHash,
+			BlockHeight:        initialChainPos.BlockHeight,
+			WaitingForNextHash: false,
+			ChainName:          c.chain.ChainName,
+		}, nil
+	}
+	return c.resolveStart()
+}
+
+// checkChain verifies the detected chain against the expected chain option,
+// the network reported by Core, and the chain of the saved position.
+func (c *ChainFollower) checkChain(chain *doge.ChainParams, genesisHash string, coreNetwork string, initialChainPos *state.ChainPos) *ChainMismatchError {
+	mismatch := func(expected string, reason string) *ChainMismatchError {
+		return &ChainMismatchError{
+			Expected:    expected,
+			Detected:    chain.ChainName,
+			GenesisHash: genesisHash,
+			CoreNetwork: coreNetwork,
+			Reason:      reason,
+		}
+	}
+	if c.opts.ExpectedChain != "" {
+		expected, _ := doge.ChainFromName(c.opts.ExpectedChain)
+		if expected != chain {
+			return mismatch(expected.ChainName, "expected chain option")
+		}
+	}
+	// Core reports "main", "test" or "regtest" (not reported by all transports)
+	if coreNetwork != "" && coreNetwork != chain.Network {
+		return mismatch(chain.ChainName, "genesis block does not match Core network")
+	}
+	if initialChainPos.ChainName != "" && initialChainPos.ChainName != chain.ChainName {
+		return mismatch(initialChainPos.ChainName, "saved position")
+	}
+	return nil
+}
+
+// verifyCheckpoint checks a block against the checkpoints (if there is one at that height).
+func (c *ChainFollower) verifyCheckpoint(height int64, hash string) *CheckpointMismatchError {
+	if expected, ok := c.checkpoints[height]; ok && !strings.EqualFold(expected, hash) {
+		return &CheckpointMismatchError{Chain: c.chainName(), Height: height, Expected: expected, Actual: hash}
+	}
+	return nil
+}
+
+// verifyCheckpointsBelow checks the checkpoints up to the Core node's tip
+// that the transport can serve (see rpc.RangeTransport), in height order.
+// Checkpoints that passed are not checked again.
+func (c *ChainFollower) verifyCheckpointsBelow(tip int64) error {
+	from, to := int64(0), tip
+	if ranged, ok := c.rpc.(rpc.RangeTransport); ok {
+		from, to = ranged.Range()
+		to = min(to, tip)
+	}
+	for _, height := range slices.Sorted(maps.Keys(c.checkpoints)) {
+		if height <= c.verifiedTo || height < from || height > to {
+			continue
+		}
+		hash, err := c.rpc.GetBlockHash(height)
+		if err != nil {
+			return err
+		}
+		if mismatch := c.verifyCheckpoint(height, hash); mismatch != nil {
+			return mismatch
+		}
+		c.verifiedTo = height
+	}
+	return nil
+}
+
+// verifyBlock checks PoW, AuxPoW and merkle root (if verification is enabled).
+func (c *ChainFollower) verifyBlock(block *types.Block) *BlockVerificationError {
+	if c.verifier == nil {
+		return nil
+	}
+	var raw []byte
+	if source, ok := c.rpc.(rpc.RawBlockTransport); ok {
+		rawHex, err := source.GetBlockHex(block.Hash)
+		if err == nil {
+			raw, err = doge.DecodeHex(rawHex)
+		}
+		if err != nil {
+			return &BlockVerificationError{Height: block.Height, Hash: block.Hash, Err: err}
 		}
 	}
+	if err := c.verifier.VerifyBlock(block, raw); err != nil {
+		return &BlockVerificationError{Height: block.Height, Hash: block.Hash, Err: err}
+	}
+	return nil
+}
+
+func (c *ChainFollower) alertCheckpoint(mismatch *CheckpointMismatchError) {
+	c.Logger.Error("ChainFollower: CHECKPOINT MISMATCH! The Core Node is following a divergent chain", "height", mismatch.Height, "expected", mismatch.Expected, "hash", mismatch.Actual)
+	c.send(messages.CheckpointAlertMessage{Err: mismatch})
+}
+
+func (c *ChainFollower) chainName() string {
+	if c.chain == nil {
+		return ""
+	}
+	return c.chain.ChainName
 }
 
 func (c *ChainFollower) sleepForRetry(delay time.Duration) {
 	if delay == 0 {
-		delay = RETRY_DELAY
+		delay = c.opts.Retry.RetryDelay
 	}
 	select {
 	case cmd := <-c.Commands:
-		log.Println("ChainFollower: received command")
+		c.Logger.Info("ChainFollower: received command")
 		switch cm := cmd.(type) {
 		case commands.StopChainFollowerCmd:
-			c.stopping = true
 			panic("stopped") // caught in `Run` method.
 		case commands.RestartChainFollowerCmd:
 			panic("stopped") // caught in `Run` method.
@@ -274,9 +565,21 @@ func (c *ChainFollower) sleepForRetry(delay time.Duration) {
 			c.SetSync = &cm
 			panic("restart") // caught in `Run` method.
 		default:
-			log.Println("ChainFollower: unknown command received (ignored)")
+			c.Logger.Warn("ChainFollower: unknown command received (ignored)")
 		}
 	case <-time.After(delay):
 		return
+	case <-c.context.Done():
+		return
+	}
+}
+
+// sleep waits for delay, returning false if the follower is stopped first.
+func (c *ChainFollower) sleep(delay time.Duration) bool {
+	select {
+	case <-time.After(delay):
+		return true
+	case <-c.context.Done():
+		return false
 	}
 }
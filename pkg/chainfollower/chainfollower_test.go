@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dogecoinfoundation/chainfollower/internal/doge"
 	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
 	"github.com/dogecoinfoundation/chainfollower/pkg/rpc"
 	"github.com/dogecoinfoundation/chainfollower/pkg/state"
@@ -33,10 +34,8 @@ func TestShutdown(t *testing.T) {
 	for {
 		select {
 		case <-timer.C:
-			if !follower.stopping {
-				follower.Stop()
-				return
-			}
+			follower.Stop()
+			return
 		case <-follower.Messages:
 			fmt.Println("Message received")
 		}
@@ -160,3 +159,164 @@ func TestRollbackMessage(t *testing.T) {
 		t.Errorf("Block message received is not correct")
 	}
 }
+
+func TestChainMismatchOnResume(t *testing.T) {
+	testTransport := rpc.NewTestRpcTransport()
+
+	testTransport.AddBlockAndHeader(&types.Block{
+		Hash:          "1a91e3dace36e2be3bf030a65679fe821aa1d6ef92e7c9902eb318182c355691",
+		Confirmations: 1,
+	}, &types.BlockHeader{
+		Hash:          "1a91e3dace36e2be3bf030a65679fe821aa1d6ef92e7c9902eb318182c355691",
+		Confirmations: 1,
+	})
+
+	follower := NewChainFollower(testTransport)
+	defer follower.Stop()
+
+	messageChan := follower.Start(&state.ChainPos{
+		BlockHash:   "1a91e3dace36e2be3bf030a65679fe821aa1d6ef92e7c9902eb318182c355691",
+		BlockHeight: 0,
+		ChainName:   "doge_test",
+	})
+
+	msg, ok := (<-messageChan).(messages.ChainMismatchMessage)
+	if !ok {
+		t.Fatalf("expected ChainMismatchMessage")
+	}
+
+	mismatch, ok := msg.Err.(*ChainMismatchError)
+	if !ok {
+		t.Fatalf("expected *ChainMismatchError, got %T", msg.Err)
+	}
+	if mismatch.Expected != "doge_test" || mismatch.Detected != "doge_main" {
+		t.Errorf("wrong mismatch details: %v", mismatch)
+	}
+}
+
+func TestExpectedChainMismatch(t *testing.T) {
+	testTransport := rpc.NewTestRpcTransport()
+	testTransport.SetBlockchainInfo(&types.BlockchainInfo{Chain: "main"})
+
+	testTransport.AddBlockAndHeader(&types.Block{
+		Hash:          "1a91e3dace36e2be3bf030a65679fe821aa1d6ef92e7c9902eb318182c355691",
+		Confirmations: 1,
+	}, &types.BlockHeader{
+		Hash:          "1a91e3dace36e2be3bf030a65679fe821aa1d6ef92e7c9902eb318182c355691",
+		Confirmations: 1,
+	})
+
+	follower := NewChainFollower(testTransport, WithExpectedChain("test"))
+	defer follower.Stop()
+
+	messageChan := follower.Start(&state.ChainPos{})
+
+	msg, ok := (<-messageChan).(messages.ChainMismatchMessage)
+	if !ok {
+		t.Fatalf("expected ChainMismatchMessage")
+	}
+	if mismatch := msg.Err.(*ChainMismatchError); mismatch.Expected != "doge_test" || mismatch.CoreNetwork != "main" {
+		t.Errorf("wrong mismatch details: %v", mismatch)
+	}
+}
+
+func TestCheckpointAlert(t *testing.T) {
+	testTransport := rpc.NewTestRpcTransport()
+
+	testTransport.AddBlockAndHeader(&types.Block{
+		Hash: "1a91e3dace36e2be3bf030a65679fe821aa1d6ef92e7c9902eb318182c355691",
+	}, &types.BlockHeader{
+		Hash:          "1a91e3dace36e2be3bf030a65679fe821aa1d6ef92e7c9902eb318182c355691",
+		NextBlockHash: "0000000000000000000000000000000000000000000000000000000000000000",
+		Confirmations: 2,
+	})
+
+	testTransport.AddBlockAndHeader(&types.Block{
+		Hash:   "0000000000000000000000000000000000000000000000000000000000000000",
+		Height: 1,
+	}, &types.BlockHeader{
+		Hash:          "0000000000000000000000000000000000000000000000000000000000000000",
+		Height:        1,
+		Confirmations: 1,
+	})
+
+	follower := NewChainFollower(testTransport, WithCheckpoints(map[int64]string{
+		1: "1111111111111111111111111111111111111111111111111111111111111111",
+	}))
+	defer follower.Stop()
+
+	messageChan := follower.Start(&state.ChainPos{
+		BlockHash:   "1a91e3dace36e2be3bf030a65679fe821aa1d6ef92e7c9902eb318182c355691",
+		BlockHeight: 0,
+	})
+
+	if _, ok := (<-messageChan).(messages.BlockMessage); !ok {
+		t.Fatalf("expected BlockMessage for the genesis block")
+	}
+
+	alert, ok := (<-messageChan).(messages.CheckpointAlertMessage)
+	if !ok {
+		t.Fatalf("expected CheckpointAlertMessage instead of the divergent block")
+	}
+	if mismatch := alert.Err.(*CheckpointMismatchError); mismatch.Height != 1 || mismatch.Actual != "0000000000000000000000000000000000000000000000000000000000000000" {
+		t.Errorf("wrong checkpoint mismatch details: %v", mismatch)
+	}
+}
+
+// hashCounter records the heights requested with GetBlockHash.
+type hashCounter struct {
+	*rpc.TestRpcTransport
+	heights []int64
+}
+
+func (h *hashCounter) GetBlockHash(height int64) (string, error) {
+	h.heights = append(h.heights, height)
+	return h.TestRpcTransport.GetBlockHash(height)
+}
+
+func TestCheckpointsVerifiedOnce(t *testing.T) {
+	transport := &hashCounter{TestRpcTransport: rpc.NewTestChain(10)}
+	follower := NewChainFollower(transport, WithCheckpoints(map[int64]string{
+		7: rpc.TestBlockHash(7), 2: rpc.TestBlockHash(2), 5: rpc.TestBlockHash(5),
+	}))
+	for range 2 {
+		if _, err := follower.identifyChain(&state.ChainPos{}, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the genesis lookup, the checkpoints in height order (genesis is one),
+	// then only the genesis lookup again.
+	if fmt.Sprint(transport.heights) != "[0 0 2 5 7 0]" {
+		t.Errorf("GetBlockHash heights: %v", transport.heights)
+	}
+}
+
+func TestCustomChain(t *testing.T) {
+	const genesis = "6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b6b"
+	if _, err := doge.RegisterChainParams(doge.ChainDefinition{ChainName: "doge_follower_test", GenesisBlock: genesis}); err != nil {
+		t.Fatal(err)
+	}
+
+	testTransport := rpc.NewTestRpcTransport()
+	testTransport.SetBlockchainInfo(&types.BlockchainInfo{Chain: "regtest"})
+	testTransport.AddBlockAndHeader(&types.Block{
+		Hash:          genesis,
+		Confirmations: 1,
+	}, &types.BlockHeader{
+		Hash:          genesis,
+		Confirmations: 1,
+	})
+
+	follower := NewChainFollower(testTransport, WithExpectedChain("doge_follower_test"))
+	defer follower.Stop()
+
+	messageChan := follower.Start(&state.ChainPos{BlockHash: genesis, BlockHeight: 0})
+
+	msg, ok := (<-messageChan).(messages.BlockMessage)
+	if !ok {
+		t.Fatalf("expected BlockMessage for the custom genesis block")
+	}
+	if msg.ChainPos.ChainName != "doge_follower_test" {
+		t.Errorf("expected ChainPos on doge_follower_test, got %q", msg.ChainPos.ChainName)
+	}
+}
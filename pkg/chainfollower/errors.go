@@ -0,0 +1,47 @@
+package chainfollower
+
+import "fmt"
+
+// ChainMismatchError reports that the Core node is on the wrong chain:
+// not the configured expected chain, or not the chain of the saved position.
+type ChainMismatchError struct {
+	Expected    string // expected ChainName
+	Detected    string // ChainName detected from the genesis block
+	GenesisHash string // block #0 on the Core node
+	CoreNetwork string // network name reported by getblockchaininfo
+	Reason      string // what the expectation was based on
+}
+
+func (e *ChainMismatchError) Error() string {
+	return fmt.Sprintf("chain mismatch (%s): expected %v but Core node is on %v (network %q, genesis %v)",
+		e.Reason, e.Expected, e.Detected, e.CoreNetwork, e.GenesisHash)
+}
+
+// CheckpointMismatchError reports that the Core node has a different block
+// at a checkpoint height: it is serving a divergent (fake or forked) history.
+type CheckpointMismatchError struct {
+	Chain    string // ChainName
+	Height   int64
+	Expected string // checkpoint hash
+	Actual   string // hash reported by the Core node
+}
+
+func (e *CheckpointMismatchError) Error() string {
+	return fmt.Sprintf("checkpoint mismatch on %v at height %d: expected %v but Core node has %v", e.Chain, e.Height, e.Expected, e.Actual)
+}
+
+// BlockVerificationError reports a block that failed verification
+// (scrypt proof-of-work, AuxPoW or merkle root).
+type BlockVerificationError struct {
+	Height int64
+	Hash   string
+	Err    error
+}
+
+func (e *BlockVerificationError) Error() string {
+	return fmt.Sprintf("block %v at height %d failed verification: %v", e.Hash, e.Height, e.Err)
+}
+
+func (e *BlockVerificationError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,394 @@
+package chainfollower
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/internal/doge"
+	"github.com/dogecoinfoundation/chainfollower/pkg/config"
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/metrics"
+	"github.com/dogecoinfoundation/chainfollower/pkg/state"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+)
+
+const DEFAULT_START_BELOW_TIP = 100 // blocks below tip to start at, without a saved position.
+const DEFAULT_POLL_INTERVAL = 1 * time.Second
+const DEFAULT_MAX_START_ATTEMPTS = 5
+const DEFAULT_WATCHDOG_INTERVAL = 30 * time.Second
+const DEFAULT_SHUTDOWN_TIMEOUT = 30 * time.Second
+
+// RetryPolicy controls how the follower waits and retries.
+type RetryPolicy struct {
+	MaxStartAttempts int           // attempts to find the starting position (exponential backoff)
+	BaseDelay        time.Duration // first backoff delay between start attempts
+	RetryDelay       time.Duration // delay after RPC errors
+	WrongChainDelay  time.Duration // delay before re-checking an unrecognised chain
+	IBDDelay         time.Duration // delay while Core is in initial block download
+}
+
+// WatchdogPolicy configures stall and lag detection (see WithWatchdog).
+// A zero threshold disables that check.
+type WatchdogPolicy struct {
+	Interval     time.Duration // how often to check Core (default 30s)
+	MaxLag       int64         // alert when more than this many blocks behind Core's header count
+	StallTimeout time.Duration // alert when no block is delivered for this long while Core has newer ones, or Core is unreachable
+	MaxTipAge    time.Duration // alert when Core's tip block is older than this (Core or the network is stuck)
+}
+
+// PositionSaver persists the position to resume from, e.g. with
+// store.SaveChainPos.
+type PositionSaver func(pos *state.ChainPos) error
+
+// AlertHook is called with each StallMessage and LagMessage, from the
+// watchdog goroutine; it must not block.
+type AlertHook func(msg messages.Message)
+
+// Options configure a ChainFollower; see the With* functions.
+type Options struct {
+	StartHeight   *int64    // start height without a saved position (nil = unset)
+	StartHash     string    // start hash without a saved position ("" = unset)
+	StartTime     time.Time // start at the first block at or after this time (zero = unset)
+	StartBelowTip int64     // otherwise start this many blocks below the tip
+	PollInterval  time.Duration
+	Retry         RetryPolicy
+	ExpectedChain string           // ChainName or Core network name ("" = accept any Dogecoin chain)
+	ChannelSize   int              // Messages channel buffer size
+	Confirmations int64            // only deliver blocks with at least this many confirmations
+	Checkpoints   map[int64]string // extra checkpoints (in addition to the chain's built-in ones)
+	VerifyBlocks  bool             // verify scrypt PoW, AuxPoW and merkle roots before delivering blocks
+	DataCarrier   bool             // send DataCarrierMessage for OP_RETURN outputs
+	DataPrefixes  [][]byte         // only for payloads with one of these prefixes (nil = all)
+	BlockFilter   BlockFilter      // filters the block in each BlockMessage (nil = whole blocks)
+	Watchdog      WatchdogPolicy   // stall and lag alerts (disabled unless a threshold is set)
+	AlertHooks    []AlertHook      // called with each watchdog alert, in addition to Messages
+	RequireAcks   bool             // Shutdown waits for the consumer to Ack each message
+	SavePosition  PositionSaver    // called by Shutdown with the final position (nil = not saved)
+	HandleSignals bool             // Shutdown on SIGINT or SIGTERM
+	SignalTimeout time.Duration    // how long a signal's Shutdown waits for the consumer
+	Logger        *slog.Logger
+	Metrics       *metrics.Metrics
+}
+
+type Option func(o *Options)
+
+// BlockFilter selects the transactions delivered in each BlockMessage,
+// e.g. a watchlist.Watchlist. It must not modify the block it is given.
+type BlockFilter interface {
+	FilterBlock(block *types.Block) *types.Block
+}
+
+func defaultOptions() Options {
+	return Options{
+		StartBelowTip: DEFAULT_START_BELOW_TIP,
+		PollInterval:  DEFAULT_POLL_INTERVAL,
+		Retry: RetryPolicy{
+			MaxStartAttempts: DEFAULT_MAX_START_ATTEMPTS,
+			BaseDelay:        time.Second,
+			RetryDelay:       RETRY_DELAY,
+			WrongChainDelay:  WRONG_CHAIN_DELAY,
+			IBDDelay:         WAIT_INITIAL_BLOCK,
+		},
+		ChannelSize: 0,
+		Logger:      slog.New(slog.DiscardHandler),
+	}
+}
+
+// Start at this block height when there is no saved position.
+func WithStartHeight(height int64) Option {
+	return func(o *Options) { o.StartHeight = &height }
+}
+
+// Start at this block hash when there is no saved position.
+func WithStartHash(hash string) Option {
+	return func(o *Options) { o.StartHash = hash }
+}
+
+// Start at the genesis block when there is no saved position.
+func WithStartAtGenesis() Option {
+	return WithStartHeight(0)
+}
+
+// Start at the first block timestamped at or after t when there is no saved
+// position (found by binary search over block headers).
+func WithStartTime(t time.Time) Option {
+	return func(o *Options) { o.StartTime = t }
+}
+
+// Start this many blocks below the tip when there is no saved position (default 100).
+func WithStartBelowTip(blocks int64) Option {
+	return func(o *Options) { o.StartBelowTip = blocks }
+}
+
+// How often to poll for a new block once the follower reaches the tip.
+func WithPollInterval(interval time.Duration) Option {
+	return func(o *Options) { o.PollInterval = interval }
+}
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *Options) { o.Retry = policy }
+}
+
+// Only follow the named chain: main, test, regtest (or a ChainName).
+func WithExpectedChain(name string) Option {
+	return func(o *Options) { o.ExpectedChain = name }
+}
+
+func WithChannelSize(size int) Option {
+	return func(o *Options) { o.ChannelSize = size }
+}
+
+// Only deliver blocks once they have at least this many confirmations
+// (1 = the tip block itself is delivered, which is the default behaviour).
+func WithConfirmations(depth int64) Option {
+	return func(o *Options) { o.Confirmations = depth }
+}
+
+// Verify these block hashes (by height) in addition to the chain's built-in checkpoints.
+func WithCheckpoints(checkpoints map[int64]string) Option {
+	return func(o *Options) {
+		if o.Checkpoints == nil {
+			o.Checkpoints = map[int64]string{}
+		}
+		for height, hash := range checkpoints {
+			o.Checkpoints[height] = hash
+		}
+	}
+}
+
+// Verify each block's scrypt proof-of-work, AuxPoW (merged mining) proof and
+// merkle root, refusing blocks that fail. AuxPoW verification requires a
+// transport that implements rpc.RawBlockTransport.
+func WithBlockVerification(enabled bool) Option {
+	return func(o *Options) { o.VerifyBlocks = enabled }
+}
+
+// Send a DataCarrierMessage for each OP_RETURN output after its BlockMessage.
+// If prefixes are given, only payloads starting with one of them are sent
+// (e.g. a protocol magic such as []byte("DOGE")).
+func WithDataCarrier(prefixes ...[]byte) Option {
+	return func(o *Options) {
+		o.DataCarrier = true
+		o.DataPrefixes = append(o.DataPrefixes, prefixes...)
+	}
+}
+
+// Deliver each BlockMessage with the block returned by the filter (for
+// example only the transactions touching a watch-only wallet). Blocks are
+// still delivered when no transactions match, so ChainPos keeps advancing.
+func WithBlockFilter(filter BlockFilter) Option {
+	return func(o *Options) { o.BlockFilter = filter }
+}
+
+// Watch for stalls and lag, sending a StallMessage or LagMessage when a
+// threshold is crossed and again when it recovers.
+func WithWatchdog(policy WatchdogPolicy) Option {
+	return func(o *Options) {
+		if policy.Interval == 0 {
+			policy.Interval = DEFAULT_WATCHDOG_INTERVAL
+		}
+		o.Watchdog = policy
+	}
+}
+
+// Call hook with each watchdog alert, e.g. to page on-call.
+func WithAlertHook(hook AlertHook) Option {
+	return func(o *Options) { o.AlertHooks = append(o.AlertHooks, hook) }
+}
+
+// Make Shutdown wait for the consumer to Ack each message it has received,
+// and save the position of the last one acknowledged.
+func WithAcks() Option {
+	return func(o *Options) { o.RequireAcks = true }
+}
+
+// Save the final position on Shutdown.
+func WithPositionSaver(save PositionSaver) Option {
+	return func(o *Options) { o.SavePosition = save }
+}
+
+// Shut down gracefully on SIGINT or SIGTERM, waiting up to timeout for the
+// consumer (0 = DEFAULT_SHUTDOWN_TIMEOUT); a second signal stops at once.
+// Signal handling is process-wide, so it is left to the application unless
+// this is set.
+func WithSignalHandling(timeout time.Duration) Option {
+	return func(o *Options) {
+		if timeout == 0 {
+			timeout = DEFAULT_SHUTDOWN_TIMEOUT
+		}
+		o.HandleSignals = true
+		o.SignalTimeout = timeout
+	}
+}
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *Options) { o.Logger = logger }
+}
+
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(o *Options) { o.Metrics = m }
+}
+
+// Validate checks the options for consistency.
+func (o *Options) Validate() error {
+	var errs []error
+	if o.StartHeight != nil && *o.StartHeight < 0 {
+		errs = append(errs, fmt.Errorf("start height must not be negative: %d", *o.StartHeight))
+	}
+	starts := 0
+	for _, set := range []bool{o.StartHeight != nil, o.StartHash != "", !o.StartTime.IsZero()} {
+		if set {
+			starts++
+		}
+	}
+	if starts > 1 {
+		errs = append(errs, errors.New("start height, start hash and start time are mutually exclusive"))
+	}
+	if o.StartHash != "" && !isHash(o.StartHash) {
+		errs = append(errs, fmt.Errorf("start hash must be 64 hex characters: %q", o.StartHash))
+	}
+	if o.StartBelowTip < 0 {
+		errs = append(errs, fmt.Errorf("start below tip must not be negative: %d", o.StartBelowTip))
+	}
+	if o.PollInterval <= 0 {
+		errs = append(errs, fmt.Errorf("poll interval must be positive: %v", o.PollInterval))
+	}
+	if o.Retry.MaxStartAttempts < 1 {
+		errs = append(errs, fmt.Errorf("max start attempts must be at least 1: %d", o.Retry.MaxStartAttempts))
+	}
+	if o.Retry.BaseDelay <= 0 || o.Retry.RetryDelay <= 0 || o.Retry.WrongChainDelay <= 0 || o.Retry.IBDDelay <= 0 {
+		errs = append(errs, errors.New("retry delays must be positive"))
+	}
+	if o.ExpectedChain != "" {
+		if _, err := doge.ChainFromName(o.ExpectedChain); err != nil {
+			errs = append(errs, fmt.Errorf("expected chain must be main, test, regtest or a registered chain name: %q", o.ExpectedChain))
+		}
+	}
+	if o.ChannelSize < 0 {
+		errs = append(errs, fmt.Errorf("channel size must not be negative: %d", o.ChannelSize))
+	}
+	if o.Confirmations < 0 {
+		errs = append(errs, fmt.Errorf("confirmations must not be negative: %d", o.Confirmations))
+	}
+	for height, hash := range o.Checkpoints {
+		if height < 0 || !isHash(hash) {
+			errs = append(errs, fmt.Errorf("invalid checkpoint: %d = %q", height, hash))
+		}
+	}
+	if o.Watchdog.Interval < 0 || o.Watchdog.MaxLag < 0 || o.Watchdog.StallTimeout < 0 || o.Watchdog.MaxTipAge < 0 {
+		errs = append(errs, errors.New("watchdog interval and thresholds must not be negative"))
+	}
+	if o.Watchdog.enabled() && o.Watchdog.Interval == 0 {
+		errs = append(errs, errors.New("watchdog interval must be positive"))
+	}
+	if o.HandleSignals && o.SignalTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("shutdown timeout must be positive: %v", o.SignalTimeout))
+	}
+	if o.Logger == nil {
+		errs = append(errs, errors.New("logger must not be nil"))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("chainfollower: invalid options: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// ValidateOptions applies the options to the defaults and validates the result.
+// NewChainFollower panics on invalid options, so check user-supplied values first.
+func ValidateOptions(opts ...Option) error {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o.Validate()
+}
+
+// OptionsFromConfig converts the [follower] config section into options,
+// validating the result. Unset fields keep their defaults.
+func OptionsFromConfig(cfg config.FollowerConfig) ([]Option, error) {
+	var opts []Option
+	if cfg.StartHeight != nil {
+		opts = append(opts, WithStartHeight(*cfg.StartHeight))
+	}
+	if cfg.StartHash != "" {
+		opts = append(opts, WithStartHash(cfg.StartHash))
+	}
+	if !cfg.StartTime.IsZero() {
+		opts = append(opts, WithStartTime(cfg.StartTime))
+	}
+	if cfg.StartBelowTip != nil {
+		opts = append(opts, WithStartBelowTip(*cfg.StartBelowTip))
+	}
+	if cfg.PollInterval != 0 {
+		opts = append(opts, WithPollInterval(cfg.PollInterval))
+	}
+	if cfg.RetryDelay != 0 || cfg.MaxStartAttempts != 0 || cfg.WrongChainDelay != 0 || cfg.IBDDelay != 0 {
+		opts = append(opts, func(o *Options) {
+			if cfg.RetryDelay != 0 {
+				o.Retry.RetryDelay = cfg.RetryDelay
+			}
+			if cfg.MaxStartAttempts != 0 {
+				o.Retry.MaxStartAttempts = cfg.MaxStartAttempts
+			}
+			if cfg.WrongChainDelay != 0 {
+				o.Retry.WrongChainDelay = cfg.WrongChainDelay
+			}
+			if cfg.IBDDelay != 0 {
+				o.Retry.IBDDelay = cfg.IBDDelay
+			}
+		})
+	}
+	if cfg.ExpectedChain != "" {
+		opts = append(opts, WithExpectedChain(cfg.ExpectedChain))
+	}
+	if cfg.ChannelSize != nil {
+		opts = append(opts, WithChannelSize(*cfg.ChannelSize))
+	}
+	if cfg.Confirmations != nil {
+		opts = append(opts, WithConfirmations(*cfg.Confirmations))
+	}
+	if cfg.VerifyBlocks {
+		opts = append(opts, WithBlockVerification(true))
+	}
+	if cfg.DataCarrier || len(cfg.DataPrefixes) > 0 {
+		prefixes := make([][]byte, 0, len(cfg.DataPrefixes))
+		for _, prefix := range cfg.DataPrefixes {
+			prefixes = append(prefixes, []byte(prefix))
+		}
+		opts = append(opts, WithDataCarrier(prefixes...))
+	}
+	if w := cfg.Watchdog; w.MaxLag != 0 || w.StallTimeout != 0 || w.MaxTipAge != 0 {
+		opts = append(opts, WithWatchdog(WatchdogPolicy(w)))
+	}
+	if len(cfg.Checkpoints) > 0 {
+		checkpoints := make(map[int64]string, len(cfg.Checkpoints))
+		for key, hash := range cfg.Checkpoints {
+			height, err := strconv.ParseInt(key, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("config [follower.checkpoints]: height must be a number: %q", key)
+			}
+			checkpoints[height] = hash
+		}
+		opts = append(opts, WithCheckpoints(checkpoints))
+	}
+
+	if err := ValidateOptions(opts...); err != nil {
+		return nil, fmt.Errorf("config [follower]: %w", err)
+	}
+	return opts, nil
+}
+
+func isHash(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, ch := range s {
+		if !(ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'f' || ch >= 'A' && ch <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package chainfollower
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/dogecoinfoundation/chainfollower/pkg/config"
+)
+
+func TestOptionsFromConfig(t *testing.T) {
+	var cfg config.Config
+	_, err := toml.Decode(`
+[follower]
+start_height = 0
+poll_interval = "250ms"
+expected_chain = "regtest"
+confirmations = 6
+
+[follower.watchdog]
+max_lag = 10
+stall_timeout = "10m"
+`, &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := OptionsFromConfig(cfg.Follower)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	follower := NewChainFollower(nil, opts...)
+	if follower.opts.StartHeight == nil || *follower.opts.StartHeight != 0 || follower.opts.PollInterval != 250*time.Millisecond ||
+		follower.opts.ExpectedChain != "regtest" || follower.opts.Confirmations != 6 {
+		t.Errorf("options not applied: %+v", follower.opts)
+	}
+	if w := follower.opts.Watchdog; w.MaxLag != 10 || w.StallTimeout != 10*time.Minute || w.Interval != DEFAULT_WATCHDOG_INTERVAL {
+		t.Errorf("watchdog not applied: %+v", w)
+	}
+	if follower.opts.StartBelowTip != DEFAULT_START_BELOW_TIP {
+		t.Errorf("unset option should keep its default")
+	}
+}
+
+func TestOptionsFromConfigInvalid(t *testing.T) {
+	height := int64(10)
+	_, err := OptionsFromConfig(config.FollowerConfig{
+		StartHeight:   &height,
+		StartHash:     "1a91e3dace36e2be3bf030a65679fe821aa1d6ef92e7c9902eb318182c355691",
+		ExpectedChain: "btc",
+		PollInterval:  -time.Second,
+	})
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, msg := range []string{"mutually exclusive", "expected chain", "poll interval"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Errorf("error should mention %q: %v", msg, err)
+		}
+	}
+}
+
+func TestNegativeStartHeight(t *testing.T) {
+	height := int64(-1)
+	if _, err := OptionsFromConfig(config.FollowerConfig{StartHeight: &height}); err == nil || !strings.Contains(err.Error(), "start height") {
+		t.Errorf("expected start_height = -1 to be rejected, got %v", err)
+	}
+	if err := ValidateOptions(WithStartHeight(-1)); err == nil {
+		t.Error("expected WithStartHeight(-1) to be rejected")
+	}
+	if err := ValidateOptions(WithStartAtGenesis()); err != nil {
+		t.Errorf("start at genesis: %v", err)
+	}
+}
+
+func TestNewInvalidOptions(t *testing.T) {
+	if _, err := New(nil, WithConfirmations(-1)); err == nil || !strings.Contains(err.Error(), "confirmations") {
+		t.Errorf("expected a confirmations error, got %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("NewChainFollower should panic on invalid options")
+		}
+	}()
+	NewChainFollower(nil, WithConfirmations(-1))
+}
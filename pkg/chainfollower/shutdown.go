@@ -0,0 +1,151 @@
+package chainfollower
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/state"
+)
+
+const drainPollInterval = 10 * time.Millisecond
+
+var ErrNotStarted = errors.New("chainfollower: not started with Start")
+
+// ackState counts the messages sent and acknowledged, with the position of
+// the last of each (see Ack).
+type ackState struct {
+	mu       sync.Mutex
+	sent     int64
+	acked    int64
+	sentPos  *state.ChainPos
+	ackedPos *state.ChainPos
+}
+
+func (a *ackState) record(msg messages.Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sent++
+	if pos := messages.Position(msg); pos != nil {
+		a.sentPos = pos
+	}
+}
+
+// Ack tells the follower that the consumer has processed msg, so that
+// Shutdown (with WithAcks) waits for it and saves its position. Messages must
+// be acknowledged in the order they were received.
+func (c *ChainFollower) Ack(msg messages.Message) {
+	c.acks.mu.Lock()
+	defer c.acks.mu.Unlock()
+	c.acks.acked++
+	if pos := messages.Position(msg); pos != nil {
+		c.acks.ackedPos = pos
+	}
+}
+
+// Shutdown stops fetching blocks, waits for the consumer to receive the
+// messages already fetched (and to Ack them, with WithAcks), saves the final
+// position (WithPositionSaver) and closes Messages. If ctx ends first, the
+// follower is stopped as with Stop, the last acknowledged position is saved
+// (with WithAcks) and the context's error is returned; Messages is closed once
+// the main loop has exited. Stop a Backfill with Stop instead.
+func (c *ChainFollower) Shutdown(ctx context.Context) error {
+	if !c.started {
+		return ErrNotStarted
+	}
+	c.shutdownOnce.Do(func() {
+		c.Logger.Info("ChainFollower: shutting down")
+		c.cancel()
+		go func() {
+			c.running.Wait()
+			close(c.Messages)
+			close(c.closed)
+		}()
+	})
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for !c.drained() {
+		select {
+		case <-ctx.Done():
+			c.Stop()
+			err := fmt.Errorf("chainfollower: shutdown: %w", ctx.Err())
+			if c.opts.RequireAcks {
+				err = errors.Join(err, c.savePosition(c.acks.position(true)))
+			}
+			return err
+		case <-ticker.C:
+		}
+	}
+	return c.savePosition(c.acks.position(c.opts.RequireAcks))
+}
+
+// drained reports whether the main loop has exited and the consumer has
+// received (and acknowledged, with WithAcks) every message.
+func (c *ChainFollower) drained() bool {
+	select {
+	case <-c.closed:
+	default:
+		return false
+	}
+	if len(c.Messages) > 0 {
+		return false
+	}
+	if !c.opts.RequireAcks {
+		return true
+	}
+	c.acks.mu.Lock()
+	defer c.acks.mu.Unlock()
+	return c.acks.acked >= c.acks.sent
+}
+
+// position returns the position of the last message acknowledged, or sent.
+func (a *ackState) position(acked bool) *state.ChainPos {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if acked {
+		return a.ackedPos
+	}
+	return a.sentPos
+}
+
+func (c *ChainFollower) savePosition(pos *state.ChainPos) error {
+	if c.opts.SavePosition == nil || pos == nil {
+		return nil
+	}
+	c.Logger.Info("ChainFollower: saving final position", "height", pos.BlockHeight, "hash", pos.BlockHash)
+	if err := c.opts.SavePosition(pos); err != nil {
+		return fmt.Errorf("chainfollower: saving final position: %w", err)
+	}
+	return nil
+}
+
+// handleSignals shuts down on SIGINT or SIGTERM (see WithSignalHandling).
+// A second signal stops at once.
+func (c *ChainFollower) handleSignals() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		sig := <-sigCh
+		c.Logger.Info("ChainFollower: caught signal, shutting down", "signal", sig.String())
+		ctx, cancel := context.WithTimeout(context.Background(), c.opts.SignalTimeout)
+		defer cancel()
+		go func() {
+			select {
+			case <-sigCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		if err := c.Shutdown(ctx); err != nil {
+			c.Logger.Error("ChainFollower: shutdown incomplete", "error", err)
+		}
+		signal.Stop(sigCh)
+	}()
+}
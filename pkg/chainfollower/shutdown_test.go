@@ -0,0 +1,76 @@
+package chainfollower
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/rpc"
+	"github.com/dogecoinfoundation/chainfollower/pkg/state"
+)
+
+func TestGracefulShutdown(t *testing.T) {
+	var saved *state.ChainPos
+	follower := NewChainFollower(rpc.NewTestChain(10), WithStartHeight(1), WithAcks(),
+		WithPositionSaver(func(pos *state.ChainPos) error { saved = pos; return nil }))
+	msgs := follower.Start(&state.ChainPos{})
+
+	first := <-msgs
+	follower.Ack(first)
+	time.Sleep(20 * time.Millisecond) // the follower is now blocked sending block 2.
+
+	done := make(chan error, 1)
+	go func() { done <- follower.Shutdown(context.Background()) }()
+
+	var heights []int64
+	for msg := range msgs {
+		heights = append(heights, msg.(messages.BlockMessage).Block.Height)
+		select {
+		case err := <-done:
+			t.Fatalf("shutdown returned before the last message was acknowledged: %v", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+		follower.Ack(msg)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	// block 2 was in flight; the follower may have fetched one more before
+	// seeing the shutdown.
+	if len(heights) == 0 || len(heights) > 2 || heights[0] != 2 {
+		t.Fatalf("expected the in-flight block 2, got %v", heights)
+	}
+	last := heights[len(heights)-1]
+	if saved == nil || saved.BlockHeight != last || saved.BlockHash != rpc.TestBlockHash(last) {
+		t.Errorf("saved position: %+v", saved)
+	}
+	if follower.Status().Running {
+		t.Error("follower still running")
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	var saved *state.ChainPos
+	follower := NewChainFollower(rpc.NewTestChain(10), WithStartHeight(1), WithAcks(),
+		WithPositionSaver(func(pos *state.ChainPos) error { saved = pos; return nil }))
+	if err := follower.Shutdown(context.Background()); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("expected ErrNotStarted, got %v", err)
+	}
+	msgs := follower.Start(&state.ChainPos{})
+	follower.Ack(<-msgs)
+	<-msgs // received but never acknowledged.
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := follower.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+	if saved == nil || saved.BlockHeight != 1 {
+		t.Errorf("expected the acknowledged position, got %+v", saved)
+	}
+	for range msgs {
+		// closed once the main loop exits.
+	}
+}
@@ -0,0 +1,117 @@
+package chainfollower
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/state"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+)
+
+// Starting positions without a saved ChainPos: a block hash, a height
+// (0 = genesis), a time, or (by default) a number of blocks below the tip.
+
+const medianTimeSpan = 11 // blocks in Core's median time past.
+
+// ResolveStart identifies the Core node's chain and returns the position the
+// follower would start at without a saved position, according to the start
+// options. Pass it to Start to begin following there.
+func (c *ChainFollower) ResolveStart() (*state.ChainPos, error) {
+	if _, err := c.identifyChain(&state.ChainPos{}, false); err != nil {
+		return nil, err
+	}
+	return c.resolveStart()
+}
+
+func (c *ChainFollower) resolveStart() (*state.ChainPos, error) {
+	pos := &state.ChainPos{ChainName: c.chainName()}
+	switch {
+	case c.opts.StartHash != "":
+		header, err := c.rpc.GetBlockHeader(c.opts.StartHash)
+		if err != nil {
+			return nil, err
+		}
+		pos.BlockHash, pos.BlockHeight = header.Hash, header.Height
+
+	case !c.opts.StartTime.IsZero():
+		height, afterTip, err := c.findHeightByTime(c.opts.StartTime)
+		if err != nil {
+			return nil, err
+		}
+		pos.BlockHeight = height
+		pos.WaitingForNextHash = afterTip // start with the next block.
+
+	case c.opts.StartHeight != nil:
+		pos.BlockHeight = *c.opts.StartHeight
+
+	default:
+		tip, err := c.rpc.GetBlockCount()
+		if err != nil {
+			return nil, err
+		}
+		pos.BlockHeight = max(tip-c.opts.StartBelowTip, 0)
+	}
+
+	if pos.BlockHash == "" {
+		hash, err := c.rpc.GetBlockHash(pos.BlockHeight)
+		if err != nil {
+			return nil, err
+		}
+		pos.BlockHash = hash
+	}
+	c.Logger.Info("ChainFollower: START SYNC", "height", pos.BlockHeight, "hash", pos.BlockHash)
+	return pos, nil
+}
+
+// findHeightByTime returns the first block timestamped at or after t.
+// Block times are not monotonic, so it binary searches for the first block
+// whose median time past is at or after t (every later block has a later
+// Time), then starts at the earliest of the blocks in that median time
+// window with Time >= t. If no block is that recent it returns the tip with
+// afterTip set.
+func (c *ChainFollower) findHeightByTime(t time.Time) (int64, bool, error) {
+	tip, err := c.rpc.GetBlockCount()
+	if err != nil {
+		return 0, false, err
+	}
+	target := t.Unix()
+
+	var searchErr error
+	height := int64(sort.Search(int(tip+1), func(h int) bool {
+		if searchErr != nil {
+			return true
+		}
+		header, err := c.headerAtHeight(int64(h))
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return int64(header.MedianTime) >= target
+	}))
+	if searchErr != nil {
+		return 0, false, searchErr
+	}
+
+	first := height
+	for h := min(height, tip); h >= 0 && h > height-medianTimeSpan; h-- {
+		header, err := c.headerAtHeight(h)
+		if err != nil {
+			return 0, false, err
+		}
+		if int64(header.Time) >= target {
+			first = h
+		}
+	}
+	if first > tip {
+		return tip, true, nil
+	}
+	return first, false, nil
+}
+
+func (c *ChainFollower) headerAtHeight(height int64) (*types.BlockHeader, error) {
+	hash, err := c.rpc.GetBlockHash(height)
+	if err != nil {
+		return nil, err
+	}
+	return c.rpc.GetBlockHeader(hash)
+}
@@ -0,0 +1,96 @@
+package chainfollower
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/rpc"
+)
+
+const testEpoch = 1_700_000_000
+
+// timedChain returns rpc.NewTestChain(n) with block times a minute apart, except
+// that block 10 is timestamped before block 9, and median times computed
+// over the previous 11 blocks as Core does.
+func timedChain(n int) *rpc.TestRpcTransport {
+	tr := rpc.NewTestChain(int64(n))
+	tr.SetBlockCount(int64(n - 1))
+	times := make([]int, n)
+	for h := 0; h < n; h++ {
+		hash, _ := tr.GetBlockHash(int64(h))
+		header, _ := tr.GetBlockHeader(hash)
+		header.Time = testEpoch + h*60
+		if h == 10 {
+			header.Time -= 90
+		}
+		times[h] = header.Time
+		window := append([]int(nil), times[max(0, h-medianTimeSpan+1):h+1]...)
+		sort.Ints(window)
+		header.MedianTime = window[len(window)/2]
+	}
+	return tr
+}
+
+func TestStartTime(t *testing.T) {
+	tests := []struct {
+		time     int64
+		height   int64
+		afterTip bool
+	}{
+		{testEpoch - 1000, 0, false},
+		{testEpoch, 0, false},
+		{testEpoch + 30*60, 30, false},
+		{testEpoch + 30*60 - 1, 30, false},
+		{testEpoch + 9*60 - 30, 9, false}, // block 10 is earlier than block 9
+		{testEpoch + 49*60, 49, false},
+		{testEpoch + 49*60 + 1, 49, true},
+	}
+	for _, test := range tests {
+		follower := NewChainFollower(timedChain(50), WithStartTime(time.Unix(test.time, 0)))
+		pos, err := follower.ResolveStart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos.BlockHeight != test.height || pos.WaitingForNextHash != test.afterTip {
+			t.Errorf("time %d: got height %d waiting %v, expected %d %v", test.time, pos.BlockHeight, pos.WaitingForNextHash, test.height, test.afterTip)
+		}
+		hash, _ := follower.rpc.GetBlockHash(pos.BlockHeight)
+		if pos.BlockHash != hash || pos.ChainName != "doge_main" {
+			t.Errorf("unexpected position %+v", pos)
+		}
+	}
+}
+
+func TestResolveStart(t *testing.T) {
+	tests := []struct {
+		opts   []Option
+		height int64
+	}{
+		{nil, 0}, // fewer than 100 blocks below the tip
+		{[]Option{WithStartBelowTip(5)}, 44},
+		{[]Option{WithStartAtGenesis()}, 0},
+		{[]Option{WithStartHeight(12)}, 12},
+		{[]Option{WithStartHash("0000000000000000000000000000000000000000000000000000000000000021")}, 33},
+	}
+	for _, test := range tests {
+		follower := NewChainFollower(timedChain(50), test.opts...)
+		pos, err := follower.ResolveStart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos.BlockHeight != test.height || pos.WaitingForNextHash {
+			t.Errorf("options %v: got %+v, expected height %d", test.opts, pos, test.height)
+		}
+	}
+
+	opts := defaultOptions()
+	WithStartTime(time.Unix(testEpoch, 0))(&opts)
+	if err := opts.Validate(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	WithStartAtGenesis()(&opts)
+	if err := opts.Validate(); err == nil {
+		t.Error("expected error for a start height and a start time")
+	}
+}
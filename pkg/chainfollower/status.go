@@ -0,0 +1,40 @@
+package chainfollower
+
+import (
+	"time"
+)
+
+// Status is a snapshot of the follower's progress, used for health checks.
+type Status struct {
+	Running              bool      // main loop is running (false before Start and after a fatal error)
+	Chain                string    // ChainName detected from the genesis block ("" until detected)
+	InitialBlockDownload bool      // Core node reported initial block download
+	Height               int64     // height of the last block delivered (-1 if none yet)
+	TipHeight            int64     // best known height on the Core node (-1 if unknown)
+	LastBlockSeen        time.Time // wall-clock time the last block was delivered
+	LastError            string    // last error that stopped the main loop (if any)
+	Confirmations        int64     // confirmations a block needs before it is delivered (see WithConfirmations)
+}
+
+// Status returns a snapshot of the follower's progress.
+func (c *ChainFollower) Status() Status {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	return c.status
+}
+
+func (c *ChainFollower) updateStatus(update func(s *Status)) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	update(&c.status)
+}
+
+// setFatal records the error that caused the main loop to exit.
+func (c *ChainFollower) setFatal(err error) {
+	c.updateStatus(func(s *Status) {
+		s.Running = false
+		if err != nil {
+			s.LastError = err.Error()
+		}
+	})
+}
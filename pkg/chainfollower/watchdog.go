@@ -0,0 +1,152 @@
+package chainfollower
+
+import (
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+)
+
+// The watchdog runs alongside the main loop (see WithWatchdog), comparing the
+// follower's progress with Core's block and header counts and the age of
+// Core's tip block. Each alert is sent once when its threshold is crossed
+// and once more when it recovers.
+
+// Stall reasons (StallMessage.Reason).
+const (
+	StallFollower = "follower" // no block delivered while Core has newer ones
+	StallTip      = "tip"      // Core's tip block is older than MaxTipAge
+	StallRPC      = "rpc"      // Core has been unreachable for StallTimeout
+)
+
+func (p WatchdogPolicy) enabled() bool {
+	return p.MaxLag > 0 || p.StallTimeout > 0 || p.MaxTipAge > 0
+}
+
+// watchdog holds the state of the checks between ticks.
+type watchdog struct {
+	height   int64                // follower height at the last check
+	progress time.Time            // last check at which the follower was caught up or had advanced
+	lastOK   time.Time            // last check at which Core answered
+	stalls   map[string]time.Time // active stalls: reason -> since
+	lagging  bool
+}
+
+func newWatchdog(now time.Time) *watchdog {
+	return &watchdog{height: -1, progress: now, lastOK: now, stalls: map[string]time.Time{}}
+}
+
+func (c *ChainFollower) runWatchdog() {
+	w := newWatchdog(time.Now())
+	ticker := time.NewTicker(c.opts.Watchdog.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.context.Done():
+			return
+		case now := <-ticker.C:
+			for _, msg := range c.checkWatchdog(w, now) {
+				c.alert(msg)
+			}
+		}
+	}
+}
+
+// checkWatchdog compares the follower's status with Core and returns the
+// alerts that were raised or recovered.
+func (c *ChainFollower) checkWatchdog(w *watchdog, now time.Time) []messages.Message {
+	p := c.opts.Watchdog
+	status := c.Status()
+	var alerts []messages.Message
+	setStall := func(reason string, stalled bool, since time.Time, tipHeight int64) {
+		prev, active := w.stalls[reason]
+		if stalled == active {
+			return
+		}
+		if active {
+			since = prev
+			delete(w.stalls, reason)
+		} else {
+			w.stalls[reason] = since
+		}
+		alerts = append(alerts, messages.StallMessage{
+			Active:    stalled,
+			Reason:    reason,
+			Height:    status.Height,
+			TipHeight: tipHeight,
+			Since:     since,
+			Duration:  now.Sub(since),
+		})
+	}
+
+	info, err := c.rpc.GetBlockchainInfo()
+	if err != nil {
+		c.Logger.Warn("ChainFollower: watchdog cannot reach Core", "error", err)
+		w.progress = now // the follower cannot progress either; that is the rpc stall
+		if p.StallTimeout > 0 {
+			setStall(StallRPC, now.Sub(w.lastOK) > p.StallTimeout, w.lastOK, -1)
+		}
+		return alerts
+	}
+	w.lastOK = now
+	setStall(StallRPC, false, now, info.Blocks)
+
+	// blocks the follower holds back until they have enough confirmations.
+	depth := max(c.opts.Confirmations-1, 0)
+
+	if p.StallTimeout > 0 {
+		// the follower waits on purpose during initial block download.
+		behind := info.Blocks-depth > status.Height && !info.InitialBlockDownload
+		if !behind || status.Height != w.height {
+			w.progress = now
+		}
+		setStall(StallFollower, now.Sub(w.progress) > p.StallTimeout, w.progress, info.Blocks)
+	}
+	w.height = status.Height
+
+	if p.MaxTipAge > 0 {
+		if header, err := c.rpc.GetBlockHeader(info.BestBlockHash); err != nil {
+			c.Logger.Warn("ChainFollower: watchdog cannot fetch the tip header", "hash", info.BestBlockHash, "error", err)
+		} else {
+			tipTime := time.Unix(int64(header.Time), 0)
+			setStall(StallTip, now.Sub(tipTime) > p.MaxTipAge, tipTime, info.Blocks)
+		}
+	}
+
+	if p.MaxLag > 0 && status.Height >= 0 {
+		headers := max(info.Headers, info.Blocks)
+		lag := max(headers-status.Height-depth, 0)
+		if lagging := lag > p.MaxLag; lagging != w.lagging {
+			w.lagging = lagging
+			alerts = append(alerts, messages.LagMessage{
+				Active:       lagging,
+				Height:       status.Height,
+				TipHeight:    info.Blocks,
+				HeaderHeight: headers,
+				Lag:          lag,
+			})
+		}
+	}
+	return alerts
+}
+
+// alert logs a watchdog alert, calls the alert hooks and sends it to Messages.
+func (c *ChainFollower) alert(msg messages.Message) {
+	switch m := msg.(type) {
+	case messages.StallMessage:
+		if m.Active {
+			c.Logger.Warn("ChainFollower: stalled", "reason", m.Reason, "height", m.Height, "tip", m.TipHeight, "since", m.Since)
+		} else {
+			c.Logger.Info("ChainFollower: stall recovered", "reason", m.Reason, "height", m.Height, "duration", m.Duration)
+		}
+	case messages.LagMessage:
+		if m.Active {
+			c.Logger.Warn("ChainFollower: lagging behind Core", "lag", m.Lag, "height", m.Height, "headers", m.HeaderHeight)
+		} else {
+			c.Logger.Info("ChainFollower: caught up with Core", "lag", m.Lag, "height", m.Height)
+		}
+	}
+	for _, hook := range c.opts.AlertHooks {
+		hook(msg)
+	}
+	c.send(msg)
+}
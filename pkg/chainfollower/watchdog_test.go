@@ -0,0 +1,119 @@
+package chainfollower
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/rpc"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+)
+
+// flakyTransport fails GetBlockchainInfo while down is set.
+type flakyTransport struct {
+	*rpc.TestRpcTransport
+	down bool
+}
+
+func (f *flakyTransport) GetBlockchainInfo() (*types.BlockchainInfo, error) {
+	if f.down {
+		return nil, errors.New("connection refused")
+	}
+	return f.TestRpcTransport.GetBlockchainInfo()
+}
+
+func TestWatchdog(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	tip := &types.BlockHeader{Hash: "tip", Time: int(start.Unix())}
+	transport := &flakyTransport{TestRpcTransport: rpc.NewTestRpcTransport()}
+	transport.AddBlockAndHeader(&types.Block{Hash: tip.Hash}, tip)
+	info := &types.BlockchainInfo{Blocks: 100, Headers: 100, BestBlockHash: tip.Hash}
+	transport.SetBlockchainInfo(info)
+
+	var hooked []messages.Message
+	follower := NewChainFollower(transport,
+		WithWatchdog(WatchdogPolicy{MaxLag: 5, StallTimeout: time.Minute, MaxTipAge: 10 * time.Minute}),
+		WithAlertHook(func(msg messages.Message) { hooked = append(hooked, msg) }))
+	follower.Messages = make(chan messages.Message, 10)
+	setHeight := func(h int64) { follower.updateStatus(func(s *Status) { s.Height = h }) }
+	w := newWatchdog(start)
+
+	check := func(at time.Duration, expect ...string) {
+		t.Helper()
+		alerts := follower.checkWatchdog(w, start.Add(at))
+		var got []string
+		for _, msg := range alerts {
+			follower.alert(msg)
+			var name string
+			var active bool
+			switch m := msg.(type) {
+			case messages.StallMessage:
+				name, active = m.Reason, m.Active
+			case messages.LagMessage:
+				name, active = "lag", m.Active
+			}
+			if !active {
+				name += " recovered"
+			}
+			got = append(got, name)
+		}
+		if len(got) != len(expect) {
+			t.Fatalf("at %v: expected %v, got %v", at, expect, got)
+		}
+		for i := range got {
+			if got[i] != expect[i] {
+				t.Fatalf("at %v: expected %v, got %v", at, expect, got)
+			}
+		}
+	}
+
+	setHeight(100)
+	check(0)
+	check(time.Minute)
+
+	// Core moves on, the follower does not.
+	info.Blocks, info.Headers = 110, 110
+	check(90*time.Second, "lag")
+	check(3*time.Minute, StallFollower)
+	check(4 * time.Minute)
+	setHeight(108)
+	check(5*time.Minute, StallFollower+" recovered", "lag recovered")
+
+	// Core is unreachable.
+	transport.down = true
+	check(5*time.Minute + 30*time.Second)
+	check(7*time.Minute, StallRPC)
+	transport.down = false
+	check(8*time.Minute, StallRPC+" recovered")
+
+	// Core's tip gets old, then a new block arrives.
+	setHeight(110)
+	check(11*time.Minute, StallTip)
+	tip.Time = int(start.Add(11 * time.Minute).Unix())
+	check(12*time.Minute, StallTip+" recovered")
+
+	if len(hooked) != 8 || len(follower.Messages) != 8 {
+		t.Errorf("expected 8 alerts in hooks and Messages, got %d and %d", len(hooked), len(follower.Messages))
+	}
+	if m := hooked[1].(messages.StallMessage); m.Height != 100 || m.TipHeight != 110 || m.Duration < 2*time.Minute {
+		t.Errorf("stall alert: %+v", m)
+	}
+}
+
+func TestWatchdogConfirmations(t *testing.T) {
+	transport := rpc.NewTestRpcTransport()
+	transport.SetBlockchainInfo(&types.BlockchainInfo{Blocks: 100, Headers: 100})
+	follower := NewChainFollower(transport, WithConfirmations(6),
+		WithWatchdog(WatchdogPolicy{MaxLag: 1, StallTimeout: time.Minute}))
+	follower.updateStatus(func(s *Status) { s.Height = 95 })
+
+	// five blocks behind the tip is where a 6-confirmation follower waits.
+	start := time.Now()
+	w := newWatchdog(start)
+	for _, at := range []time.Duration{0, time.Hour} {
+		if alerts := follower.checkWatchdog(w, start.Add(at)); len(alerts) != 0 {
+			t.Errorf("unexpected alerts: %+v", alerts)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package chainparams
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/dogecoinfoundation/chainfollower/internal/doge"
+	"github.com/dogecoinfoundation/chainfollower/pkg/address"
+	"github.com/dogecoinfoundation/chainfollower/pkg/config"
+)
+
+// Custom networks (e.g. private regtest-derived chains with their own
+// genesis block). Register them before creating a ChainFollower or decoding
+// keys and addresses: the follower, address, wif and hdwallet packages
+// recognise registered chains like the built-in ones.
+
+type Definition = doge.ChainDefinition
+
+// RegisterChainParams adds a custom network. Unset prefixes and limits are
+// inherited from def.Base (default address.RegTest).
+func RegisterChainParams(def Definition) (*address.Network, error) {
+	return doge.RegisterChainParams(def)
+}
+
+// Chains returns the registered networks, built-in chains first.
+func Chains() []*address.Network {
+	return doge.Chains()
+}
+
+// ByName accepts a ChainName or a Core network name (main, test, regtest).
+func ByName(name string) (*address.Network, error) {
+	return doge.ChainFromName(name)
+}
+
+// ByGenesisHash returns the network whose block #0 has this hash.
+func ByGenesisHash(hash string) (*address.Network, error) {
+	return doge.ChainFromGenesisHash(hash)
+}
+
+// RegisterFromConfig registers the [[chain]] sections of the config file.
+func RegisterFromConfig(chains []config.ChainConfig) ([]*address.Network, error) {
+	var registered []*address.Network
+	for _, cfg := range chains {
+		def, err := definitionFromConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		chain, err := RegisterChainParams(def)
+		if err != nil {
+			return nil, fmt.Errorf("config [[chain]] %q: %w", cfg.Name, err)
+		}
+		registered = append(registered, chain)
+	}
+	return registered, nil
+}
+
+func definitionFromConfig(cfg config.ChainConfig) (Definition, error) {
+	def := Definition{
+		ChainName:           cfg.Name,
+		Network:             cfg.Network,
+		GenesisBlock:        cfg.GenesisHash,
+		P2PKHPrefix:         cfg.P2PKHPrefix,
+		P2SHPrefix:          cfg.P2SHPrefix,
+		WIFPrefix:           cfg.WIFPrefix,
+		Bip32PrivKeyPrefix:  cfg.Bip32PrivatePrefix,
+		Bip32PubKeyPrefix:   cfg.Bip32PublicPrefix,
+		PowLimitBits:        cfg.PowLimitBits,
+		AuxPoWStrictChainID: cfg.AuxPoWStrictChainID,
+	}
+	if cfg.Base != "" {
+		base, err := doge.ChainFromName(cfg.Base)
+		if err != nil {
+			return def, fmt.Errorf("config [[chain]] %q: unknown base chain %q", cfg.Name, cfg.Base)
+		}
+		def.Base = base
+	}
+	if len(cfg.Checkpoints) > 0 {
+		def.Checkpoints = make(map[int64]string, len(cfg.Checkpoints))
+		for key, hash := range cfg.Checkpoints {
+			height, err := strconv.ParseInt(key, 10, 64)
+			if err != nil || height < 0 {
+				return def, fmt.Errorf("config [[chain]] %q checkpoints: height must be a number: %q", cfg.Name, key)
+			}
+			def.Checkpoints[height] = hash
+		}
+	}
+	return def, nil
+}
@@ -1,6 +1,10 @@
 package config
 
-import "github.com/BurntSushi/toml"
+import (
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
 
 type Config struct {
 	Path    string
@@ -8,7 +12,97 @@ type Config struct {
 	RpcUser string `toml:"rpc_user"`
 	RpcPass string `toml:"rpc_pass"`
 	ZmqUrl  string `toml:"zmq_url"`
-	DbUrl   string `toml:"db_url"`
+	DbUrl   string `toml:"db_url"` // optional: index blocks into sqlite://file.db or postgres://...
+
+	HttpListen  string `toml:"http_listen"`   // optional: address for /metrics, /healthz and /readyz, e.g. ":9100"
+	LogLevel    string `toml:"log_level"`     // optional: debug, info (default), warn or error
+	ReadyMaxLag int64  `toml:"ready_max_lag"` // optional: /readyz fails when more than this many blocks behind tip
+
+	BlockCacheSize int    `toml:"block_cache_size"` // optional: keep this many blocks in memory (see rpc.CachingTransport)
+	BlockCacheDir  string `toml:"block_cache_dir"`  // optional: also keep fetched blocks in this directory
+	ArchiveDir     string `toml:"archive_dir"`      // optional: follow a block archive (`chainfollower export`) instead of Core
+
+	ShutdownTimeout time.Duration `toml:"shutdown_timeout"` // optional: on SIGINT/SIGTERM, wait this long for in-flight messages (default 30s)
+
+	Follower FollowerConfig `toml:"follower"`
+	Serve    ServeConfig    `toml:"serve"`
+	Chains   []ChainConfig  `toml:"chain"` // custom networks, registered with chainparams.RegisterFromConfig
+	Sinks    []SinkConfig   `toml:"sink"`  // output sinks, created with sink.FromConfig
+}
+
+// FollowerConfig holds the [follower] section: ChainFollower options.
+// All fields are optional; see chainfollower.OptionsFromConfig.
+type FollowerConfig struct {
+	StartHeight      *int64        `toml:"start_height"`       // start at this block height (when there is no saved position)
+	StartHash        string        `toml:"start_hash"`         // start at this block hash (when there is no saved position)
+	StartTime        time.Time     `toml:"start_time"`         // start at the first block at or after this time, e.g. 2024-01-01T00:00:00Z
+	StartBelowTip    *int64        `toml:"start_below_tip"`    // start this many blocks below the tip (default 100)
+	PollInterval     time.Duration `toml:"poll_interval"`      // how often to poll for a new block at the tip, e.g. "1s"
+	RetryDelay       time.Duration `toml:"retry_delay"`        // delay after RPC errors, e.g. "5s"
+	MaxStartAttempts int           `toml:"max_start_attempts"` // attempts to find the starting position
+	WrongChainDelay  time.Duration `toml:"wrong_chain_delay"`  // delay before re-checking an unrecognised chain
+	IBDDelay         time.Duration `toml:"ibd_delay"`          // delay while Core is in initial block download
+	ExpectedChain    string        `toml:"expected_chain"`     // main, test, regtest or the name of a [[chain]]
+	ChannelSize      *int          `toml:"channel_size"`       // Messages channel buffer size
+	Confirmations    *int64        `toml:"confirmations"`      // only deliver blocks with at least this many confirmations
+	VerifyBlocks     bool          `toml:"verify_blocks"`      // verify PoW, AuxPoW and merkle roots of blocks from Core
+	DataCarrier      bool          `toml:"data_carrier"`       // send DataCarrierMessage for OP_RETURN outputs
+	DataPrefixes     []string      `toml:"data_prefixes"`      // only OP_RETURN payloads with these prefixes, e.g. ["DOGE"]
+
+	Checkpoints map[string]string `toml:"checkpoints"` // extra checkpoints: "height" = "block hash"
+	Watchdog    WatchdogConfig    `toml:"watchdog"`
+}
+
+// WatchdogConfig holds the [follower.watchdog] section: stall and lag
+// alerts, disabled unless a threshold is set.
+type WatchdogConfig struct {
+	Interval     time.Duration `toml:"interval"`      // how often to check Core (default 30s)
+	MaxLag       int64         `toml:"max_lag"`       // alert when more than this many blocks behind Core's headers
+	StallTimeout time.Duration `toml:"stall_timeout"` // alert when no block is delivered for this long while Core has newer ones
+	MaxTipAge    time.Duration `toml:"max_tip_age"`   // alert when Core's tip block is older than this, e.g. "30m"
+}
+
+// ServeConfig holds the [serve] section, used by `chainfollower serve`.
+type ServeConfig struct {
+	WebSocketListen string `toml:"websocket_listen"` // address for the WebSocket stream at /stream, e.g. ":8080"
+	GRPCListen      string `toml:"grpc_listen"`      // address for the gRPC Stream service, e.g. ":9090"
+	History         int    `toml:"history"`          // events kept for resuming clients (default 10000); older positions are replayed from Core
+	MaxReplays      int    `toml:"max_replays"`      // clients replayed from Core at once (default 4)
+}
+
+// SinkConfig holds a [[sink]] section: an external system that receives
+// every event, with its position saved in position-<name>.json.
+type SinkConfig struct {
+	Name         string        `toml:"name"`          // unique name, used in logs and the position file
+	Type         string        `toml:"type"`          // webhook, nats or kafka
+	URL          string        `toml:"url"`           // webhook: URL to POST to; nats: nats://host:4222
+	Secret       string        `toml:"secret"`        // webhook: HMAC-SHA256 signing key
+	Subject      string        `toml:"subject"`       // nats: subject prefix (events go to <subject>.<type>)
+	Brokers      []string      `toml:"brokers"`       // kafka: seed brokers, e.g. ["localhost:9092"]
+	Topic        string        `toml:"topic"`         // kafka: topic (events go to partition 0)
+	Timeout      time.Duration `toml:"timeout"`       // per-delivery timeout (default 10s)
+	Types        []string      `toml:"types"`         // only these event types, e.g. ["block", "rollback"]
+	HeadersOnly  bool          `toml:"headers_only"`  // omit transactions from blocks
+	DataPrefixes []string      `toml:"data_prefixes"` // only data events with these payload prefixes, e.g. ["DOGE"]
+	MaxAttempts  int           `toml:"max_attempts"`  // give up (and stop) after this many attempts (0 = retry forever)
+	RetryDelay   time.Duration `toml:"retry_delay"`   // first delay between attempts (default 1s, doubling)
+}
+
+// ChainConfig holds a [[chain]] section: a custom network such as a private
+// regtest-derived chain. Unset prefixes are inherited from the base chain.
+type ChainConfig struct {
+	Name                string            `toml:"name"`                   // chain name, e.g. "doge_privnet" (use as expected_chain)
+	Base                string            `toml:"base"`                   // chain to inherit parameters from (default regtest)
+	Network             string            `toml:"network"`                // network name reported by Core's getblockchaininfo (default: the base chain's)
+	GenesisHash         string            `toml:"genesis_hash"`           // hash of block #0
+	P2PKHPrefix         *uint8            `toml:"p2pkh_prefix"`           // address version byte, e.g. 0x6f
+	P2SHPrefix          *uint8            `toml:"p2sh_prefix"`            // script address version byte, e.g. 0xc4
+	WIFPrefix           *uint8            `toml:"wif_prefix"`             // private key (WIF) version byte, e.g. 0xef
+	Bip32PrivatePrefix  *uint32           `toml:"bip32_private_prefix"`   // extended private key version, e.g. 0x04358394
+	Bip32PublicPrefix   *uint32           `toml:"bip32_public_prefix"`    // extended public key version, e.g. 0x043587cf
+	PowLimitBits        uint32            `toml:"pow_limit_bits"`         // easiest allowed target (compact form), e.g. 0x207fffff
+	AuxPoWStrictChainID *bool             `toml:"auxpow_strict_chain_id"` // blocks (other than version 1 and 2) must use Dogecoin's chain ID
+	Checkpoints         map[string]string `toml:"checkpoints"`            // "height" = "block hash"
 }
 
 func LoadConfig(path string) (*Config, error) {
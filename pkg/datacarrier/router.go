@@ -0,0 +1,146 @@
+package datacarrier
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+)
+
+// Routes OP_RETURN payloads (messages.DataCarrierMessage) to handlers
+// registered for a protocol prefix, and tells each handler which of its
+// payloads were undone by a RollbackMessage.
+
+const DEFAULT_MAX_REORG_DEPTH = 100 // blocks of delivered payloads to remember for rollbacks.
+
+type Handler interface {
+	// HandlePayload receives payloads that start with the registered prefix.
+	HandlePayload(msg messages.DataCarrierMessage)
+	// HandleRollback receives the previously delivered payloads that are
+	// no longer on-chain, newest first.
+	HandleRollback(rollback messages.RollbackMessage, undone []messages.DataCarrierMessage)
+}
+
+// HandlerFuncs adapts functions to the Handler interface (either may be nil).
+type HandlerFuncs struct {
+	OnPayload  func(msg messages.DataCarrierMessage)
+	OnRollback func(rollback messages.RollbackMessage, undone []messages.DataCarrierMessage)
+}
+
+func (h HandlerFuncs) HandlePayload(msg messages.DataCarrierMessage) {
+	if h.OnPayload != nil {
+		h.OnPayload(msg)
+	}
+}
+
+func (h HandlerFuncs) HandleRollback(rollback messages.RollbackMessage, undone []messages.DataCarrierMessage) {
+	if h.OnRollback != nil {
+		h.OnRollback(rollback, undone)
+	}
+}
+
+type route struct {
+	prefix    []byte
+	handler   Handler
+	delivered []messages.DataCarrierMessage // recent payloads, oldest first
+}
+
+type Router struct {
+	mu             sync.Mutex
+	routes         []*route
+	MaxReorgDepth  int64 // payloads older than this many blocks are forgotten
+	lastSeenHeight int64
+}
+
+func NewRouter() *Router {
+	return &Router{MaxReorgDepth: DEFAULT_MAX_REORG_DEPTH}
+}
+
+// Register a handler for payloads starting with prefix, e.g. []byte("DOGE").
+// An empty prefix receives every payload. A payload is delivered to every
+// matching handler.
+func (r *Router) Register(prefix []byte, h Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes = append(r.routes, &route{prefix: append([]byte(nil), prefix...), handler: h})
+}
+
+// Dispatch routes a follower message; other message types are ignored.
+// Returns true if the message was a DataCarrierMessage or RollbackMessage.
+func (r *Router) Dispatch(msg messages.Message) bool {
+	switch m := msg.(type) {
+	case messages.DataCarrierMessage:
+		r.dispatchPayload(m)
+		return true
+	case messages.RollbackMessage:
+		r.dispatchRollback(m)
+		return true
+	}
+	return false
+}
+
+func (r *Router) dispatchPayload(msg messages.DataCarrierMessage) {
+	r.mu.Lock()
+	r.lastSeenHeight = max(r.lastSeenHeight, msg.BlockHeight)
+	var matched []Handler
+	for _, rt := range r.routes {
+		if bytes.HasPrefix(msg.Data, rt.prefix) {
+			rt.delivered = append(rt.delivered, msg)
+			r.prune(rt)
+			matched = append(matched, rt.handler)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, h := range matched {
+		h.HandlePayload(msg)
+	}
+}
+
+func (r *Router) dispatchRollback(rollback messages.RollbackMessage) {
+	if rollback.NewChainPos == nil {
+		return
+	}
+	forkHeight := rollback.NewChainPos.BlockHeight
+
+	type undo struct {
+		handler Handler
+		undone  []messages.DataCarrierMessage
+	}
+	var undos []undo
+
+	r.mu.Lock()
+	for _, rt := range r.routes {
+		keep := len(rt.delivered)
+		for keep > 0 && rt.delivered[keep-1].BlockHeight > forkHeight {
+			keep--
+		}
+		if keep == len(rt.delivered) {
+			continue
+		}
+		undone := make([]messages.DataCarrierMessage, 0, len(rt.delivered)-keep)
+		for i := len(rt.delivered) - 1; i >= keep; i-- {
+			undone = append(undone, rt.delivered[i])
+		}
+		rt.delivered = rt.delivered[:keep]
+		undos = append(undos, undo{rt.handler, undone})
+	}
+	r.lastSeenHeight = forkHeight
+	r.mu.Unlock()
+
+	for _, u := range undos {
+		u.handler.HandleRollback(rollback, u.undone)
+	}
+}
+
+// prune forgets payloads too deep to be rolled back.
+func (r *Router) prune(rt *route) {
+	cutoff := r.lastSeenHeight - r.MaxReorgDepth
+	drop := 0
+	for drop < len(rt.delivered) && rt.delivered[drop].BlockHeight < cutoff {
+		drop++
+	}
+	if drop > 0 {
+		rt.delivered = append([]messages.DataCarrierMessage(nil), rt.delivered[drop:]...)
+	}
+}
@@ -0,0 +1,48 @@
+package datacarrier
+
+import (
+	"testing"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/state"
+)
+
+func TestRouterPrefixesAndRollback(t *testing.T) {
+	router := NewRouter()
+
+	var doge, dns []messages.DataCarrierMessage
+	var undone []messages.DataCarrierMessage
+	router.Register([]byte("DOGE"), HandlerFuncs{
+		OnPayload: func(msg messages.DataCarrierMessage) { doge = append(doge, msg) },
+		OnRollback: func(rollback messages.RollbackMessage, payloads []messages.DataCarrierMessage) {
+			undone = append(undone, payloads...)
+		},
+	})
+	router.Register([]byte("dns"), HandlerFuncs{
+		OnPayload: func(msg messages.DataCarrierMessage) { dns = append(dns, msg) },
+	})
+
+	router.Dispatch(messages.DataCarrierMessage{BlockHeight: 10, TxID: "a", Data: []byte("DOGE:one")})
+	router.Dispatch(messages.DataCarrierMessage{BlockHeight: 11, TxID: "b", Data: []byte("dns:example")})
+	router.Dispatch(messages.DataCarrierMessage{BlockHeight: 12, TxID: "c", Data: []byte("DOGE:two")})
+	router.Dispatch(messages.DataCarrierMessage{BlockHeight: 13, TxID: "d", Data: []byte("other")})
+
+	if len(doge) != 2 || len(dns) != 1 {
+		t.Fatalf("wrong routing: DOGE=%d dns=%d", len(doge), len(dns))
+	}
+
+	router.Dispatch(messages.RollbackMessage{
+		OldChainPos: &state.ChainPos{BlockHeight: 13},
+		NewChainPos: &state.ChainPos{BlockHeight: 11},
+	})
+	if len(undone) != 1 || undone[0].TxID != "c" {
+		t.Errorf("expected payload c to be rolled back, got %v", undone)
+	}
+
+	// rolling back again past height 10 only undoes payload a.
+	undone = nil
+	router.Dispatch(messages.RollbackMessage{NewChainPos: &state.ChainPos{BlockHeight: 5}})
+	if len(undone) != 1 || undone[0].TxID != "a" {
+		t.Errorf("expected payload a to be rolled back, got %v", undone)
+	}
+}
@@ -0,0 +1,150 @@
+package hdwallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dogecoinfoundation/chainfollower/internal/doge"
+	"github.com/dogecoinfoundation/chainfollower/pkg/address"
+)
+
+// BIP32 extended keys (dgpv/dgub on mainnet, tprv/tpub on testnet and regtest)
+// and BIP44-style receive/change address derivation for watch-only wallets.
+
+type ExtendedKey = doge.ExtendedKey
+
+const HardenedKeyStart = doge.HardenedKeyStart
+
+const (
+	ReceiveBranch = 0 // m/0/i: addresses handed out to payers
+	ChangeBranch  = 1 // m/1/i: change addresses
+)
+
+const DEFAULT_GAP_LIMIT = 20 // unused addresses to derive past the last used one.
+
+var (
+	ErrHardenedFromPublic = doge.ErrHardenedFromPublic
+	ErrInvalidChild       = doge.ErrInvalidChild
+	ErrInvalidBranch      = errors.New("hdwallet: branch must be 0 (receive) or 1 (change)")
+)
+
+// ParseExtendedKey decodes a dgpv/dgub/tprv/tpub key. Testnet and regtest
+// share version bytes, so pass the network to choose between them; nil
+// accepts any Dogecoin network (tpub keys decode as testnet).
+func ParseExtendedKey(key string, net *address.Network) (*ExtendedKey, error) {
+	return doge.DecodeExtendedKey(key, net)
+}
+
+// NewMasterKey derives the master private key from a 16 to 64 byte seed.
+func NewMasterKey(net *address.Network, seed []byte) (*ExtendedKey, error) {
+	return doge.NewMasterKey(net, seed)
+}
+
+// DeriveAddresses returns the P2PKH addresses for m/branch/from ..
+// m/branch/(from+count-1) below an (account-level) extended key.
+func DeriveAddresses(key *ExtendedKey, branch uint32, from uint32, count int) ([]string, error) {
+	branchKey, err := key.Child(branch)
+	if err != nil {
+		return nil, fmt.Errorf("hdwallet: derive branch %d: %w", branch, err)
+	}
+	addrs := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		child, err := branchKey.Child(from + uint32(i))
+		if err != nil {
+			return nil, fmt.Errorf("hdwallet: derive %d/%d: %w", branch, from+uint32(i), err)
+		}
+		addr, err := child.Address()
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// Account is a watch-only BIP44 account: an extended public key (usually
+// m/44'/3'/n') with gap-limited receive and change address sets.
+type Account struct {
+	Key      *ExtendedKey
+	GapLimit int
+	Receive  []string // m/0/0 .. m/0/(len-1)
+	Change   []string // m/1/0 .. m/1/(len-1)
+}
+
+// NewAccount parses a dgub (or dgpv, which is neutered) and derives the first
+// gapLimit receive and change addresses (gapLimit <= 0 uses DEFAULT_GAP_LIMIT).
+func NewAccount(key string, net *address.Network, gapLimit int) (*Account, error) {
+	k, err := ParseExtendedKey(key, net)
+	if err != nil {
+		return nil, err
+	}
+	if gapLimit <= 0 {
+		gapLimit = DEFAULT_GAP_LIMIT
+	}
+	a := &Account{Key: k.Neuter(), GapLimit: gapLimit}
+	if err := a.extend(ReceiveBranch, gapLimit); err != nil {
+		return nil, err
+	}
+	if err := a.extend(ChangeBranch, gapLimit); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// MarkUsed records that address `index` on `branch` has received funds,
+// deriving more addresses so that GapLimit unused ones follow it.
+// Returns the newly derived addresses.
+func (a *Account) MarkUsed(branch uint32, index int) ([]string, error) {
+	addrs, err := a.branch(branch)
+	if err != nil {
+		return nil, err
+	}
+	have := len(*addrs)
+	need := index + 1 + a.GapLimit
+	if need <= have {
+		return nil, nil
+	}
+	if err := a.extend(branch, need-have); err != nil {
+		return nil, err
+	}
+	return (*addrs)[have:], nil
+}
+
+// Lookup returns the branch and index of a derived address.
+func (a *Account) Lookup(addr string) (branch uint32, index int, ok bool) {
+	for i, r := range a.Receive {
+		if r == addr {
+			return ReceiveBranch, i, true
+		}
+	}
+	for i, c := range a.Change {
+		if c == addr {
+			return ChangeBranch, i, true
+		}
+	}
+	return 0, 0, false
+}
+
+// branch returns the address set of a branch (receive or change).
+func (a *Account) branch(branch uint32) (*[]string, error) {
+	switch branch {
+	case ReceiveBranch:
+		return &a.Receive, nil
+	case ChangeBranch:
+		return &a.Change, nil
+	}
+	return nil, fmt.Errorf("%w: %d", ErrInvalidBranch, branch)
+}
+
+func (a *Account) extend(branch uint32, count int) error {
+	addrs, err := a.branch(branch)
+	if err != nil {
+		return err
+	}
+	derived, err := DeriveAddresses(a.Key, branch, uint32(len(*addrs)), count)
+	if err != nil {
+		return err
+	}
+	*addrs = append(*addrs, derived...)
+	return nil
+}
@@ -0,0 +1,82 @@
+package hdwallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/address"
+)
+
+// testAccount returns m/44'/3'/0' of the BIP32 test vector 1 seed.
+func testAccount(t *testing.T, gapLimit int) *Account {
+	t.Helper()
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := NewMasterKey(address.MainNet, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := master.DerivePath(44+HardenedKeyStart, 3+HardenedKeyStart, HardenedKeyStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := NewAccount(key.String(), address.MainNet, gapLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return account
+}
+
+func TestAccountGap(t *testing.T) {
+	a := testAccount(t, 3)
+	receive, _ := DeriveAddresses(a.Key, ReceiveBranch, 0, 8)
+	change, _ := DeriveAddresses(a.Key, ChangeBranch, 0, 3)
+	if !slices.Equal(a.Receive, receive[:3]) || !slices.Equal(a.Change, change) {
+		t.Fatalf("initial addresses:\n%v\n%v", a.Receive, a.Change)
+	}
+	if slices.Equal(receive[:3], change) {
+		t.Fatal("receive and change branches derived the same addresses")
+	}
+
+	// using the last address extends the branch to keep 3 unused after it.
+	added, err := a.MarkUsed(ReceiveBranch, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(added, receive[3:6]) || !slices.Equal(a.Receive, receive[:6]) {
+		t.Errorf("after using receive 2: added %v, have %d", added, len(a.Receive))
+	}
+	if added, _ := a.MarkUsed(ReceiveBranch, 1); added != nil {
+		t.Errorf("using an earlier address derived %v", added)
+	}
+	if len(a.Change) != 3 {
+		t.Errorf("change branch changed: %d addresses", len(a.Change))
+	}
+
+	for _, c := range []struct {
+		addr   string
+		branch uint32
+		index  int
+		ok     bool
+	}{
+		{receive[5], ReceiveBranch, 5, true},
+		{change[1], ChangeBranch, 1, true},
+		{receive[7], 0, 0, false}, // not derived yet
+	} {
+		branch, index, ok := a.Lookup(c.addr)
+		if branch != c.branch || index != c.index || ok != c.ok {
+			t.Errorf("Lookup(%v) = %d, %d, %v; want %d, %d, %v", c.addr, branch, index, ok, c.branch, c.index, c.ok)
+		}
+	}
+}
+
+func TestInvalidBranch(t *testing.T) {
+	a := testAccount(t, 3)
+	if _, err := a.MarkUsed(5, 0); !errors.Is(err, ErrInvalidBranch) {
+		t.Errorf("expected ErrInvalidBranch, got %v", err)
+	}
+	if len(a.Receive) != 3 || len(a.Change) != 3 {
+		t.Errorf("an invalid branch changed the account: %d receive, %d change", len(a.Receive), len(a.Change))
+	}
+}
@@ -0,0 +1,135 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/chainfollower"
+)
+
+const DEFAULT_MAX_LAG = 5 // blocks behind tip before the follower is not ready.
+
+type StatusSource interface {
+	Status() chainfollower.Status
+}
+
+// Checker serves Kubernetes-style /healthz (liveness) and /readyz (readiness)
+// endpoints for a ChainFollower. Responses are JSON; failing checks return 503.
+type Checker struct {
+	Follower     StatusSource
+	MaxLagBlocks int64         // ready only within this many blocks of tip (0 = DEFAULT_MAX_LAG)
+	MaxBlockAge  time.Duration // optional: not ready if no block was seen for this long
+}
+
+type Report struct {
+	Status               string    `json:"status"` // "ok" or "unavailable"
+	Problems             []string  `json:"problems,omitempty"`
+	Running              bool      `json:"running"`
+	Chain                string    `json:"chain,omitempty"`
+	InitialBlockDownload bool      `json:"initial_block_download"`
+	Height               int64     `json:"height"`
+	TipHeight            int64     `json:"tip_height"`
+	LagBlocks            int64     `json:"lag_blocks"`
+	LastBlockSeen        time.Time `json:"last_block_seen,omitzero"`
+	LastError            string    `json:"last_error,omitempty"`
+}
+
+func NewChecker(follower StatusSource, maxLagBlocks int64) *Checker {
+	return &Checker{Follower: follower, MaxLagBlocks: maxLagBlocks}
+}
+
+// Handler returns a mux serving /healthz and /readyz.
+func (h *Checker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	h.Register(mux)
+	return mux
+}
+
+// Register adds the /healthz and /readyz routes to an existing mux.
+func (h *Checker) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", h.ServeHealthz)
+	mux.HandleFunc("/readyz", h.ServeReadyz)
+}
+
+// Healthz reports whether the follower main loop is alive.
+func (h *Checker) Healthz() Report {
+	st := h.Follower.Status()
+	r := newReport(st)
+	if !st.Running {
+		r.Problems = append(r.Problems, "follower is not running")
+	}
+	return r.finish()
+}
+
+// Readyz reports whether the follower is alive and caught up with the chain.
+func (h *Checker) Readyz() Report {
+	st := h.Follower.Status()
+	r := newReport(st)
+	if !st.Running {
+		r.Problems = append(r.Problems, "follower is not running")
+	}
+	if st.Chain == "" {
+		r.Problems = append(r.Problems, "chain not detected yet")
+	}
+	if st.InitialBlockDownload {
+		r.Problems = append(r.Problems, "core node is in initial block download")
+	}
+	maxLag := h.MaxLagBlocks
+	if maxLag <= 0 {
+		maxLag = DEFAULT_MAX_LAG
+	}
+	if st.Height < 0 || st.TipHeight < 0 {
+		r.Problems = append(r.Problems, "no blocks received yet")
+	} else if r.LagBlocks > maxLag {
+		r.Problems = append(r.Problems, "follower is too far behind tip")
+	}
+	if h.MaxBlockAge > 0 && !st.LastBlockSeen.IsZero() && time.Since(st.LastBlockSeen) > h.MaxBlockAge {
+		r.Problems = append(r.Problems, "no block seen recently")
+	}
+	return r.finish()
+}
+
+func (h *Checker) ServeHealthz(w http.ResponseWriter, req *http.Request) {
+	writeReport(w, h.Healthz())
+}
+
+func (h *Checker) ServeReadyz(w http.ResponseWriter, req *http.Request) {
+	writeReport(w, h.Readyz())
+}
+
+func newReport(st chainfollower.Status) *Report {
+	lag := int64(0)
+	if st.Height >= 0 {
+		// blocks the follower holds back until they have enough confirmations.
+		depth := max(st.Confirmations-1, 0)
+		lag = max(st.TipHeight-st.Height-depth, 0)
+	}
+	return &Report{
+		Running:              st.Running,
+		Chain:                st.Chain,
+		InitialBlockDownload: st.InitialBlockDownload,
+		Height:               st.Height,
+		TipHeight:            st.TipHeight,
+		LagBlocks:            lag,
+		LastBlockSeen:        st.LastBlockSeen,
+		LastError:            st.LastError,
+	}
+}
+
+func (r *Report) finish() Report {
+	if len(r.Problems) == 0 {
+		r.Status = "ok"
+	} else {
+		r.Status = "unavailable"
+	}
+	return *r
+}
+
+func writeReport(w http.ResponseWriter, r Report) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(r)
+}
@@ -0,0 +1,85 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/chainfollower"
+)
+
+type fixedStatus chainfollower.Status
+
+func (s fixedStatus) Status() chainfollower.Status {
+	return chainfollower.Status(s)
+}
+
+func TestChecks(t *testing.T) {
+	running := chainfollower.Status{Running: true, Chain: "main", Height: 100, TipHeight: 100, LastBlockSeen: time.Now()}
+	with := func(update func(s *chainfollower.Status)) chainfollower.Status {
+		s := running
+		update(&s)
+		return s
+	}
+	tests := []struct {
+		name     string
+		status   chainfollower.Status
+		healthy  bool
+		ready    bool
+		problems []string // readyz problems
+		lag      int64
+	}{
+		{"not started", chainfollower.Status{Height: -1, TipHeight: -1}, false, false,
+			[]string{"follower is not running", "chain not detected yet", "no blocks received yet"}, 0},
+		{"caught up", running, true, true, nil, 0},
+		{"initial block download", with(func(s *chainfollower.Status) { s.InitialBlockDownload = true }), true, false,
+			[]string{"core node is in initial block download"}, 0},
+		{"lag under the limit", with(func(s *chainfollower.Status) { s.Height = 95 }), true, true, nil, 5},
+		{"lag over the limit", with(func(s *chainfollower.Status) { s.Height = 94 }), true, false,
+			[]string{"follower is too far behind tip"}, 6},
+		{"lag held back for confirmations", with(func(s *chainfollower.Status) { s.Height, s.Confirmations = 90, 6 }), true, true, nil, 5},
+		{"stale last block", with(func(s *chainfollower.Status) { s.LastBlockSeen = time.Now().Add(-time.Hour) }), true, false,
+			[]string{"no block seen recently"}, 0},
+		{"fatal error", with(func(s *chainfollower.Status) { s.Running, s.LastError = false, "rpc: unauthorized" }), false, false,
+			[]string{"follower is not running"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(fixedStatus(tt.status), 0)
+			checker.MaxBlockAge = 10 * time.Minute
+			handler := checker.Handler()
+			for _, check := range []struct {
+				path     string
+				ok       bool
+				problems []string
+			}{
+				{"/healthz", tt.healthy, nil},
+				{"/readyz", tt.ready, tt.problems},
+			} {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, check.path, nil))
+				code, status := http.StatusOK, "ok"
+				if !check.ok {
+					code, status = http.StatusServiceUnavailable, "unavailable"
+				}
+				if rec.Code != code {
+					t.Errorf("%v: expected %d, got %d", check.path, code, rec.Code)
+				}
+				var r Report
+				if err := json.NewDecoder(rec.Body).Decode(&r); err != nil {
+					t.Fatalf("%v: %v", check.path, err)
+				}
+				if r.Status != status || r.LagBlocks != tt.lag || r.Height != tt.status.Height ||
+					r.Running != tt.status.Running || r.LastError != tt.status.LastError {
+					t.Errorf("%v: unexpected report %+v", check.path, r)
+				}
+				if check.path == "/readyz" && !slices.Equal(r.Problems, check.problems) {
+					t.Errorf("%v: expected problems %q, got %q", check.path, check.problems, r.Problems)
+				}
+			}
+		})
+	}
+}
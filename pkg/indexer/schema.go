@@ -0,0 +1,74 @@
+package indexer
+
+// The indexer schema (the same DDL works on SQLite and PostgreSQL). Every
+// row carries the height of its block, so a rollback deletes everything
+// above the fork height. Hashes and scripts are lowercase hex; values are
+// in koinu (1 DOGE = 100,000,000 koinu).
+//
+//	blocks          one row per block on the current chain
+//	transactions    tx_index is the position in the block (0 = coinbase)
+//	inputs          prev_txid/prev_vout name the spent output ("" / 0 for coinbase)
+//	outputs         address is set for single-address scripts, otherwise ""
+//	chain_position  the follower position of the last committed block (one row)
+
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS blocks (
+	height      BIGINT  NOT NULL PRIMARY KEY,
+	hash        TEXT    NOT NULL UNIQUE,
+	prev_hash   TEXT    NOT NULL,
+	version     BIGINT  NOT NULL,
+	merkle_root TEXT    NOT NULL,
+	time        BIGINT  NOT NULL,
+	bits        TEXT    NOT NULL,
+	nonce       BIGINT  NOT NULL,
+	size        BIGINT  NOT NULL,
+	tx_count    INTEGER NOT NULL
+)`,
+	`CREATE TABLE IF NOT EXISTS transactions (
+	block_height BIGINT  NOT NULL,
+	tx_index     INTEGER NOT NULL,
+	txid         TEXT    NOT NULL,
+	version      BIGINT  NOT NULL,
+	locktime     BIGINT  NOT NULL,
+	size         BIGINT  NOT NULL,
+	PRIMARY KEY (block_height, tx_index)
+)`,
+	`CREATE INDEX IF NOT EXISTS transactions_txid ON transactions (txid)`,
+	`CREATE TABLE IF NOT EXISTS inputs (
+	block_height BIGINT  NOT NULL,
+	tx_index     INTEGER NOT NULL,
+	n            INTEGER NOT NULL,
+	prev_txid    TEXT    NOT NULL,
+	prev_vout    INTEGER NOT NULL,
+	script_sig   TEXT    NOT NULL,
+	sequence     BIGINT  NOT NULL,
+	PRIMARY KEY (block_height, tx_index, n)
+)`,
+	`CREATE INDEX IF NOT EXISTS inputs_prev ON inputs (prev_txid, prev_vout)`,
+	`CREATE TABLE IF NOT EXISTS outputs (
+	block_height BIGINT  NOT NULL,
+	tx_index     INTEGER NOT NULL,
+	n            INTEGER NOT NULL,
+	value        BIGINT  NOT NULL,
+	script_type  TEXT    NOT NULL,
+	script       TEXT    NOT NULL,
+	address      TEXT    NOT NULL,
+	PRIMARY KEY (block_height, tx_index, n)
+)`,
+	`CREATE INDEX IF NOT EXISTS outputs_address ON outputs (address)`,
+	`CREATE TABLE IF NOT EXISTS chain_position (
+	id                    INTEGER NOT NULL PRIMARY KEY,
+	block_hash            TEXT    NOT NULL,
+	block_height          BIGINT  NOT NULL,
+	waiting_for_next_hash BOOLEAN NOT NULL,
+	chain_name            TEXT    NOT NULL
+)`,
+}
+
+// deleteAbove removes the rows of blocks above a height.
+var deleteAbove = []string{
+	`DELETE FROM outputs WHERE block_height > ?`,
+	`DELETE FROM inputs WHERE block_height > ?`,
+	`DELETE FROM transactions WHERE block_height > ?`,
+	`DELETE FROM blocks WHERE height > ?`,
+}
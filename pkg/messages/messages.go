@@ -1,6 +1,8 @@
 package messages
 
 import (
+	"time"
+
 	"github.com/dogecoinfoundation/chainfollower/pkg/state"
 	"github.com/dogecoinfoundation/chainfollower/pkg/types"
 )
@@ -19,3 +21,112 @@ type RollbackMessage struct {
 	OldChainPos *state.ChainPos
 	NewChainPos *state.ChainPos
 }
+
+// ChainMismatchMessage is sent when the Core node is not on the expected
+// chain, or not on the chain of the saved position. The follower will not
+// deliver blocks until the node is on the right chain.
+type ChainMismatchMessage struct {
+	Message
+	Err error // *chainfollower.ChainMismatchError
+}
+
+// CheckpointAlertMessage is sent when the Core node reports a block hash
+// that contradicts a checkpoint. The follower will not deliver blocks past
+// the checkpoint until the node is back on the checkpointed chain.
+type CheckpointAlertMessage struct {
+	Message
+	Err error // *chainfollower.CheckpointMismatchError
+}
+
+// InvalidBlockMessage is sent when block verification is enabled and a block
+// from the Core node fails proof-of-work, AuxPoW or merkle root checks.
+// The block is not delivered.
+type InvalidBlockMessage struct {
+	Message
+	Err error // *chainfollower.BlockVerificationError
+}
+
+// DataCarrierMessage carries the payload of an OP_RETURN (nulldata) output.
+// It is sent after the BlockMessage for the block containing it, when
+// data-carrier messages are enabled (see chainfollower.WithDataCarrier).
+type DataCarrierMessage struct {
+	Message
+	BlockHash   string
+	BlockHeight int64
+	TxIndex     int      // position of the transaction in the block
+	TxID        string   // transaction id
+	VOut        int      // output index
+	Pushes      [][]byte // data pushed after OP_RETURN
+	Data        []byte   // all pushes concatenated
+}
+
+// BackfillBlockMessage is a block delivered by ChainFollower.Backfill.
+// Blocks arrive in height order within a shard; shards run concurrently.
+// Shard is the shard's progress including this block.
+type BackfillBlockMessage struct {
+	Message
+	Block *types.Block
+	Shard state.BackfillShard
+}
+
+// BackfillCompleteMessage is the last message of a backfill; the channel is
+// closed after it.
+type BackfillCompleteMessage struct {
+	Message
+	From         int64
+	To           int64
+	Blocks       int64                 // blocks delivered by this run
+	Transactions int64                 // transactions in those blocks (before any block filter)
+	Shards       []state.BackfillShard // final progress of each shard
+	Elapsed      time.Duration
+	Err          error // nil if every block in the range was delivered
+}
+
+// StallMessage is sent by the watchdog (see chainfollower.WithWatchdog) when
+// progress stops, and again with Active false when it resumes. Reason says
+// what stalled: "follower" (no block delivered while Core has newer ones),
+// "tip" (Core's tip block is too old) or "rpc" (Core is unreachable).
+type StallMessage struct {
+	Message
+	Active    bool
+	Reason    string
+	Height    int64         // height of the last block delivered (-1 if none)
+	TipHeight int64         // Core's block count (-1 if unreachable)
+	Since     time.Time     // when progress last happened
+	Duration  time.Duration // how long it has been stalled
+}
+
+// LagMessage is sent by the watchdog when the follower falls more than the
+// allowed number of blocks behind Core's header count, and again with Active
+// false when it catches up.
+type LagMessage struct {
+	Message
+	Active       bool
+	Height       int64 // height of the last block delivered (-1 if none)
+	TipHeight    int64 // Core's block count
+	HeaderHeight int64 // Core's header count (ahead of TipHeight while Core syncs)
+	Lag          int64 // blocks behind HeaderHeight, less the confirmation depth
+}
+
+// Position returns the position to resume from after a block or rollback
+// message (nil for other messages): a block's own position, waiting for the
+// next block, or a rollback's NewChainPos. Consumers that save their
+// position with each message should use it rather than
+// broker.Subscriber.Checkpoint, which moves just after the message has been
+// received.
+func Position(msg Message) *state.ChainPos {
+	switch m := msg.(type) {
+	case BlockMessage:
+		pos := &state.ChainPos{BlockHash: m.Block.Hash, BlockHeight: m.Block.Height, WaitingForNextHash: true}
+		if m.ChainPos != nil {
+			pos.ChainName = m.ChainPos.ChainName
+		}
+		return pos
+	case RollbackMessage:
+		if m.NewChainPos != nil {
+			pos := *m.NewChainPos
+			return &pos
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+const rateWindow = 30 * time.Second // window for the blocks-per-second gauge.
+
+var (
+	rpcLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	reorgDepthBuckets = []float64{1, 2, 3, 5, 10, 20, 50, 100}
+)
+
+// Metrics holds all ChainFollower and RpcTransport metrics.
+// A nil *Metrics is valid and records nothing.
+type Metrics struct {
+	Registry *Registry
+
+	CurrentHeight   *ValueVec     // height of the last block sent to the consumer.
+	TipHeight       *ValueVec     // height of the Core node's best block.
+	LagBlocks       *ValueVec     // TipHeight - CurrentHeight.
+	LagSeconds      *ValueVec     // wall-clock age of the last block sent.
+	BlocksPerSecond *ValueVec     // blocks sent per second (over rateWindow).
+	Blocks          *ValueVec     // total blocks sent.
+	Reorgs          *ValueVec     // total rollbacks sent.
+	ReorgDepth      *HistogramVec // number of blocks rolled back per reorg.
+	RpcLatency      *HistogramVec // RPC latency by method.
+	RpcErrors       *ValueVec     // RPC errors by method and code.
+	MessageBacklog  *ValueVec     // messages waiting in the Messages channel.
+
+	rateMu     sync.Mutex
+	rateStart  time.Time
+	rateBlocks int
+}
+
+func New() *Metrics {
+	r := NewRegistry()
+	return &Metrics{
+		Registry:        r,
+		CurrentHeight:   r.NewGauge("chainfollower_current_height", "Height of the last block delivered to the consumer."),
+		TipHeight:       r.NewGauge("chainfollower_tip_height", "Height of the best block on the Core node."),
+		LagBlocks:       r.NewGauge("chainfollower_lag_blocks", "Number of blocks between the follower and the chain tip."),
+		LagSeconds:      r.NewGauge("chainfollower_lag_seconds", "Age in seconds of the last block delivered (wall-clock minus block time)."),
+		BlocksPerSecond: r.NewGauge("chainfollower_blocks_per_second", "Blocks delivered per second."),
+		Blocks:          r.NewCounter("chainfollower_blocks_total", "Total number of blocks delivered."),
+		Reorgs:          r.NewCounter("chainfollower_reorgs_total", "Total number of chain reorganisations (rollbacks)."),
+		ReorgDepth:      r.NewHistogram("chainfollower_reorg_depth_blocks", "Number of blocks rolled back per reorganisation.", reorgDepthBuckets),
+		RpcLatency:      r.NewHistogram("chainfollower_rpc_latency_seconds", "Core RPC request latency in seconds.", rpcLatencyBuckets, "method"),
+		RpcErrors:       r.NewCounter("chainfollower_rpc_errors_total", "Core RPC errors by method and error code.", "method", "code"),
+		MessageBacklog:  r.NewGauge("chainfollower_message_backlog", "Messages waiting in the follower message channel."),
+	}
+}
+
+// Handler serves the metrics in Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return m.Registry.Handler()
+}
+
+// ObserveBlock records a block delivered to the consumer.
+// blockTime is the block header time (unix seconds) and tipHeight
+// the best known height on the Core node (or -1 if unknown).
+func (m *Metrics) ObserveBlock(height int64, blockTime int64, tipHeight int64) {
+	if m == nil {
+		return
+	}
+	now := time.Now()
+	m.Blocks.With().Inc()
+	m.CurrentHeight.With().Set(float64(height))
+	if blockTime > 0 {
+		m.LagSeconds.With().Set(now.Sub(time.Unix(blockTime, 0)).Seconds())
+	}
+	if tipHeight >= 0 {
+		m.ObserveTip(tipHeight)
+	}
+
+	m.rateMu.Lock()
+	defer m.rateMu.Unlock()
+	if m.rateStart.IsZero() {
+		m.rateStart = now
+	}
+	m.rateBlocks++
+	elapsed := now.Sub(m.rateStart)
+	if elapsed >= time.Second {
+		m.BlocksPerSecond.With().Set(float64(m.rateBlocks) / elapsed.Seconds())
+	}
+	if elapsed >= rateWindow {
+		m.rateStart = now
+		m.rateBlocks = 0
+	}
+}
+
+// ObserveTip records the best known height on the Core node.
+func (m *Metrics) ObserveTip(tipHeight int64) {
+	if m == nil {
+		return
+	}
+	m.TipHeight.With().Set(float64(tipHeight))
+	lag := float64(tipHeight) - m.CurrentHeight.With().Get()
+	if lag < 0 {
+		lag = 0
+	}
+	m.LagBlocks.With().Set(lag)
+}
+
+// ObserveReorg records a rollback of `depth` blocks.
+func (m *Metrics) ObserveReorg(depth int64) {
+	if m == nil {
+		return
+	}
+	m.Reorgs.With().Inc()
+	m.ReorgDepth.With().Observe(float64(depth))
+}
+
+// ObserveRpc records the latency and (optional) error code of an RPC request.
+// Use an empty code for successful requests.
+func (m *Metrics) ObserveRpc(method string, latency time.Duration, code string) {
+	if m == nil {
+		return
+	}
+	m.RpcLatency.With(method).Observe(latency.Seconds())
+	if code != "" {
+		m.RpcErrors.With(method, code).Inc()
+	}
+}
+
+// ObserveBacklog records the number of messages waiting in the channel.
+func (m *Metrics) ObserveBacklog(n int) {
+	if m == nil {
+		return
+	}
+	m.MessageBacklog.With().Set(float64(n))
+}
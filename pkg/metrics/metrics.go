@@ -0,0 +1,312 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Minimal Prometheus-compatible metrics registry (text exposition format 0.0.4).
+// We only need counters, gauges and histograms with a handful of labels,
+// which does not justify pulling in the full Prometheus client library.
+
+type metricType string
+
+const (
+	typeCounter   metricType = "counter"
+	typeGauge     metricType = "gauge"
+	typeHistogram metricType = "histogram"
+)
+
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+	names      map[string]bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: map[string]bool{}}
+}
+
+func (r *Registry) register(name string, c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic("metrics: duplicate metric name: " + name)
+	}
+	r.names[name] = true
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteText writes all registered metrics in Prometheus text format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler serves the registry in Prometheus text format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteText(w)
+	})
+}
+
+// desc is shared by all metric families.
+type desc struct {
+	name   string
+	help   string
+	typ    metricType
+	labels []string
+}
+
+func (d *desc) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.typ)
+}
+
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+func formatLabels(names []string, values []string, extra ...string) string {
+	if len(names) == 0 && len(extra) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	first := true
+	add := func(k, v string) {
+		if !first {
+			sb.WriteByte(',')
+		}
+		first = false
+		sb.WriteString(k)
+		sb.WriteString(`="`)
+		sb.WriteString(escapeLabel(v))
+		sb.WriteByte('"')
+	}
+	for i, n := range names {
+		add(n, values[i])
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		add(extra[i], extra[i+1])
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeHelp(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
+
+func escapeLabel(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
+
+// Value is a single counter or gauge series.
+type Value struct {
+	mu sync.Mutex
+	v  float64
+}
+
+func (v *Value) Set(x float64) {
+	v.mu.Lock()
+	v.v = x
+	v.mu.Unlock()
+}
+
+func (v *Value) Add(x float64) {
+	v.mu.Lock()
+	v.v += x
+	v.mu.Unlock()
+}
+
+func (v *Value) Inc() { v.Add(1) }
+
+func (v *Value) Get() float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.v
+}
+
+// ValueVec is a counter or gauge family, optionally partitioned by labels.
+type ValueVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*Value
+	values map[string][]string
+}
+
+func (r *Registry) newValueVec(typ metricType, name, help string, labels []string) *ValueVec {
+	v := &ValueVec{
+		desc:   desc{name: name, help: help, typ: typ, labels: labels},
+		series: map[string]*Value{},
+		values: map[string][]string{},
+	}
+	r.register(name, v)
+	return v
+}
+
+// NewCounter registers a counter family. Counters must only be increased.
+func (r *Registry) NewCounter(name, help string, labels ...string) *ValueVec {
+	return r.newValueVec(typeCounter, name, help, labels)
+}
+
+// NewGauge registers a gauge family.
+func (r *Registry) NewGauge(name, help string, labels ...string) *ValueVec {
+	return r.newValueVec(typeGauge, name, help, labels)
+}
+
+// With returns the series for the given label values (in label order).
+func (v *ValueVec) With(values ...string) *Value {
+	key := v.key(values)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.series[key]
+	if !ok {
+		s = &Value{}
+		v.series[key] = s
+		v.values[key] = append([]string(nil), values...)
+	}
+	return s
+}
+
+func (v *ValueVec) write(w *bufio.Writer) {
+	v.writeHeader(w)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if len(v.labels) == 0 && len(v.series) == 0 {
+		fmt.Fprintf(w, "%s 0\n", v.name)
+		return
+	}
+	for _, key := range sortedKeys(v.series) {
+		fmt.Fprintf(w, "%s%s %s\n", v.name, formatLabels(v.labels, v.values[key]), formatFloat(v.series[key].Get()))
+	}
+}
+
+// Histogram is a single histogram series.
+type Histogram struct {
+	mu      sync.Mutex
+	bounds  []float64
+	buckets []uint64 // cumulative counts are computed on write.
+	count   uint64
+	sum     float64
+}
+
+func (h *Histogram) Observe(x float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	i := sort.SearchFloat64s(h.bounds, x)
+	if i < len(h.buckets) {
+		h.buckets[i]++
+	}
+	h.count++
+	h.sum += x
+}
+
+// HistogramVec is a histogram family, optionally partitioned by labels.
+type HistogramVec struct {
+	desc
+	bounds []float64
+	mu     sync.Mutex
+	series map[string]*Histogram
+	values map[string][]string
+}
+
+// NewHistogram registers a histogram family with the given upper bucket bounds.
+func (r *Registry) NewHistogram(name, help string, bounds []float64, labels ...string) *HistogramVec {
+	b := append([]float64(nil), bounds...)
+	sort.Float64s(b)
+	h := &HistogramVec{
+		desc:   desc{name: name, help: help, typ: typeHistogram, labels: labels},
+		bounds: b,
+		series: map[string]*Histogram{},
+		values: map[string][]string{},
+	}
+	r.register(name, h)
+	return h
+}
+
+// With returns the series for the given label values (in label order).
+func (h *HistogramVec) With(values ...string) *Histogram {
+	key := h.key(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &Histogram{bounds: h.bounds, buckets: make([]uint64, len(h.bounds))}
+		h.series[key] = s
+		h.values[key] = append([]string(nil), values...)
+	}
+	return s
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.writeHeader(w)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.labels) == 0 && len(h.series) == 0 {
+		// always expose an unlabelled histogram, even before the first observation.
+		h.series[""] = &Histogram{bounds: h.bounds, buckets: make([]uint64, len(h.bounds))}
+		h.values[""] = nil
+	}
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		values := h.values[key]
+		s.mu.Lock()
+		var cum uint64
+		for i, b := range h.bounds {
+			cum += s.buckets[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, values, "le", formatFloat(b)), cum)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, values, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, values), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, values), s.count)
+		s.mu.Unlock()
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
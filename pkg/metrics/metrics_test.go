@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteText(t *testing.T) {
+	m := New()
+	m.ObserveBlock(100, 0, 105)
+	m.ObserveReorg(2)
+	m.ObserveRpc("getblock", 20*time.Millisecond, "")
+	m.ObserveRpc("getblock", 30*time.Millisecond, "-5")
+
+	var buf bytes.Buffer
+	if err := m.Registry.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	expected := []string{
+		"# TYPE chainfollower_current_height gauge",
+		"chainfollower_current_height 100",
+		"chainfollower_tip_height 105",
+		"chainfollower_lag_blocks 5",
+		"chainfollower_reorgs_total 1",
+		`chainfollower_reorg_depth_blocks_bucket{le="2"} 1`,
+		`chainfollower_reorg_depth_blocks_bucket{le="+Inf"} 1`,
+		`chainfollower_rpc_latency_seconds_bucket{method="getblock",le="0.025"} 1`,
+		`chainfollower_rpc_latency_seconds_count{method="getblock"} 2`,
+		`chainfollower_rpc_errors_total{method="getblock",code="-5"} 1`,
+		"chainfollower_message_backlog 0",
+	}
+	for _, line := range expected {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("missing line %q in output:\n%s", line, out)
+		}
+	}
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	m.ObserveBlock(1, 0, 1)
+	m.ObserveReorg(1)
+	m.ObserveRpc("getblock", time.Millisecond, "transport")
+	m.ObserveBacklog(1)
+}
@@ -0,0 +1,300 @@
+package rpc
+
+import (
+	"container/list"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/dogecoinfoundation/chainfollower/internal/doge"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+)
+
+// A caching RpcTransportInterface decorator: blocks are kept by hash in a
+// bounded in-memory LRU and, optionally, as JSON files in a directory, so
+// reorgs, restarts and replays do not fetch them from Core again.
+//
+// A block's content never changes, but its Confirmations and NextBlockHash
+// do (a reorg sets Confirmations to -1). A block is treated as final once it
+// is FinalDepth blocks deep: its header is then answered from the cache, with
+// Confirmations recomputed from the highest tip seen. Headers of shallower
+// blocks always come from Core, and cached blocks are returned with
+// Confirmations and NextBlockHash from a fresh header.
+
+const DEFAULT_CACHE_SIZE = 100  // blocks kept in memory.
+const DEFAULT_FINAL_DEPTH = 100 // confirmations after which a block cannot be reorganised.
+
+var ErrNoRawBlocks = errors.New("rpc: transport cannot return raw blocks")
+
+type CachingTransport struct {
+	RpcTransportInterface              // the wrapped transport
+	Dir                   string       // optional: also keep blocks in <Dir>/<hash>.json
+	FinalDepth            int64        // see above
+	Logger                *slog.Logger // diagnostics (silent by default).
+
+	mu      sync.Mutex
+	size    int
+	lru     *list.List // of *cacheEntry, most recently used first
+	entries map[string]*list.Element
+	tip     int64 // highest block height seen
+	hits    int64
+	misses  int64
+}
+
+type cacheEntry struct {
+	hash   string
+	block  *types.Block       // nil if only the header is cached
+	header *types.BlockHeader // set once final
+	hex    string             // raw block, if fetched
+}
+
+// NewCachingTransport wraps a transport with a cache of size blocks
+// (DEFAULT_CACHE_SIZE if size <= 0), and a block store in dir ("" = none).
+func NewCachingTransport(inner RpcTransportInterface, size int, dir string) *CachingTransport {
+	if size <= 0 {
+		size = DEFAULT_CACHE_SIZE
+	}
+	return &CachingTransport{
+		RpcTransportInterface: inner,
+		Dir:                   dir,
+		FinalDepth:            DEFAULT_FINAL_DEPTH,
+		Logger:                slog.New(slog.DiscardHandler),
+		size:                  size,
+		lru:                   list.New(),
+		entries:               map[string]*list.Element{},
+	}
+}
+
+// Stats returns the number of requests answered from the cache, and the
+// number passed on to the wrapped transport.
+func (t *CachingTransport) Stats() (hits, misses int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.hits, t.misses
+}
+
+func (t *CachingTransport) GetBlock(hash string) (*types.Block, error) {
+	cached, final := t.cachedBlock(hash)
+	if cached == nil {
+		if block := t.load(hash); block != nil {
+			t.putBlock(block)
+			cached, final = t.cachedBlock(hash)
+		}
+	}
+	if cached != nil {
+		if final == nil { // not final: refresh the fields that change.
+			header, err := t.GetBlockHeader(hash)
+			if err != nil {
+				return nil, err
+			}
+			final = header
+		}
+		block := *cached
+		block.Confirmations, block.NextBlockHash = final.Confirmations, final.NextBlockHash
+		return &block, nil
+	}
+
+	t.mu.Lock()
+	t.misses++
+	t.mu.Unlock()
+	block, err := t.RpcTransportInterface.GetBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	t.seen(block.Height, block.Confirmations)
+	t.store(block)
+	t.putBlock(block)
+	return block, nil
+}
+
+// cachedBlock returns a cached block and, if final, its current header.
+func (t *CachingTransport) cachedBlock(hash string) (*types.Block, *types.BlockHeader) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e := t.get(hash)
+	if e == nil || e.block == nil {
+		return nil, nil
+	}
+	t.hits++
+	if e.header == nil {
+		return e.block, nil
+	}
+	header := *e.header
+	header.Confirmations = t.confirmations(header.Height)
+	return e.block, &header
+}
+
+func (t *CachingTransport) putBlock(block *types.Block) {
+	t.put(block.Hash, func(e *cacheEntry) {
+		e.block = block
+		if e.header == nil && t.isFinal(block.Confirmations, block.NextBlockHash) {
+			e.header = doge.HeaderFromBlock(block)
+		}
+	})
+}
+
+func (t *CachingTransport) GetBlockHeader(hash string) (*types.BlockHeader, error) {
+	t.mu.Lock()
+	if e := t.get(hash); e != nil && e.header != nil {
+		t.hits++
+		header := *e.header
+		header.Confirmations = t.confirmations(header.Height)
+		t.mu.Unlock()
+		return &header, nil
+	}
+	t.misses++
+	t.mu.Unlock()
+
+	header, err := t.RpcTransportInterface.GetBlockHeader(hash)
+	if err != nil {
+		return nil, err
+	}
+	t.seen(header.Height, header.Confirmations)
+	if t.isFinal(header.Confirmations, header.NextBlockHash) {
+		final := *header
+		t.put(hash, func(e *cacheEntry) { e.header = &final })
+	}
+	return header, nil
+}
+
+// GetBlockHex returns the serialized block, if the wrapped transport can.
+func (t *CachingTransport) GetBlockHex(hash string) (string, error) {
+	source, ok := t.RpcTransportInterface.(RawBlockTransport)
+	if !ok {
+		return "", ErrNoRawBlocks
+	}
+	t.mu.Lock()
+	if e := t.get(hash); e != nil && e.hex != "" {
+		t.hits++
+		t.mu.Unlock()
+		return e.hex, nil
+	}
+	t.misses++
+	t.mu.Unlock()
+	raw, err := source.GetBlockHex(hash)
+	if err != nil {
+		return "", err
+	}
+	t.put(hash, func(e *cacheEntry) { e.hex = raw })
+	return raw, nil
+}
+
+func (t *CachingTransport) GetBlockCount() (int64, error) {
+	count, err := t.RpcTransportInterface.GetBlockCount()
+	if err == nil {
+		t.seen(count, 1)
+	}
+	return count, err
+}
+
+func (t *CachingTransport) GetBlockchainInfo() (*types.BlockchainInfo, error) {
+	info, err := t.RpcTransportInterface.GetBlockchainInfo()
+	if err == nil {
+		t.seen(info.Blocks, 1)
+	}
+	return info, err
+}
+
+func (t *CachingTransport) isFinal(confirmations int64, nextHash string) bool {
+	return confirmations >= t.FinalDepth && nextHash != ""
+}
+
+// seen records the tip implied by a block's confirmations.
+func (t *CachingTransport) seen(height, confirmations int64) {
+	if confirmations <= 0 {
+		return
+	}
+	t.mu.Lock()
+	t.tip = max(t.tip, height+confirmations-1)
+	t.mu.Unlock()
+}
+
+// confirmations of a final block at height (with t.mu held).
+func (t *CachingTransport) confirmations(height int64) int64 {
+	return max(t.tip-height+1, t.FinalDepth)
+}
+
+// get returns a cached entry and marks it recently used (with t.mu held).
+func (t *CachingTransport) get(hash string) *cacheEntry {
+	el, ok := t.entries[hash]
+	if !ok {
+		return nil
+	}
+	t.lru.MoveToFront(el)
+	return el.Value.(*cacheEntry)
+}
+
+// put updates (or adds) an entry, evicting the least recently used.
+func (t *CachingTransport) put(hash string, update func(e *cacheEntry)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e := t.get(hash)
+	if e == nil {
+		e = &cacheEntry{hash: hash}
+		t.entries[hash] = t.lru.PushFront(e)
+		for t.lru.Len() > t.size {
+			oldest := t.lru.Back()
+			t.lru.Remove(oldest)
+			delete(t.entries, oldest.Value.(*cacheEntry).hash)
+		}
+	}
+	update(e)
+}
+
+// path returns the block file for a hash ("" without a Dir, or for a
+// malformed hash).
+func (t *CachingTransport) path(hash string) string {
+	if b, err := hex.DecodeString(hash); t.Dir == "" || err != nil || len(b) != 32 {
+		return ""
+	}
+	return filepath.Join(t.Dir, hash+".json")
+}
+
+// load reads a block from the block store.
+func (t *CachingTransport) load(hash string) *types.Block {
+	path := t.path(hash)
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			t.Logger.Warn("rpc: reading cached block", "path", path, "error", err)
+		}
+		return nil
+	}
+	var block types.Block
+	if err := json.Unmarshal(data, &block); err != nil || block.Hash != hash {
+		t.Logger.Warn("rpc: discarding corrupt cached block", "path", path, "error", err)
+		os.Remove(path)
+		return nil
+	}
+	// the saved confirmations are a lower bound for the tip.
+	t.seen(block.Height, block.Confirmations)
+	return &block
+}
+
+// store writes a block to the block store.
+func (t *CachingTransport) store(block *types.Block) {
+	path := t.path(block.Hash)
+	if path == "" {
+		return
+	}
+	data, err := json.Marshal(block)
+	if err == nil {
+		err = os.MkdirAll(t.Dir, 0o755)
+	}
+	if err == nil {
+		tmp := path + ".tmp"
+		if err = os.WriteFile(tmp, data, 0o644); err == nil {
+			err = os.Rename(tmp, path)
+		}
+	}
+	if err != nil {
+		t.Logger.Warn("rpc: caching block", "path", path, "error", err)
+	}
+}
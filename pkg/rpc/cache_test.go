@@ -0,0 +1,98 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+)
+
+// countingTransport counts the block and header requests reaching Core.
+type countingTransport struct {
+	*TestRpcTransport
+	blocks, headers int
+}
+
+func (c *countingTransport) GetBlock(hash string) (*types.Block, error) {
+	c.blocks++
+	return c.TestRpcTransport.GetBlock(hash)
+}
+
+func (c *countingTransport) GetBlockHeader(hash string) (*types.BlockHeader, error) {
+	c.headers++
+	return c.TestRpcTransport.GetBlockHeader(hash)
+}
+
+// testChain returns a counting transport with blocks 0..tip.
+func testChain(tip int64) *countingTransport {
+	return &countingTransport{TestRpcTransport: NewTestChain(tip + 1)}
+}
+
+func TestCachingTransport(t *testing.T) {
+	inner := testChain(199)
+	cache := NewCachingTransport(inner, 2, "")
+
+	// deep blocks are final: served from the cache, headers included.
+	for range 2 {
+		b, err := cache.GetBlock(TestBlockHash(10))
+		if err != nil || b.Confirmations != 190 || b.NextBlockHash != TestBlockHash(11) {
+			t.Fatalf("block 10: %+v, %v", b, err)
+		}
+		if h, err := cache.GetBlockHeader(TestBlockHash(10)); err != nil || h.Confirmations != 190 {
+			t.Fatalf("header 10: %+v, %v", h, err)
+		}
+	}
+	if inner.blocks != 1 || inner.headers != 0 {
+		t.Errorf("fetched %d blocks and %d headers from Core", inner.blocks, inner.headers)
+	}
+
+	// confirmations follow the tip.
+	cache.GetBlockCount()
+	inner.SetBlockCount(250)
+	cache.GetBlockCount()
+	if h, _ := cache.GetBlockHeader(TestBlockHash(10)); h.Confirmations != 241 {
+		t.Errorf("confirmations after new blocks: %d", h.Confirmations)
+	}
+
+	// shallow blocks are cached, but their headers come from Core: a reorg
+	// is seen.
+	inner.blocks, inner.headers = 0, 0
+	cache.GetBlock(TestBlockHash(195))
+	inner.TestRpcTransport.headers[195].Confirmations, inner.TestRpcTransport.headers[195].NextBlockHash = -1, ""
+	b, err := cache.GetBlock(TestBlockHash(195))
+	if err != nil || b.Confirmations != -1 || b.NextBlockHash != "" || len(b.Tx) != 1 {
+		t.Errorf("orphaned block 195: %+v, %v", b, err)
+	}
+	if inner.blocks != 1 || inner.headers != 1 {
+		t.Errorf("fetched %d blocks and %d headers from Core", inner.blocks, inner.headers)
+	}
+
+	// the LRU holds 2 blocks.
+	inner.blocks = 0
+	cache.GetBlock(TestBlockHash(20))
+	cache.GetBlock(TestBlockHash(10))
+	if inner.blocks != 2 {
+		t.Errorf("block 10 should have been evicted (fetched %d blocks)", inner.blocks)
+	}
+	if hits, misses := cache.Stats(); hits == 0 || misses == 0 {
+		t.Errorf("stats: %d hits, %d misses", hits, misses)
+	}
+}
+
+func TestCachingTransportDisk(t *testing.T) {
+	dir := t.TempDir()
+	inner := testChain(199)
+	if _, err := NewCachingTransport(inner, 0, dir).GetBlock(TestBlockHash(10)); err != nil {
+		t.Fatal(err)
+	}
+
+	// after a restart, the block comes from disk.
+	inner.blocks = 0
+	cache := NewCachingTransport(inner, 0, dir)
+	b, err := cache.GetBlock(TestBlockHash(10))
+	if err != nil || b.Hash != TestBlockHash(10) || b.Confirmations != 190 || inner.blocks != 0 {
+		t.Errorf("block from disk: %+v, %v (fetched %d blocks)", b, err, inner.blocks)
+	}
+	if _, err := cache.GetBlockHex(TestBlockHash(10)); err != ErrNoRawBlocks {
+		t.Errorf("expected ErrNoRawBlocks, got %v", err)
+	}
+}
@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/rpc"
+	"strconv"
 	"sync/atomic"
+	"time"
 
 	"github.com/dogecoinfoundation/chainfollower/pkg/config"
+	"github.com/dogecoinfoundation/chainfollower/pkg/metrics"
 	"github.com/dogecoinfoundation/chainfollower/pkg/types"
 )
 
@@ -30,10 +34,12 @@ type RpcTransport struct {
 	RpcClient *rpc.Client
 	config    *config.Config
 	Id        atomic.Uint64
+	Metrics   *metrics.Metrics // optional: RPC latency and error metrics.
+	Logger    *slog.Logger     // diagnostics (silent by default).
 }
 
 func NewRpcTransport(config *config.Config) *RpcTransport {
-	return &RpcTransport{config: config}
+	return &RpcTransport{config: config, Logger: slog.New(slog.DiscardHandler)}
 }
 
 func (t *RpcTransport) GetBlock(hash string) (*types.Block, error) {
@@ -51,6 +57,22 @@ func (t *RpcTransport) GetBlock(hash string) (*types.Block, error) {
 	return result, nil
 }
 
+// GetBlockHex returns the serialized block (including AuxPoW) as hex.
+func (t *RpcTransport) GetBlockHex(hash string) (string, error) {
+	res, err := t.Request("getblock", []any{hash, 0})
+	if err != nil {
+		return "", err
+	}
+
+	var result string
+	err = json.Unmarshal(*res, &result)
+	if err != nil {
+		return "", fmt.Errorf("json-rpc unmarshal error: %v | %v", err, string(*res))
+	}
+
+	return result, nil
+}
+
 func (t *RpcTransport) GetBlockHash(height int64) (string, error) {
 	res, err := t.Request("getblockhash", []any{height})
 	if err != nil {
@@ -125,7 +147,22 @@ func (t *RpcTransport) GetBlockchainInfo() (*types.BlockchainInfo, error) {
 	return result, nil
 }
 
-func (t *RpcTransport) Request(method string, params []any) (*json.RawMessage, error) {
+func (t *RpcTransport) Request(method string, params []any) (result *json.RawMessage, err error) {
+	start := time.Now()
+	code := ""
+	defer func() {
+		if err != nil && code == "" {
+			code = "transport"
+		}
+		latency := time.Since(start)
+		t.Metrics.ObserveRpc(method, latency, code)
+		if err != nil {
+			t.Logger.Warn("RpcTransport: request failed", "method", method, "latency", latency, "code", code, "error", err)
+		} else {
+			t.Logger.Debug("RpcTransport: request", "method", method, "latency", latency)
+		}
+	}()
+
 	id := t.Id.Add(1)
 
 	body := rpcRequest{
@@ -159,6 +196,7 @@ func (t *RpcTransport) Request(method string, params []any) (*json.RawMessage, e
 	}
 	// check for error response
 	if res.StatusCode != 200 {
+		code = rpcErrorCode(res_bytes, res.StatusCode)
 		return nil, fmt.Errorf("json-rpc error status: %v | %v", res.StatusCode, string(res_bytes))
 	}
 	// cannot use json.NewDecoder: "The decoder introduces its own buffering
@@ -166,12 +204,15 @@ func (t *RpcTransport) Request(method string, params []any) (*json.RawMessage, e
 	var rpcres rpcResponse
 	err = json.Unmarshal(res_bytes, &rpcres)
 	if err != nil {
+		code = "invalid_response"
 		return nil, fmt.Errorf("json-rpc unmarshal response: %v | %v", err, string(res_bytes))
 	}
 	if rpcres.Id != body.Id {
+		code = "invalid_response"
 		return nil, fmt.Errorf("json-rpc wrong ID returned: %v vs %v", rpcres.Id, body.Id)
 	}
 	if rpcres.Error != nil {
+		code = coreErrorCode(rpcres.Error)
 		enc, err := json.Marshal(rpcres.Error)
 		if err == nil {
 			return nil, fmt.Errorf("json-rpc: error from Core Node: %v", string(enc))
@@ -180,8 +221,29 @@ func (t *RpcTransport) Request(method string, params []any) (*json.RawMessage, e
 		}
 	}
 	if rpcres.Result == nil {
+		code = "invalid_response"
 		return nil, fmt.Errorf("json-rpc no result or error was returned")
 	}
 
 	return rpcres.Result, nil
 }
+
+// coreErrorCode extracts the numeric error code from a Core JSON-RPC error object.
+func coreErrorCode(rpcErr any) string {
+	if obj, ok := rpcErr.(map[string]any); ok {
+		if c, ok := obj["code"].(float64); ok {
+			return strconv.FormatInt(int64(c), 10)
+		}
+	}
+	return "unknown"
+}
+
+// rpcErrorCode prefers the Core error code in the body of a non-200 response
+// (Core returns HTTP 404/500 along with a JSON-RPC error) over the HTTP status.
+func rpcErrorCode(body []byte, status int) string {
+	var rpcres rpcResponse
+	if json.Unmarshal(body, &rpcres) == nil && rpcres.Error != nil {
+		return coreErrorCode(rpcres.Error)
+	}
+	return "http_" + strconv.Itoa(status)
+}
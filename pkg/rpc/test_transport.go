@@ -3,9 +3,15 @@ package rpc
 import (
 	"fmt"
 
+	"github.com/dogecoinfoundation/chainfollower/internal/doge"
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/state"
 	"github.com/dogecoinfoundation/chainfollower/pkg/types"
 )
 
+// MainNetGenesisHash is the hash of Dogecoin mainnet block #0.
+const MainNetGenesisHash = "1a91e3dace36e2be3bf030a65679fe821aa1d6ef92e7c9902eb318182c355691"
+
 type TestRpcTransport struct {
 	RpcTransportInterface
 	blocks         []*types.Block
@@ -79,3 +85,69 @@ func NewTestRpcTransport() *TestRpcTransport {
 		blockChainInfo: &types.BlockchainInfo{},
 	}
 }
+
+// TestBlockHash is the hash of the fake block at height in NewTestChain
+// (mainnet genesis at height 0).
+func TestBlockHash(height int64) string {
+	return TestForkBlockHash(0, height)
+}
+
+// TestForkBlockHash is the hash of the fake block at height on branch fork;
+// fork 0 is the NewTestChain chain and other forks compete with it.
+func TestForkBlockHash(fork, height int64) string {
+	if fork == 0 && height == 0 {
+		return MainNetGenesisHash
+	}
+	return fmt.Sprintf("%02x%062x", fork, height)
+}
+
+// testBlock is the fake block at height on branch fork, with one
+// transaction (its txid is the block hash) and linked to its parent.
+func testBlock(fork, height int64) *types.Block {
+	b := &types.Block{Hash: TestForkBlockHash(fork, height), Height: height, Tx: []types.RawTxn{{TxID: TestForkBlockHash(fork, height)}}}
+	if height > 0 {
+		b.PreviousBlockHash = TestForkBlockHash(fork, height-1)
+	}
+	return b
+}
+
+// TestBlockMessage returns the follower's message for the block at height
+// in NewTestChain, with the position to resume after it.
+func TestBlockMessage(height int64) messages.BlockMessage {
+	return TestForkBlockMessage(0, height)
+}
+
+// TestForkBlockMessage is TestBlockMessage for the block at height on
+// branch fork (see TestForkBlockHash).
+func TestForkBlockMessage(fork, height int64) messages.BlockMessage {
+	b := testBlock(fork, height)
+	return messages.BlockMessage{
+		Block:    b,
+		ChainPos: &state.ChainPos{BlockHash: b.Hash, BlockHeight: height, WaitingForNextHash: true, ChainName: doge.DogeMainNetChain.ChainName},
+	}
+}
+
+// TestRollbackMessage returns the follower's message for a rollback from
+// block `from` to block `to` of NewTestChain.
+func TestRollbackMessage(from, to int64) messages.RollbackMessage {
+	return messages.RollbackMessage{OldChainPos: TestBlockMessage(from).ChainPos, NewChainPos: TestBlockMessage(to).ChainPos}
+}
+
+// NewTestChain returns a transport serving a mainnet chain of n blocks:
+// genesis followed by fake blocks, each with one transaction and linked to
+// its neighbours, with confirmations counted from the last block (the tip).
+func NewTestChain(n int64) *TestRpcTransport {
+	t := NewTestRpcTransport()
+	for h := int64(0); h < n; h++ {
+		b := testBlock(0, h)
+		b.Confirmations = n - h
+		if h < n-1 {
+			b.NextBlockHash = TestBlockHash(h + 1)
+		}
+		t.AddBlockAndHeader(b, doge.HeaderFromBlock(b))
+	}
+	t.SetBlockchainInfo(&types.BlockchainInfo{Chain: "main", Blocks: n - 1, Headers: n - 1, BestBlockHash: TestBlockHash(n - 1)})
+	t.SetBlockCount(n - 1)
+	t.SetBestBlockHash(TestBlockHash(n - 1))
+	return t
+}
@@ -10,3 +10,15 @@ type RpcTransportInterface interface {
 	GetBlockchainInfo() (*types.BlockchainInfo, error)
 	GetBlockHash(height int64) (string, error)
 }
+
+// RawBlockTransport is implemented by transports that can return serialized
+// blocks (hex), as needed to verify merged-mining (AuxPoW) proofs.
+type RawBlockTransport interface {
+	GetBlockHex(hash string) (string, error)
+}
+
+// RangeTransport is implemented by transports that serve only a height range
+// of the chain (plus the genesis hash), such as a block archive.
+type RangeTransport interface {
+	Range() (from, to int64)
+}
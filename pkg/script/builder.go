@@ -0,0 +1,76 @@
+package script
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// MaxMultiSigKeys is the most public keys a standard bare or P2SH multisig
+// script may have (Core's IsStandard; P2SH redeem scripts are limited to
+// 520 bytes, which 15 compressed keys fit).
+const MaxMultiSigKeys = 15
+
+// PushData appends a push of data to the script, using the same encoding
+// as Core's CScript << vector.
+func PushData(script []byte, data []byte) []byte {
+	n := len(data)
+	switch {
+	case n < int(OP_PUSHDATA1):
+		script = append(script, byte(n))
+	case n <= 0xff:
+		script = append(script, byte(OP_PUSHDATA1), byte(n))
+	case n <= 0xffff:
+		script = binary.LittleEndian.AppendUint16(append(script, byte(OP_PUSHDATA2)), uint16(n))
+	default:
+		script = binary.LittleEndian.AppendUint32(append(script, byte(OP_PUSHDATA4)), uint32(n))
+	}
+	return append(script, data...)
+}
+
+// SmallIntOpcode returns OP_0..OP_16 for n in 0..16.
+func SmallIntOpcode(n int) (Opcode, error) {
+	if n < 0 || n > 16 {
+		return 0, errors.New("script: small integer out of range")
+	}
+	if n == 0 {
+		return OP_0, nil
+	}
+	return OP_1 + Opcode(n-1), nil
+}
+
+// PayToPubKeyHash returns OP_DUP OP_HASH160 <hash> OP_EQUALVERIFY OP_CHECKSIG.
+func PayToPubKeyHash(pubKeyHash []byte) ([]byte, error) {
+	if len(pubKeyHash) != 20 {
+		return nil, errors.New("script: public key hash must be 20 bytes")
+	}
+	s := []byte{byte(OP_DUP), byte(OP_HASH160)}
+	s = PushData(s, pubKeyHash)
+	return append(s, byte(OP_EQUALVERIFY), byte(OP_CHECKSIG)), nil
+}
+
+// PayToScriptHash returns OP_HASH160 <hash> OP_EQUAL.
+func PayToScriptHash(scriptHash []byte) ([]byte, error) {
+	if len(scriptHash) != 20 {
+		return nil, errors.New("script: script hash must be 20 bytes")
+	}
+	s := PushData([]byte{byte(OP_HASH160)}, scriptHash)
+	return append(s, byte(OP_EQUAL)), nil
+}
+
+// MultiSigScript returns OP_m <pubkey>... OP_n OP_CHECKMULTISIG, the redeem
+// script of an m-of-n P2SH multisig address. Signatures must be supplied
+// in the same order as the keys.
+func MultiSigScript(m int, pubKeys [][]byte) ([]byte, error) {
+	n := len(pubKeys)
+	if m < 1 || m > n || n > MaxMultiSigKeys {
+		return nil, errors.New("script: multisig requires 1 <= m <= n <= 15")
+	}
+	s := []byte{byte(OP_1) + byte(m-1)}
+	for _, pub := range pubKeys {
+		if !isPubKey(pub) {
+			return nil, errors.New("script: invalid public key in multisig")
+		}
+		s = PushData(s, pub)
+	}
+	return append(s, byte(OP_1)+byte(n-1), byte(OP_CHECKMULTISIG)), nil
+}
@@ -0,0 +1,90 @@
+package script
+
+import "fmt"
+
+type Opcode byte
+
+// Opcodes used by standard scripts (see Dogecoin Core src/script/script.h).
+const (
+	OP_0                   Opcode = 0x00
+	OP_PUSHDATA1           Opcode = 0x4c
+	OP_PUSHDATA2           Opcode = 0x4d
+	OP_PUSHDATA4           Opcode = 0x4e
+	OP_1NEGATE             Opcode = 0x4f
+	OP_1                   Opcode = 0x51
+	OP_16                  Opcode = 0x60
+	OP_NOP                 Opcode = 0x61
+	OP_IF                  Opcode = 0x63
+	OP_NOTIF               Opcode = 0x64
+	OP_ELSE                Opcode = 0x67
+	OP_ENDIF               Opcode = 0x68
+	OP_VERIFY              Opcode = 0x69
+	OP_RETURN              Opcode = 0x6a
+	OP_DROP                Opcode = 0x75
+	OP_DUP                 Opcode = 0x76
+	OP_EQUAL               Opcode = 0x87
+	OP_EQUALVERIFY         Opcode = 0x88
+	OP_SHA256              Opcode = 0xa8
+	OP_HASH160             Opcode = 0xa9
+	OP_HASH256             Opcode = 0xaa
+	OP_CHECKSIG            Opcode = 0xac
+	OP_CHECKSIGVERIFY      Opcode = 0xad
+	OP_CHECKMULTISIG       Opcode = 0xae
+	OP_CHECKMULTISIGVERIFY Opcode = 0xaf
+	OP_CHECKLOCKTIMEVERIFY Opcode = 0xb1
+	OP_CHECKSEQUENCEVERIFY Opcode = 0xb2
+)
+
+var opcodeNames = map[Opcode]string{
+	OP_0:                   "0",
+	OP_PUSHDATA1:           "OP_PUSHDATA1",
+	OP_PUSHDATA2:           "OP_PUSHDATA2",
+	OP_PUSHDATA4:           "OP_PUSHDATA4",
+	OP_1NEGATE:             "-1",
+	OP_NOP:                 "OP_NOP",
+	OP_IF:                  "OP_IF",
+	OP_NOTIF:               "OP_NOTIF",
+	OP_ELSE:                "OP_ELSE",
+	OP_ENDIF:               "OP_ENDIF",
+	OP_VERIFY:              "OP_VERIFY",
+	OP_RETURN:              "OP_RETURN",
+	OP_DROP:                "OP_DROP",
+	OP_DUP:                 "OP_DUP",
+	OP_EQUAL:               "OP_EQUAL",
+	OP_EQUALVERIFY:         "OP_EQUALVERIFY",
+	OP_SHA256:              "OP_SHA256",
+	OP_HASH160:             "OP_HASH160",
+	OP_HASH256:             "OP_HASH256",
+	OP_CHECKSIG:            "OP_CHECKSIG",
+	OP_CHECKSIGVERIFY:      "OP_CHECKSIGVERIFY",
+	OP_CHECKMULTISIG:       "OP_CHECKMULTISIG",
+	OP_CHECKMULTISIGVERIFY: "OP_CHECKMULTISIGVERIFY",
+	OP_CHECKLOCKTIMEVERIFY: "OP_CHECKLOCKTIMEVERIFY",
+	OP_CHECKSEQUENCEVERIFY: "OP_CHECKSEQUENCEVERIFY",
+}
+
+func (op Opcode) String() string {
+	if name, ok := opcodeNames[op]; ok {
+		return name
+	}
+	if op >= OP_1 && op <= OP_16 {
+		return fmt.Sprint(int(op - OP_1 + 1))
+	}
+	return fmt.Sprintf("OP_UNKNOWN(%#02x)", byte(op))
+}
+
+// IsPush reports whether the opcode pushes data (including OP_0..OP_16).
+func (op Opcode) IsPush() bool {
+	return op <= OP_16 && op != 0x50 // 0x50 is OP_RESERVED
+}
+
+// SmallInt returns the value of OP_0..OP_16, or -1.
+func (op Opcode) SmallInt() int {
+	if op == OP_0 {
+		return 0
+	}
+	if op >= OP_1 && op <= OP_16 {
+		return int(op - OP_1 + 1)
+	}
+	return -1
+}
@@ -0,0 +1,222 @@
+package script
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+)
+
+var ErrTruncated = errors.New("script: push extends past end of script")
+
+type ScriptType int
+
+// Standard script types, as classified by Dogecoin Core's Solver.
+const (
+	NonStandard ScriptType = iota
+	P2PK                   // <pubkey> OP_CHECKSIG
+	P2PKH                  // OP_DUP OP_HASH160 <20> OP_EQUALVERIFY OP_CHECKSIG
+	P2SH                   // OP_HASH160 <20> OP_EQUAL
+	MultiSig               // OP_m <pubkey>... OP_n OP_CHECKMULTISIG
+	NullData               // OP_RETURN <pushdata>...
+)
+
+var scriptTypeNames = map[ScriptType]string{
+	NonStandard: "nonstandard",
+	P2PK:        "pubkey",
+	P2PKH:       "pubkeyhash",
+	P2SH:        "scripthash",
+	MultiSig:    "multisig",
+	NullData:    "nulldata",
+}
+
+// String returns Core's name for the script type.
+func (t ScriptType) String() string {
+	return scriptTypeNames[t]
+}
+
+// DecodeCoreRPCScriptType maps Core's RawTxnScriptPubKey.Type onto ScriptType.
+// Unknown names (including witness types, which Dogecoin does not use) are NonStandard.
+func DecodeCoreRPCScriptType(coreType string) ScriptType {
+	for t, name := range scriptTypeNames {
+		if name == coreType {
+			return t
+		}
+	}
+	return NonStandard
+}
+
+// Instruction is a parsed opcode with its pushed data (if any).
+type Instruction struct {
+	Op   Opcode
+	Data []byte // data pushed by 0x01-0x4b and OP_PUSHDATA1/2/4
+}
+
+func (in Instruction) String() string {
+	if len(in.Data) > 0 || (in.Op > OP_0 && in.Op <= OP_PUSHDATA4) {
+		return hex.EncodeToString(in.Data)
+	}
+	return in.Op.String()
+}
+
+// Parse splits a script into instructions.
+func Parse(script []byte) ([]Instruction, error) {
+	var out []Instruction
+	for pc := 0; pc < len(script); {
+		op := Opcode(script[pc])
+		pc++
+		var size int
+		switch {
+		case op > OP_0 && op < OP_PUSHDATA1:
+			size = int(op)
+		case op == OP_PUSHDATA1:
+			if pc+1 > len(script) {
+				return out, ErrTruncated
+			}
+			size = int(script[pc])
+			pc++
+		case op == OP_PUSHDATA2:
+			if pc+2 > len(script) {
+				return out, ErrTruncated
+			}
+			size = int(binary.LittleEndian.Uint16(script[pc:]))
+			pc += 2
+		case op == OP_PUSHDATA4:
+			if pc+4 > len(script) {
+				return out, ErrTruncated
+			}
+			size = int(binary.LittleEndian.Uint32(script[pc:]))
+			pc += 4
+		default:
+			out = append(out, Instruction{Op: op})
+			continue
+		}
+		if size < 0 || pc+size > len(script) {
+			return out, ErrTruncated
+		}
+		out = append(out, Instruction{Op: op, Data: script[pc : pc+size]})
+		pc += size
+	}
+	return out, nil
+}
+
+// ParseHex parses a hex-encoded script (e.g. RawTxnScriptPubKey.Hex).
+func ParseHex(scriptHex string) ([]Instruction, error) {
+	script, err := hex.DecodeString(scriptHex)
+	if err != nil {
+		return nil, fmt.Errorf("script: invalid hex: %v", err)
+	}
+	return Parse(script)
+}
+
+// Disasm formats a script like Core's "asm" field.
+func Disasm(script []byte) string {
+	ins, err := Parse(script)
+	parts := make([]string, 0, len(ins)+1)
+	for _, in := range ins {
+		parts = append(parts, in.String())
+	}
+	if err != nil {
+		parts = append(parts, "[error]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// Script is a classified scriptPubKey.
+type Script struct {
+	Type       ScriptType
+	Hash       []byte   // P2PKH: public key hash; P2SH: script hash
+	PubKeys    [][]byte // P2PK: one public key; MultiSig: all public keys
+	ReqSigs    int      // P2PK/P2PKH: 1; MultiSig: m
+	Data       [][]byte // NullData: pushed data after OP_RETURN
+	Raw        []byte
+	Instrs     []Instruction
+	ParseError error // set for unparsable (NonStandard) scripts
+}
+
+// Classify parses and classifies a scriptPubKey.
+func Classify(script []byte) *Script {
+	s := &Script{Type: NonStandard, Raw: script}
+	ins, err := Parse(script)
+	s.Instrs = ins
+	if err != nil {
+		s.ParseError = err
+		return s
+	}
+
+	switch {
+	case len(script) == 23 && Opcode(script[0]) == OP_HASH160 && script[1] == 20 && Opcode(script[22]) == OP_EQUAL:
+		s.Type, s.Hash, s.ReqSigs = P2SH, script[2:22], 1
+	case len(script) == 25 && Opcode(script[0]) == OP_DUP && Opcode(script[1]) == OP_HASH160 && script[2] == 20 &&
+		Opcode(script[23]) == OP_EQUALVERIFY && Opcode(script[24]) == OP_CHECKSIG:
+		s.Type, s.Hash, s.ReqSigs = P2PKH, script[3:23], 1
+	case len(ins) >= 1 && ins[0].Op == OP_RETURN && isPushOnly(ins[1:]):
+		s.Type = NullData
+		for _, in := range ins[1:] {
+			s.Data = append(s.Data, in.Data)
+		}
+	case len(ins) == 2 && isPubKey(ins[0].Data) && ins[1].Op == OP_CHECKSIG:
+		s.Type, s.PubKeys, s.ReqSigs = P2PK, [][]byte{ins[0].Data}, 1
+	default:
+		if m, keys, ok := matchMultiSig(ins); ok {
+			s.Type, s.PubKeys, s.ReqSigs = MultiSig, keys, m
+		}
+	}
+	return s
+}
+
+// ClassifyHex classifies a hex-encoded scriptPubKey.
+func ClassifyHex(scriptHex string) (*Script, error) {
+	script, err := hex.DecodeString(scriptHex)
+	if err != nil {
+		return nil, fmt.Errorf("script: invalid hex: %v", err)
+	}
+	return Classify(script), nil
+}
+
+// ClassifyScriptPubKey classifies the scriptPubKey of a transaction output.
+func ClassifyScriptPubKey(spk types.RawTxnScriptPubKey) (*Script, error) {
+	return ClassifyHex(spk.Hex)
+}
+
+func matchMultiSig(ins []Instruction) (int, [][]byte, bool) {
+	if len(ins) < 4 || ins[len(ins)-1].Op != OP_CHECKMULTISIG {
+		return 0, nil, false
+	}
+	m := ins[0].Op.SmallInt()
+	n := ins[len(ins)-2].Op.SmallInt()
+	keys := ins[1 : len(ins)-2]
+	if m < 1 || n < 1 || m > n || n != len(keys) {
+		return 0, nil, false
+	}
+	pubKeys := make([][]byte, 0, n)
+	for _, k := range keys {
+		if !isPubKey(k.Data) {
+			return 0, nil, false
+		}
+		pubKeys = append(pubKeys, k.Data)
+	}
+	return m, pubKeys, true
+}
+
+func isPushOnly(ins []Instruction) bool {
+	for _, in := range ins {
+		if !in.Op.IsPush() {
+			return false
+		}
+	}
+	return true
+}
+
+func isPubKey(b []byte) bool {
+	switch len(b) {
+	case 33:
+		return b[0] == 0x02 || b[0] == 0x03
+	case 65:
+		return b[0] == 0x04
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package script
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+const (
+	pubKey33 = "0250863ad64a87ae8a2fe83c1af1a8403cb53f53e486d8511dad8a04887e5b2352"
+	hash20   = "f54a5851e9372b87810a8e60cdd2e7cfd80b6e31"
+)
+
+func TestClassify(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		hex     string
+		typ     ScriptType
+		reqSigs int
+	}{
+		{"p2pkh", "76a914" + hash20 + "88ac", P2PKH, 1},
+		{"p2sh", "a914" + hash20 + "87", P2SH, 1},
+		{"p2pk", "21" + pubKey33 + "ac", P2PK, 1},
+		{"multisig 1-of-2", "51" + "21" + pubKey33 + "21" + pubKey33 + "52ae", MultiSig, 1},
+		{"multisig m>n", "52" + "21" + pubKey33 + "51ae", NonStandard, 0},
+		{"nulldata", "6a04444f474505" + "68656c6c6f", NullData, 0},
+		{"nulldata empty", "6a", NullData, 0},
+		{"op_return non-push", "6a76", NonStandard, 0},
+		{"truncated push", "6a4c05aa", NonStandard, 0},
+		{"empty", "", NonStandard, 0},
+	} {
+		s, err := ClassifyHex(tc.hex)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if s.Type != tc.typ || s.ReqSigs != tc.reqSigs {
+			t.Errorf("%s: got %v (reqSigs %d), expected %v (reqSigs %d)", tc.name, s.Type, s.ReqSigs, tc.typ, tc.reqSigs)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	s, _ := ClassifyHex("76a914" + hash20 + "88ac")
+	if hex.EncodeToString(s.Hash) != hash20 {
+		t.Errorf("P2PKH hash: got %x", s.Hash)
+	}
+
+	s, _ = ClassifyHex("51" + "21" + pubKey33 + "21" + pubKey33 + "52ae")
+	if len(s.PubKeys) != 2 || hex.EncodeToString(s.PubKeys[1]) != pubKey33 {
+		t.Errorf("MultiSig pubkeys: got %x", s.PubKeys)
+	}
+
+	s, _ = ClassifyHex("6a04444f474505" + "68656c6c6f")
+	if len(s.Data) != 2 || string(s.Data[0]) != "DOGE" || string(s.Data[1]) != "hello" {
+		t.Errorf("NullData: got %q", s.Data)
+	}
+	if asm := Disasm(s.Raw); asm != "OP_RETURN 444f4745 68656c6c6f" {
+		t.Errorf("Disasm: got %q", asm)
+	}
+}
+
+func TestDecodeCoreRPCScriptType(t *testing.T) {
+	for core, expected := range map[string]ScriptType{
+		"pubkey":             P2PK,
+		"pubkeyhash":         P2PKH,
+		"scripthash":         P2SH,
+		"multisig":           MultiSig,
+		"nulldata":           NullData,
+		"nonstandard":        NonStandard,
+		"witness_v0_keyhash": NonStandard,
+	} {
+		if got := DecodeCoreRPCScriptType(core); got != expected {
+			t.Errorf("DecodeCoreRPCScriptType(%q) = %v, expected %v", core, got, expected)
+		}
+	}
+}
+
+func TestBuilders(t *testing.T) {
+	hash, _ := hex.DecodeString(hash20)
+	pub, _ := hex.DecodeString(pubKey33)
+
+	p2pkh, _ := PayToPubKeyHash(hash)
+	p2sh, _ := PayToScriptHash(hash)
+	multi, err := MultiSigScript(1, [][]byte{pub, pub})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		script []byte
+		typ    ScriptType
+	}{{p2pkh, P2PKH}, {p2sh, P2SH}, {multi, MultiSig}} {
+		if s := Classify(tc.script); s.Type != tc.typ {
+			t.Errorf("%x: got %v, expected %v", tc.script, s.Type, tc.typ)
+		}
+	}
+	if _, err := MultiSigScript(3, [][]byte{pub, pub}); err == nil {
+		t.Error("expected error for m > n")
+	}
+
+	for size, prefix := range map[int]string{0: "00", 75: "4b", 76: "4c4c", 256: "4d0001", 0x10000: "4e00000100"} {
+		got := hex.EncodeToString(PushData(nil, make([]byte, size)))
+		if got[:len(prefix)] != prefix || len(got) != len(prefix)+2*size {
+			t.Errorf("push of %d bytes: got prefix %v, expected %v", size, got[:len(prefix)], prefix)
+		}
+	}
+}
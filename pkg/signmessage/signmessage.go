@@ -0,0 +1,36 @@
+package signmessage
+
+import (
+	"github.com/dogecoinfoundation/chainfollower/internal/doge"
+	"github.com/dogecoinfoundation/chainfollower/pkg/address"
+	"github.com/dogecoinfoundation/chainfollower/pkg/wif"
+)
+
+// Dogecoin signed messages, compatible with Core's signmessage and
+// verifymessage RPCs: use Verify to check that a user controls an address.
+
+const Magic = doge.MessageMagic
+
+// ErrMismatch is returned when the signature was made by a different key.
+var ErrMismatch = doge.ErrMessageSignature
+
+// Sign signs a message with a WIF private key (see wif.Decode).
+func Sign(key *wif.Key, message string) (string, error) {
+	return doge.SignMessage(key.PrivKey, key.Compressed, message)
+}
+
+// Verify checks that the base64 signature over message was made by the key
+// of a P2PKH address on the network.
+func Verify(net *address.Network, addr string, signature string, message string) error {
+	return doge.VerifyMessage(net, addr, signature, message)
+}
+
+// RecoverAddress returns the P2PKH address of the key that signed the message.
+func RecoverAddress(net *address.Network, signature string, message string) (string, error) {
+	return doge.RecoverMessageAddress(net, signature, message)
+}
+
+// Hash returns the double-SHA256 hash that is signed for a message.
+func Hash(message string) []byte {
+	return doge.MessageHash(message)
+}
@@ -0,0 +1,65 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/stream"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Kafka produces each event to partition 0 of a topic, so consumers see
+// blocks and rollbacks in chain order. Records are keyed by block hash (the
+// new block for rollbacks, the containing block for data; alerts have no
+// key) and carry the event type in a "type" header; each is acknowledged by all
+// in-sync replicas before the next event is sent.
+
+type Kafka struct {
+	Topic  string
+	client *kgo.Client
+}
+
+func NewKafka(brokers []string, topic string, timeout time.Duration) (*Kafka, error) {
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(brokers...),
+		kgo.DefaultProduceTopic(topic),
+		kgo.RecordPartitioner(kgo.ManualPartitioner()),
+		kgo.ProduceRequestTimeout(timeout),
+		kgo.RecordDeliveryTimeout(timeout),
+		kgo.AllowAutoTopicCreation(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Kafka{Topic: topic, client: client}, nil
+}
+
+func (k *Kafka) Send(ctx context.Context, ev stream.Event, payload []byte) error {
+	rec := &kgo.Record{
+		Value:     payload,
+		Partition: 0,
+		Headers:   []kgo.RecordHeader{{Key: "type", Value: []byte(ev.Type)}},
+		Key:       recordKey(ev),
+	}
+	if err := k.client.ProduceSync(ctx, rec).FirstErr(); err != nil {
+		return fmt.Errorf("kafka %v: %w", k.Topic, err)
+	}
+	return nil
+}
+
+// recordKey returns the block hash of an event, or nil for alerts.
+func recordKey(ev stream.Event) []byte {
+	switch {
+	case ev.ChainPos != nil:
+		return []byte(ev.ChainPos.BlockHash)
+	case ev.Data != nil:
+		return []byte(ev.Data.BlockHash)
+	}
+	return nil
+}
+
+func (k *Kafka) Close() error {
+	k.client.Close()
+	return nil
+}
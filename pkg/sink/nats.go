@@ -0,0 +1,114 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/stream"
+)
+
+// NATS publishes each event to "<subject>.<event type>" using the NATS
+// client protocol. Each publish is followed by a PING and waits for the
+// PONG, so the server has received it before the next event is sent. Core
+// NATS does not store messages: capture the subjects in a JetStream stream
+// for durability.
+
+type NATS struct {
+	URL     string // nats://[user:pass@]host:port
+	Subject string // subject prefix, e.g. "chainfollower"
+	Timeout time.Duration
+	conn    net.Conn
+	r       *bufio.Reader
+}
+
+func NewNATS(url string, subject string, timeout time.Duration) *NATS {
+	return &NATS{URL: url, Subject: subject, Timeout: timeout}
+}
+
+func (n *NATS) Send(ctx context.Context, ev stream.Event, payload []byte) error {
+	if n.conn == nil {
+		if err := n.connect(ctx); err != nil {
+			return err
+		}
+	}
+	n.conn.SetDeadline(time.Now().Add(n.Timeout))
+	_, err := fmt.Fprintf(n.conn, "PUB %s.%s %d\r\n%s\r\nPING\r\n", n.Subject, ev.Type, len(payload), payload)
+	if err == nil {
+		err = n.waitPong()
+	}
+	if err != nil {
+		n.Close() // reconnect on the next attempt.
+		return fmt.Errorf("nats %v: %w", n.URL, err)
+	}
+	return nil
+}
+
+func (n *NATS) connect(ctx context.Context) error {
+	u, err := url.Parse(n.URL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("nats: invalid url %q", n.URL)
+	}
+	dialer := net.Dialer{Timeout: n.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return fmt.Errorf("nats %v: %w", n.URL, err)
+	}
+	n.conn, n.r = conn, bufio.NewReader(conn)
+	n.conn.SetDeadline(time.Now().Add(n.Timeout))
+
+	line, err := n.r.ReadString('\n')
+	if err == nil && !strings.HasPrefix(line, "INFO ") {
+		err = fmt.Errorf("unexpected greeting %q", strings.TrimSpace(line))
+	}
+	if err == nil {
+		connect := `{"verbose":false,"pedantic":false,"name":"chainfollower","lang":"go"`
+		if u.User != nil {
+			pass, _ := u.User.Password()
+			connect += fmt.Sprintf(`,"user":%q,"pass":%q`, u.User.Username(), pass)
+		}
+		_, err = fmt.Fprintf(n.conn, "CONNECT %s}\r\nPING\r\n", connect)
+	}
+	if err == nil {
+		err = n.waitPong()
+	}
+	if err != nil {
+		n.Close()
+		return fmt.Errorf("nats %v: %w", n.URL, err)
+	}
+	return nil
+}
+
+// waitPong reads server messages until a PONG, answering PINGs.
+func (n *NATS) waitPong() error {
+	for {
+		line, err := n.r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		switch line = strings.TrimSpace(line); {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := n.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New(line)
+		}
+	}
+}
+
+func (n *NATS) Close() error {
+	if n.conn == nil {
+		return nil
+	}
+	err := n.conn.Close()
+	n.conn, n.r = nil, nil
+	return err
+}
@@ -0,0 +1,175 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/broker"
+	"github.com/dogecoinfoundation/chainfollower/pkg/config"
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/store"
+	"github.com/dogecoinfoundation/chainfollower/pkg/stream"
+)
+
+// Output sinks deliver the follower's events (stream.Event JSON, as sent to
+// WebSocket clients) to external systems: HTTP webhooks, NATS and Kafka.
+// Each sink runs as a broker subscriber with its own saved position: events
+// are delivered one at a time in order, retried until the sink accepts
+// them, and the position is saved after each one, so delivery resumes after
+// a restart (at least once).
+
+const DEFAULT_RETRY_DELAY = 1 * time.Second
+const DEFAULT_MAX_RETRY_DELAY = 1 * time.Minute
+const DEFAULT_TIMEOUT = 10 * time.Second
+
+// Sink delivers events to an external system.
+type Sink interface {
+	// Send delivers one event, encoded as payload; it returns once the
+	// event has been accepted.
+	Send(ctx context.Context, ev stream.Event, payload []byte) error
+	Close() error
+}
+
+// Runner feeds a Sink from a broker subscription.
+type Runner struct {
+	Name          string
+	Sink          Sink
+	Filter        stream.Request // event filters (From is ignored)
+	PositionFile  string         // saved position ("" = always start with the follower)
+	MaxAttempts   int            // attempts per event before giving up (0 = retry forever)
+	RetryDelay    time.Duration  // first delay between attempts, doubling up to MaxRetryDelay
+	MaxRetryDelay time.Duration
+	Logger        *slog.Logger // diagnostics (silent by default).
+}
+
+func NewRunner(name string, sink Sink, positionFile string) *Runner {
+	return &Runner{
+		Name:          name,
+		Sink:          sink,
+		PositionFile:  positionFile,
+		RetryDelay:    DEFAULT_RETRY_DELAY,
+		MaxRetryDelay: DEFAULT_MAX_RETRY_DELAY,
+		Logger:        slog.New(slog.DiscardHandler),
+	}
+}
+
+// Subscribe adds the sink to the broker, resuming from its saved position.
+// Subscribe every sink before starting the follower at Broker.StartPos.
+func (r *Runner) Subscribe(b *broker.Broker) (*broker.Subscriber, error) {
+	var opts []broker.SubscribeOption
+	if r.PositionFile != "" {
+		pos, err := r.store().LoadChainPos(r.PositionFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, broker.WithCheckpoint(pos))
+	}
+	return b.Subscribe(r.Name, opts...), nil
+}
+
+// Run delivers the subscriber's messages until its channel is closed, an
+// event fails MaxAttempts times, or ctx is done. The sink is closed on return.
+func (r *Runner) Run(ctx context.Context, sub *broker.Subscriber) error {
+	defer r.Sink.Close()
+	for msg := range sub.Messages() {
+		ev, ok := stream.EventFromMessage(msg)
+		if !ok {
+			continue
+		}
+		pos := messages.Position(msg) // (sub.Checkpoint() lags until the next receive)
+		if ev, ok = r.Filter.Filter(ev); ok {
+			payload, err := json.Marshal(ev)
+			if err != nil {
+				return fmt.Errorf("sink %v: %w", r.Name, err)
+			}
+			if err := r.deliver(ctx, ev, payload); err != nil {
+				return err
+			}
+		}
+		if pos != nil && r.PositionFile != "" {
+			if err := r.store().SaveChainPos(r.PositionFile, pos); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Runner) store() *store.Store {
+	return &store.Store{Logger: r.Logger}
+}
+
+func (r *Runner) deliver(ctx context.Context, ev stream.Event, payload []byte) error {
+	delay := r.RetryDelay
+	for attempt := 1; ; attempt++ {
+		err := r.Sink.Send(ctx, ev, payload)
+		if err == nil {
+			return nil
+		}
+		if r.MaxAttempts > 0 && attempt >= r.MaxAttempts {
+			r.Logger.Error("sink: giving up", "sink", r.Name, "type", ev.Type, "attempts", attempt, "error", err)
+			return fmt.Errorf("sink %v: %w", r.Name, err)
+		}
+		r.Logger.Warn("sink: delivery failed", "sink", r.Name, "type", ev.Type, "attempt", attempt, "retry_in", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, r.MaxRetryDelay)
+	}
+}
+
+// FromConfig creates a sink runner from a [[sink]] section.
+func FromConfig(cfg config.SinkConfig) (*Runner, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("sink: name is required")
+	}
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = DEFAULT_TIMEOUT
+	}
+	var sink Sink
+	var err error
+	switch cfg.Type {
+	case "webhook":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("sink %v: url is required", cfg.Name)
+		}
+		sink = NewWebhook(cfg.URL, []byte(cfg.Secret), timeout)
+	case "nats":
+		if cfg.URL == "" || cfg.Subject == "" {
+			return nil, fmt.Errorf("sink %v: url and subject are required", cfg.Name)
+		}
+		sink = NewNATS(cfg.URL, cfg.Subject, timeout)
+	case "kafka":
+		if len(cfg.Brokers) == 0 || cfg.Topic == "" {
+			return nil, fmt.Errorf("sink %v: brokers and topic are required", cfg.Name)
+		}
+		sink, err = NewKafka(cfg.Brokers, cfg.Topic, timeout)
+	default:
+		return nil, fmt.Errorf("sink %v: unknown type %q (expected webhook, nats or kafka)", cfg.Name, cfg.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sink %v: %w", cfg.Name, err)
+	}
+
+	r := NewRunner(cfg.Name, sink, "")
+	r.MaxAttempts = cfg.MaxAttempts
+	if cfg.RetryDelay > 0 {
+		r.RetryDelay = cfg.RetryDelay
+	}
+	if r.Filter.Types, err = stream.ParseTypes(strings.Join(cfg.Types, ",")); err != nil {
+		return nil, fmt.Errorf("sink %v: %w", cfg.Name, err)
+	}
+	r.Filter.HeadersOnly = cfg.HeadersOnly
+	for _, p := range cfg.DataPrefixes {
+		r.Filter.DataPrefixes = append(r.Filter.DataPrefixes, []byte(p))
+	}
+	return r, nil
+}
@@ -0,0 +1,270 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/broker"
+	"github.com/dogecoinfoundation/chainfollower/pkg/rpc"
+	"github.com/dogecoinfoundation/chainfollower/pkg/store"
+	"github.com/dogecoinfoundation/chainfollower/pkg/stream"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// publishChain sends blocks 1..3, a rollback to 2 and block 3 again.
+func publishChain(b *broker.Broker) {
+	for h := int64(1); h <= 3; h++ {
+		b.Publish(rpc.TestBlockMessage(h))
+	}
+	b.Publish(rpc.TestRollbackMessage(3, 2))
+	b.Publish(rpc.TestBlockMessage(3))
+}
+
+// summary describes an event payload, e.g. "block 3" or "rollback 2".
+func summary(t *testing.T, payload []byte) string {
+	t.Helper()
+	var ev stream.Event
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("%s %d", ev.Type, ev.ChainPos.BlockHeight)
+}
+
+// runSink subscribes a runner, publishes with fn and waits for delivery.
+func runSink(t *testing.T, r *Runner, fn func(b *broker.Broker)) {
+	t.Helper()
+	r.RetryDelay = time.Millisecond
+	b := broker.NewBroker()
+	sub, err := r.Subscribe(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- r.Run(context.Background(), sub) }()
+	fn(b)
+	b.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+const wantChain = "block 1,block 2,block 3,rollback 2,block 3"
+
+func TestWebhook(t *testing.T) {
+	secret := []byte("s3cret")
+	var mu sync.Mutex
+	var got []string
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls%2 == 1 { // fail every event once
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		if !VerifySignature(secret, body, r.Header.Get(SignatureHeader)) {
+			t.Errorf("bad signature %q", r.Header.Get(SignatureHeader))
+		}
+		if typ := r.Header.Get(EventTypeHeader); !strings.HasPrefix(summary(t, body), typ+" ") {
+			t.Errorf("event type header %q for %s", typ, body)
+		}
+		got = append(got, summary(t, body))
+	}))
+	defer srv.Close()
+
+	positions := filepath.Join(t.TempDir(), "position-hook.json")
+	runSink(t, NewRunner("hook", NewWebhook(srv.URL, secret, time.Second), positions), publishChain)
+	if s := strings.Join(got, ","); s != wantChain {
+		t.Errorf("webhook got %v, want %v", s, wantChain)
+	}
+	pos, err := store.LoadChainPos(positions)
+	if err != nil || pos.BlockHash != rpc.TestBlockHash(3) || pos.BlockHeight != 3 {
+		t.Fatalf("saved position %+v, %v", pos, err)
+	}
+
+	// a restart resumes after the saved position.
+	got = nil
+	runSink(t, NewRunner("hook", NewWebhook(srv.URL, secret, time.Second), positions), func(b *broker.Broker) {
+		if start := b.StartPos(); start.BlockHash != rpc.TestBlockHash(3) {
+			t.Errorf("start position %+v", start)
+		}
+		for h := int64(3); h <= 4; h++ {
+			b.Publish(rpc.TestBlockMessage(h))
+		}
+	})
+	if s := strings.Join(got, ","); s != "block 4" {
+		t.Errorf("after restart got %v", s)
+	}
+
+	if VerifySignature(secret, []byte("{}"), Sign([]byte("other"), []byte("{}"))) {
+		t.Error("signature with the wrong secret accepted")
+	}
+}
+
+// sinkFunc is a Sink calling a function for each event.
+type sinkFunc func(ev stream.Event) error
+
+func (f sinkFunc) Send(ctx context.Context, ev stream.Event, payload []byte) error {
+	return f(ev)
+}
+
+func (f sinkFunc) Close() error {
+	return nil
+}
+
+func TestSavedPosition(t *testing.T) {
+	// when an event is sent, the position of the one before it is saved
+	// (the subscriber's Checkpoint would still lag behind).
+	positions := filepath.Join(t.TempDir(), "position-func.json")
+	var saved []string
+	record := sinkFunc(func(ev stream.Event) error {
+		pos, err := store.LoadChainPos(positions)
+		if err != nil {
+			return err
+		}
+		saved = append(saved, fmt.Sprint(pos.BlockHeight))
+		return nil
+	})
+	runSink(t, NewRunner("func", record, positions), publishChain)
+	if got := strings.Join(saved, ","); got != "0,1,2,3,2" {
+		t.Errorf("saved positions %v", got)
+	}
+}
+
+func TestGiveUp(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	r := NewRunner("hook", NewWebhook(srv.URL, nil, time.Second), "")
+	r.MaxAttempts, r.RetryDelay = 3, time.Millisecond
+	b := broker.NewBroker()
+	sub, _ := r.Subscribe(b)
+	b.Publish(rpc.TestBlockMessage(1))
+	if err := r.Run(context.Background(), sub); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("expected a delivery error, got %v", err)
+	}
+}
+
+// fakeNATS accepts one client at a time and records published subjects and
+// payloads; the first connection is dropped after its first publish.
+func fakeNATS(t *testing.T) (string, func() []string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lis.Close() })
+	var mu sync.Mutex
+	var got []string
+	go func() {
+		for conn := 1; ; conn++ {
+			c, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(c)
+			fmt.Fprintf(c, "INFO {\"server_id\":\"fake\"}\r\n")
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					break
+				}
+				f := strings.Fields(line)
+				switch {
+				case len(f) == 0:
+				case f[0] == "PING":
+					fmt.Fprintf(c, "PONG\r\n")
+				case f[0] == "PUB" && len(f) == 3:
+					n, _ := strconv.Atoi(f[2])
+					payload := make([]byte, n+2)
+					io.ReadFull(r, payload)
+					if conn == 1 {
+						c.Close() // lost before the PONG.
+						continue
+					}
+					mu.Lock()
+					got = append(got, f[1]+" "+summary(t, payload[:n]))
+					mu.Unlock()
+				}
+			}
+			c.Close()
+		}
+	}()
+	return "nats://" + lis.Addr().String(), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return got
+	}
+}
+
+func TestNATS(t *testing.T) {
+	url, published := fakeNATS(t)
+	runSink(t, NewRunner("nats", NewNATS(url, "doge", time.Second), ""), publishChain)
+	want := "doge.block block 1,doge.block block 2,doge.block block 3,doge.rollback rollback 2,doge.block block 3"
+	if s := strings.Join(published(), ","); s != want {
+		t.Errorf("nats got %v, want %v", s, want)
+	}
+}
+
+func TestKafka(t *testing.T) {
+	cluster, err := kfake.NewCluster(kfake.SeedTopics(3, "events"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cluster.Close()
+
+	k, err := NewKafka(cluster.ListenAddrs(), "events", 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runSink(t, NewRunner("kafka", k, ""), publishChain)
+
+	consumer, err := kgo.NewClient(kgo.SeedBrokers(cluster.ListenAddrs()...), kgo.ConsumeTopics("events"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var got []string
+	for len(got) < 5 && ctx.Err() == nil {
+		consumer.PollFetches(ctx).EachRecord(func(rec *kgo.Record) {
+			if rec.Partition != 0 || string(rec.Headers[0].Value) != strings.Fields(summary(t, rec.Value))[0] {
+				t.Errorf("record on partition %d with headers %v", rec.Partition, rec.Headers)
+			}
+			var ev stream.Event
+			json.Unmarshal(rec.Value, &ev)
+			if string(rec.Key) != ev.ChainPos.BlockHash {
+				t.Errorf("record keyed %q, want %v", rec.Key, ev.ChainPos.BlockHash)
+			}
+			got = append(got, summary(t, rec.Value))
+		})
+	}
+	if s := strings.Join(got, ","); s != wantChain {
+		t.Errorf("kafka got %v, want %v", s, wantChain)
+	}
+
+	data := stream.Event{Type: stream.EventData, Data: &stream.DataCarrier{BlockHash: rpc.TestBlockHash(2)}}
+	if key := string(recordKey(data)); key != rpc.TestBlockHash(2) {
+		t.Errorf("data event keyed %q", key)
+	}
+	if key := recordKey(stream.Event{Type: stream.EventStall}); key != nil {
+		t.Errorf("alert keyed %q", key)
+	}
+}
@@ -0,0 +1,78 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/stream"
+)
+
+// Webhook POSTs each event as JSON to a URL; any 2xx response accepts it.
+// With a secret, the body is signed with HMAC-SHA256 in the
+// X-Chainfollower-Signature header ("sha256=<hex>"), see VerifySignature.
+
+const SignatureHeader = "X-Chainfollower-Signature"
+const EventTypeHeader = "X-Chainfollower-Event"
+
+type Webhook struct {
+	URL    string
+	Secret []byte // HMAC key (nil = unsigned)
+	Client *http.Client
+}
+
+func NewWebhook(url string, secret []byte, timeout time.Duration) *Webhook {
+	return &Webhook{URL: url, Secret: secret, Client: &http.Client{Timeout: timeout}}
+}
+
+func (w *Webhook) Send(ctx context.Context, ev stream.Event, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventTypeHeader, ev.Type)
+	if len(w.Secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(w.Secret, payload))
+	}
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %v returned %v", w.URL, resp.Status)
+	}
+	return nil
+}
+
+func (w *Webhook) Close() error {
+	w.Client.CloseIdleConnections()
+	return nil
+}
+
+// Sign returns the signature header value for a webhook body.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks a webhook body against its signature header.
+func VerifySignature(secret, body []byte, header string) bool {
+	sig, err := hex.DecodeString(strings.TrimPrefix(header, "sha256="))
+	if err != nil || !strings.HasPrefix(header, "sha256=") {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(sig, mac.Sum(nil))
+}
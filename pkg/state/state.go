@@ -4,4 +4,21 @@ type ChainPos struct {
 	BlockHash          string // last block processed ("" at genesis)
 	BlockHeight        int64  // height of last block (0 at genesis)
 	WaitingForNextHash bool   // if the block has been fetched
+	ChainName          string `json:",omitempty"` // chain this position belongs to (refuse to resume on another chain)
+}
+
+// BackfillShard is the progress of one shard of a backfill: the heights
+// From..To (inclusive), of which Next is the first not yet delivered.
+// Save it after processing each block to resume the shard later.
+type BackfillShard struct {
+	Index     int
+	From      int64
+	To        int64
+	Next      int64
+	ChainName string `json:",omitempty"`
+}
+
+// Done reports whether every block in the shard has been delivered.
+func (s *BackfillShard) Done() bool {
+	return s.Next > s.To
 }
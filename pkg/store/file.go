@@ -3,13 +3,46 @@ package store
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/dogecoinfoundation/chainfollower/pkg/state"
 )
 
+// Store loads and saves positions and backfill progress in JSON files.
+// Errors are returned, not logged; the caller decides how to report them.
+type Store struct {
+	Logger *slog.Logger // diagnostics (silent by default).
+}
+
+func New() *Store {
+	return &Store{Logger: slog.New(slog.DiscardHandler)}
+}
+
+// LoadChainPos loads a position with a silent Store.
 func LoadChainPos(path string) (*state.ChainPos, error) {
+	return New().LoadChainPos(path)
+}
+
+// SaveChainPos saves a position with a silent Store.
+func SaveChainPos(path string, chainState *state.ChainPos) error {
+	return New().SaveChainPos(path, chainState)
+}
+
+// LoadBackfillShards loads backfill progress with a silent Store.
+func LoadBackfillShards(path string) ([]state.BackfillShard, error) {
+	return New().LoadBackfillShards(path)
+}
+
+// SaveBackfillShards saves backfill progress with a silent Store.
+func SaveBackfillShards(path string, shards []state.BackfillShard) error {
+	return New().SaveBackfillShards(path, shards)
+}
+
+// LoadChainPos loads a saved position (an empty ChainPos if there is none).
+func (s *Store) LoadChainPos(path string) (*state.ChainPos, error) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
+		s.Logger.Info("store: no saved position", "path", path)
 		return &state.ChainPos{
 			BlockHash:          "",
 			BlockHeight:        0,
@@ -19,8 +52,7 @@ func LoadChainPos(path string) (*state.ChainPos, error) {
 
 	file, err := os.Open(path)
 	if err != nil {
-		fmt.Println("Error opening file:", err)
-		return nil, err
+		return nil, fmt.Errorf("store: open %v: %w", path, err)
 	}
 	defer file.Close()
 
@@ -29,27 +61,58 @@ func LoadChainPos(path string) (*state.ChainPos, error) {
 	decoder := json.NewDecoder(file)
 	err = decoder.Decode(&chainState)
 	if err != nil {
-		fmt.Println("Error decoding JSON:", err)
-		return nil, err
+		return nil, fmt.Errorf("store: decode %v: %w", path, err)
 	}
 
+	s.Logger.Debug("store: loaded position", "path", path, "height", chainState.BlockHeight, "hash", chainState.BlockHash)
 	return &chainState, nil
 }
 
-func SaveChainPos(path string, chainState *state.ChainPos) error {
-	file, err := os.Create("data.json")
+// SaveChainPos saves a position, replacing the file.
+func (s *Store) SaveChainPos(path string, chainState *state.ChainPos) error {
+	file, err := os.Create(path)
 	if err != nil {
-		fmt.Println("Error creating file:", err)
-		return err
+		return fmt.Errorf("store: create %v: %w", path, err)
 	}
 	defer file.Close()
 
 	encoder := json.NewEncoder(file)
 	err = encoder.Encode(chainState)
 	if err != nil {
-		fmt.Println("Error encoding JSON:", err)
-		return err
+		return fmt.Errorf("store: encode %v: %w", path, err)
 	}
 
+	s.Logger.Debug("store: saved position", "path", path, "height", chainState.BlockHeight, "hash", chainState.BlockHash)
+	return nil
+}
+
+// LoadBackfillShards loads saved backfill progress (nil if there is none).
+func (s *Store) LoadBackfillShards(path string) ([]state.BackfillShard, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		s.Logger.Info("store: no saved backfill", "path", path)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("store: read %v: %w", path, err)
+	}
+	var shards []state.BackfillShard
+	if err := json.Unmarshal(data, &shards); err != nil {
+		return nil, fmt.Errorf("store: decode %v: %w", path, err)
+	}
+	s.Logger.Debug("store: loaded backfill", "path", path, "shards", len(shards))
+	return shards, nil
+}
+
+// SaveBackfillShards saves backfill progress (see messages.BackfillBlockMessage).
+func (s *Store) SaveBackfillShards(path string, shards []state.BackfillShard) error {
+	data, err := json.Marshal(shards)
+	if err != nil {
+		return fmt.Errorf("store: encode %v: %w", path, err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("store: write %v: %w", path, err)
+	}
+	s.Logger.Debug("store: saved backfill", "path", path, "shards", len(shards))
 	return nil
 }
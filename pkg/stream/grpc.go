@@ -0,0 +1,322 @@
+package stream
+
+//go:generate protoc -I .. --go_out=.. --go_opt=module=github.com/dogecoinfoundation/chainfollower/pkg --go-grpc_out=.. --go-grpc_opt=module=github.com/dogecoinfoundation/chainfollower/pkg stream/stream.proto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/state"
+	"github.com/dogecoinfoundation/chainfollower/pkg/stream/streampb"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// gRPC transport: the chainfollower.stream.v1.Stream service in stream.proto
+// (generated code in streampb), a server-streaming Subscribe call. Events
+// and requests are converted to and from the generated messages.
+
+// NewGRPCServer returns a gRPC server with the Stream service registered.
+// Other services may be registered on it as usual.
+func (s *Server) NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	gs := grpc.NewServer(opts...)
+	s.RegisterGRPC(gs)
+	return gs
+}
+
+// RegisterGRPC adds the Stream service to an existing gRPC server.
+func (s *Server) RegisterGRPC(gs grpc.ServiceRegistrar) {
+	streampb.RegisterStreamServer(gs, grpcService{server: s})
+}
+
+// grpcService implements streampb.StreamServer.
+type grpcService struct {
+	streampb.UnimplementedStreamServer
+	server *Server
+}
+
+func (g grpcService) Subscribe(pb *streampb.SubscribeRequest, stream grpc.ServerStreamingServer[streampb.Event]) error {
+	s := g.server
+	req := requestFromProto(pb)
+	if _, err := ParseTypes(strings.Join(req.Types, ",")); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	sub, err := s.Subscribe(req)
+	if errors.Is(err, ErrPositionUnavailable) {
+		return status.Error(codes.NotFound, err.Error())
+	} else if errors.Is(err, ErrTooManyReplays) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	} else if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer sub.Close()
+	s.Logger.Info("stream: gRPC client connected", "from", req.From)
+	for {
+		ev, err := sub.Next(stream.Context())
+		switch {
+		case errors.Is(err, ErrClosed), errors.Is(err, ErrReplayEnded):
+			return status.Error(codes.Unavailable, err.Error())
+		case errors.Is(err, ErrFellBehind):
+			s.Logger.Warn("stream: disconnecting gRPC client", "error", err)
+			return status.Error(codes.ResourceExhausted, err.Error())
+		case err != nil:
+			return status.FromContextError(err).Err()
+		}
+		if err := stream.Send(eventToProto(ev)); err != nil {
+			return err
+		}
+	}
+}
+
+// GRPCSubscription receives events from a remote Stream service.
+type GRPCSubscription struct {
+	stream grpc.ServerStreamingClient[streampb.Event]
+}
+
+// SubscribeGRPC starts a subscription on a connection to a Stream service.
+func SubscribeGRPC(ctx context.Context, conn grpc.ClientConnInterface, req Request) (*GRPCSubscription, error) {
+	stream, err := streampb.NewStreamClient(conn).Subscribe(ctx, requestToProto(req))
+	if err != nil {
+		return nil, err
+	}
+	return &GRPCSubscription{stream: stream}, nil
+}
+
+// Recv waits for the next event.
+func (sub *GRPCSubscription) Recv() (Event, error) {
+	pb, err := sub.stream.Recv()
+	if err != nil {
+		return Event{}, err
+	}
+	return eventFromProto(pb)
+}
+
+func posToProto(pos *state.ChainPos) *streampb.ChainPos {
+	if pos == nil {
+		return nil
+	}
+	return &streampb.ChainPos{
+		BlockHash:          pos.BlockHash,
+		BlockHeight:        pos.BlockHeight,
+		WaitingForNextHash: pos.WaitingForNextHash,
+		ChainName:          pos.ChainName,
+	}
+}
+
+func posFromProto(pb *streampb.ChainPos) *state.ChainPos {
+	if pb == nil {
+		return nil
+	}
+	return &state.ChainPos{
+		BlockHash:          pb.BlockHash,
+		BlockHeight:        pb.BlockHeight,
+		WaitingForNextHash: pb.WaitingForNextHash,
+		ChainName:          pb.ChainName,
+	}
+}
+
+func requestToProto(req Request) *streampb.SubscribeRequest {
+	return &streampb.SubscribeRequest{
+		From:         posToProto(req.From),
+		Types:        req.Types,
+		HeadersOnly:  req.HeadersOnly,
+		DataPrefixes: req.DataPrefixes,
+	}
+}
+
+func requestFromProto(pb *streampb.SubscribeRequest) Request {
+	return Request{
+		From:         posFromProto(pb.From),
+		Types:        pb.Types,
+		HeadersOnly:  pb.HeadersOnly,
+		DataPrefixes: pb.DataPrefixes,
+	}
+}
+
+func eventToProto(ev Event) *streampb.Event {
+	pb := &streampb.Event{
+		Seq:         ev.Seq,
+		Type:        ev.Type,
+		ChainPos:    posToProto(ev.ChainPos),
+		OldChainPos: posToProto(ev.OldChainPos),
+		Block:       blockToProto(ev.Block),
+		Error:       ev.Error,
+	}
+	if d := ev.Data; d != nil {
+		pb.Data = &streampb.DataCarrier{
+			BlockHash:   d.BlockHash,
+			BlockHeight: d.BlockHeight,
+			TxIndex:     int32(d.TxIndex),
+			Txid:        d.TxID,
+			Vout:        int32(d.VOut),
+			Data:        d.Data,
+		}
+	}
+	return pb
+}
+
+func eventFromProto(pb *streampb.Event) (Event, error) {
+	ev := Event{
+		Seq:         pb.Seq,
+		Type:        pb.Type,
+		ChainPos:    posFromProto(pb.ChainPos),
+		OldChainPos: posFromProto(pb.OldChainPos),
+		Error:       pb.Error,
+	}
+	if pb.Block != nil {
+		block, err := blockFromProto(pb.Block)
+		if err != nil {
+			return Event{}, err
+		}
+		ev.Block = block
+	}
+	if d := pb.Data; d != nil {
+		ev.Data = &DataCarrier{
+			BlockHash:   d.BlockHash,
+			BlockHeight: d.BlockHeight,
+			TxIndex:     int(d.TxIndex),
+			TxID:        d.Txid,
+			VOut:        int(d.Vout),
+			Data:        d.Data,
+		}
+	}
+	return ev, nil
+}
+
+func blockToProto(b *types.Block) *streampb.Block {
+	if b == nil {
+		return nil
+	}
+	pb := &streampb.Block{
+		Hash:              b.Hash,
+		Confirmations:     b.Confirmations,
+		Size:              int64(b.Size),
+		StrippedSize:      int64(b.StrippedSize),
+		Weight:            int64(b.Weight),
+		Height:            b.Height,
+		Version:           int64(b.Version),
+		VersionHex:        b.VersionHex,
+		MerkleRoot:        b.MerkleRoot,
+		Time:              int64(b.Time),
+		MedianTime:        int64(b.MedianTime),
+		Nonce:             int64(b.Nonce),
+		Bits:              b.Bits,
+		Difficulty:        b.Difficulty.String(),
+		ChainWork:         b.ChainWork,
+		PreviousBlockHash: b.PreviousBlockHash,
+		NextBlockHash:     b.NextBlockHash,
+	}
+	for _, tx := range b.Tx {
+		ptx := &streampb.Transaction{
+			Txid:     tx.TxID,
+			Hash:     tx.Hash,
+			Size:     tx.Size,
+			Vsize:    tx.VSize,
+			Version:  tx.Version,
+			Locktime: tx.LockTime,
+		}
+		for _, in := range tx.VIn {
+			ptx.Vin = append(ptx.Vin, &streampb.TxIn{
+				Txid:        in.TxID,
+				Vout:        int32(in.VOut),
+				ScriptSig:   &streampb.Script{Asm: in.ScriptSig.Asm, Hex: in.ScriptSig.Hex},
+				Txinwitness: in.TxInWitness,
+				Sequence:    in.Sequence,
+			})
+		}
+		for _, out := range tx.VOut {
+			spk := out.ScriptPubKey
+			ptx.Vout = append(ptx.Vout, &streampb.TxOut{
+				Value: out.Value.String(),
+				N:     int32(out.N),
+				ScriptPubKey: &streampb.Script{
+					Asm:       spk.Asm,
+					Hex:       spk.Hex,
+					ReqSigs:   spk.ReqSigs,
+					Type:      spk.Type,
+					Addresses: spk.Addresses,
+				},
+			})
+		}
+		pb.Tx = append(pb.Tx, ptx)
+	}
+	return pb
+}
+
+func blockFromProto(pb *streampb.Block) (*types.Block, error) {
+	difficulty, err := parseDecimal(pb.Difficulty)
+	if err != nil {
+		return nil, fmt.Errorf("stream: block difficulty: %w", err)
+	}
+	b := &types.Block{
+		Hash:              pb.Hash,
+		Confirmations:     pb.Confirmations,
+		Size:              int(pb.Size),
+		StrippedSize:      int(pb.StrippedSize),
+		Weight:            int(pb.Weight),
+		Height:            pb.Height,
+		Version:           int(pb.Version),
+		VersionHex:        pb.VersionHex,
+		MerkleRoot:        pb.MerkleRoot,
+		Time:              int(pb.Time),
+		MedianTime:        int(pb.MedianTime),
+		Nonce:             int(pb.Nonce),
+		Bits:              pb.Bits,
+		Difficulty:        difficulty,
+		ChainWork:         pb.ChainWork,
+		PreviousBlockHash: pb.PreviousBlockHash,
+		NextBlockHash:     pb.NextBlockHash,
+	}
+	for _, ptx := range pb.Tx {
+		tx := types.RawTxn{
+			TxID:     ptx.Txid,
+			Hash:     ptx.Hash,
+			Size:     ptx.Size,
+			VSize:    ptx.Vsize,
+			Version:  ptx.Version,
+			LockTime: ptx.Locktime,
+		}
+		for _, in := range ptx.Vin {
+			tx.VIn = append(tx.VIn, types.RawTxnVIn{
+				TxID:        in.Txid,
+				VOut:        int(in.Vout),
+				ScriptSig:   types.RawTxnScriptSig{Asm: in.GetScriptSig().GetAsm(), Hex: in.GetScriptSig().GetHex()},
+				TxInWitness: in.Txinwitness,
+				Sequence:    in.Sequence,
+			})
+		}
+		for _, out := range ptx.Vout {
+			value, err := parseDecimal(out.Value)
+			if err != nil {
+				return nil, fmt.Errorf("stream: value of %v:%d: %w", ptx.Txid, out.N, err)
+			}
+			spk := out.GetScriptPubKey()
+			tx.VOut = append(tx.VOut, types.RawTxnVOut{
+				Value: value,
+				N:     int(out.N),
+				ScriptPubKey: types.RawTxnScriptPubKey{
+					Asm:       spk.GetAsm(),
+					Hex:       spk.GetHex(),
+					ReqSigs:   spk.GetReqSigs(),
+					Type:      spk.GetType(),
+					Addresses: spk.GetAddresses(),
+				},
+			})
+		}
+		b.Tx = append(b.Tx, tx)
+	}
+	return b, nil
+}
+
+// parseDecimal parses an exact decimal ("" is zero).
+func parseDecimal(s string) (decimal.Decimal, error) {
+	if s == "" {
+		return decimal.Decimal{}, nil
+	}
+	return decimal.NewFromString(s)
+}
@@ -0,0 +1,57 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/chainfollower"
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/rpc"
+	"github.com/dogecoinfoundation/chainfollower/pkg/state"
+)
+
+const replayCheckInterval = time.Second // how often a replay checks its follower for a fatal error.
+
+// FollowerReplayer replays with a new follower on transport (e.g. the
+// caching transport of the server's own follower), started at the client's
+// position. opts are the follower's options, without signal handling.
+func FollowerReplayer(transport rpc.RpcTransportInterface, opts ...chainfollower.Option) Replayer {
+	return func(from *state.ChainPos) (<-chan messages.Message, func(), error) {
+		follower, err := chainfollower.New(transport, opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		in := follower.Start(from)
+		out := make(chan messages.Message)
+		done := make(chan struct{})
+		var once sync.Once
+		stop := func() {
+			once.Do(func() {
+				follower.Stop()
+				close(done)
+			})
+		}
+		go func() {
+			defer close(out)
+			ticker := time.NewTicker(replayCheckInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case msg := <-in:
+					select {
+					case out <- msg:
+					case <-done:
+						return
+					}
+				case <-ticker.C:
+					if follower.Status().LastError != "" {
+						return
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+		return out, stop, nil
+	}
+}
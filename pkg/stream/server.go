@@ -0,0 +1,425 @@
+package stream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/state"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+)
+
+// Serves the follower's block and rollback stream to remote clients (see
+// ServeWebSocket and RegisterGRPC). Events are kept in an in-memory history
+// so that a client can resume from the ChainPos of the last event it
+// acknowledged: it receives every event after that one, including any
+// rollbacks, then live events. A position older than the history is
+// replayed from Core by the server's Replayer (see FollowerReplayer) until
+// the replay reaches the history. Each client reads the history at its own
+// pace; a client that falls more than the history size behind is
+// disconnected.
+
+const DEFAULT_HISTORY = 10_000 // events kept for resuming clients.
+const DEFAULT_MAX_REPLAYS = 4  // clients replayed at once (each runs a follower against Core).
+
+var ErrPositionUnavailable = errors.New("stream: position is not in the server's history")
+var ErrFellBehind = errors.New("stream: client fell behind the server's history")
+var ErrClosed = errors.New("stream: server closed")
+var ErrReplayEnded = errors.New("stream: replay ended before reaching the server's history")
+var ErrTooManyReplays = errors.New("stream: too many clients are being replayed")
+
+// Event types.
+const (
+	EventBlock           = "block"
+	EventRollback        = "rollback"
+	EventData            = "data"
+	EventChainMismatch   = "chain_mismatch"
+	EventCheckpointAlert = "checkpoint_alert"
+	EventInvalidBlock    = "invalid_block"
+	EventStall           = "stall"
+	EventStallRecovered  = "stall_recovered"
+	EventLag             = "lag"
+	EventLagRecovered    = "lag_recovered"
+)
+
+// Event is one message of the stream, as sent to WebSocket clients (JSON).
+type Event struct {
+	Seq         uint64          `json:"seq,omitempty"` // position in the server's stream
+	Type        string          `json:"type"`
+	ChainPos    *state.ChainPos `json:"chain_pos,omitempty"`     // block: resume from here; rollback: the new position
+	OldChainPos *state.ChainPos `json:"old_chain_pos,omitempty"` // rollback: the position rolled back from
+	Block       *types.Block    `json:"block,omitempty"`
+	Data        *DataCarrier    `json:"data,omitempty"`
+	Error       string          `json:"error,omitempty"` // alerts
+}
+
+// DataCarrier is an OP_RETURN payload (messages.DataCarrierMessage).
+type DataCarrier struct {
+	BlockHash   string `json:"block_hash"`
+	BlockHeight int64  `json:"block_height"`
+	TxIndex     int    `json:"tx_index"`
+	TxID        string `json:"txid"`
+	VOut        int    `json:"vout"`
+	Data        []byte `json:"data"` // base64 in JSON
+}
+
+// Request describes a subscription.
+type Request struct {
+	From         *state.ChainPos // resume after this block or rollback (nil = live events only)
+	Types        []string        // event types to receive (nil = all)
+	HeadersOnly  bool            // omit transactions from blocks
+	DataPrefixes [][]byte        // data events must start with one of these (nil = all)
+}
+
+// Replayer starts replaying the chain after from, for a client whose
+// position is not in the history. stop ends the replay; msgs is closed if
+// the replay fails.
+type Replayer func(from *state.ChainPos) (msgs <-chan messages.Message, stop func(), err error)
+
+type Server struct {
+	mu         sync.Mutex
+	events     []Event       // oldest first
+	nextSeq    uint64        // sequence number of the next event
+	notify     chan struct{} // closed when an event is added
+	closed     bool
+	replays    int          // subscriptions holding a replay slot
+	History    int          // events kept (default DEFAULT_HISTORY)
+	Replay     Replayer     // replays positions older than the history (nil = refuse them)
+	MaxReplays int          // subscriptions replaying at once (default DEFAULT_MAX_REPLAYS)
+	Logger     *slog.Logger // diagnostics (silent by default).
+}
+
+func NewServer() *Server {
+	return &Server{
+		nextSeq:    1,
+		notify:     make(chan struct{}),
+		History:    DEFAULT_HISTORY,
+		MaxReplays: DEFAULT_MAX_REPLAYS,
+		Logger:     slog.New(slog.DiscardHandler),
+	}
+}
+
+// Run publishes messages from the follower (the channel returned by Start)
+// until the channel is closed, then closes the server.
+func (s *Server) Run(msgs <-chan messages.Message) {
+	for msg := range msgs {
+		s.Publish(msg)
+	}
+	s.Close()
+}
+
+// Publish adds a follower message to the stream; other message types
+// (e.g. backfill messages) are ignored.
+func (s *Server) Publish(msg messages.Message) {
+	ev, ok := EventFromMessage(msg)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	ev.Seq = s.nextSeq
+	s.nextSeq++
+	s.events = append(s.events, ev)
+	if len(s.events) > s.History {
+		s.events = s.events[len(s.events)-s.History:]
+	}
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// Close ends every subscription once it has received the remaining events.
+func (s *Server) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.notify)
+	}
+}
+
+// Subscribe starts a subscription. If req.From is not in the history it is
+// replayed with Replay, or refused with ErrPositionUnavailable if there is
+// none. It fails with ErrTooManyReplays if MaxReplays subscriptions are
+// already replaying; Close ends a replay.
+func (s *Server) Subscribe(req Request) (*Subscription, error) {
+	if sub, ok := s.subscribe(req); ok {
+		return sub, nil
+	}
+	if s.Replay == nil {
+		return nil, fmt.Errorf("%w: %v at height %d", ErrPositionUnavailable, req.From.BlockHash, req.From.BlockHeight)
+	}
+	if !s.acquireReplay() {
+		return nil, ErrTooManyReplays
+	}
+	msgs, stop, err := s.Replay(copyPos(req.From))
+	if err != nil {
+		s.releaseReplay()
+		return nil, fmt.Errorf("stream: replay from %v at height %d: %w", req.From.BlockHash, req.From.BlockHeight, err)
+	}
+	s.Logger.Info("stream: replaying for a client behind the history", "from", req.From.BlockHash, "height", req.From.BlockHeight)
+	return &Subscription{server: s, req: req, replay: msgs, stopReplay: stop, skip: req.From.BlockHash}, nil
+}
+
+// acquireReplay takes one of the MaxReplays replay slots.
+func (s *Server) acquireReplay() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.replays >= s.MaxReplays {
+		return false
+	}
+	s.replays++
+	return true
+}
+
+func (s *Server) releaseReplay() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replays--
+}
+
+// subscribe starts a subscription in the history; it returns false if
+// req.From is not in it.
+func (s *Server) subscribe(req Request) (*Subscription, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub := &Subscription{server: s, req: req, next: s.nextSeq}
+	if req.From != nil && req.From.BlockHash != "" {
+		seq, ok := s.findLocked(req.From)
+		if !ok {
+			return nil, false
+		}
+		sub.next = seq + 1
+	}
+	return sub, true
+}
+
+// findLocked returns the latest event that leaves a client at pos.
+func (s *Server) findLocked(pos *state.ChainPos) (uint64, bool) {
+	for i := len(s.events) - 1; i >= 0; i-- {
+		ev := s.events[i]
+		if (ev.Type == EventBlock || ev.Type == EventRollback) && strings.EqualFold(ev.ChainPos.BlockHash, pos.BlockHash) {
+			return ev.Seq, true
+		}
+	}
+	return 0, false
+}
+
+// Subscription reads the stream from a position in the history, after
+// replaying the blocks before it if needed.
+type Subscription struct {
+	server     *Server
+	req        Request
+	next       uint64                  // sequence number of the next event to read
+	replay     <-chan messages.Message // replayed messages (nil once the history is reached)
+	stopReplay func()
+	skip       string // the replay's first block, which the client already has
+}
+
+// Next waits for the next event matching the subscription's filters.
+// Replayed events have no sequence number.
+func (sub *Subscription) Next(ctx context.Context) (Event, error) {
+	for {
+		if sub.replay != nil {
+			ev, ok, err := sub.nextReplayed(ctx)
+			if err != nil {
+				return Event{}, err
+			}
+			if !ok {
+				continue
+			}
+			if filtered, ok := sub.req.Filter(ev); ok {
+				return filtered, nil
+			}
+			continue
+		}
+		ev, wait, err := sub.poll()
+		if err != nil {
+			return Event{}, err
+		}
+		if wait == nil {
+			if filtered, ok := sub.req.Filter(ev); ok {
+				return filtered, nil
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return Event{}, ctx.Err()
+		case <-wait:
+		}
+	}
+}
+
+// nextReplayed reads the next replayed message. Once it reaches a block or
+// rollback in the history, the replay is stopped and Next continues from
+// the history.
+func (sub *Subscription) nextReplayed(ctx context.Context) (Event, bool, error) {
+	s := sub.server
+	s.mu.Lock()
+	closed, wait := s.closed, s.notify
+	s.mu.Unlock()
+	if closed {
+		return Event{}, false, ErrClosed
+	}
+	var msg messages.Message
+	select {
+	case <-ctx.Done():
+		return Event{}, false, ctx.Err()
+	case <-wait:
+		return Event{}, false, nil // check whether the server was closed.
+	case m, ok := <-sub.replay:
+		if !ok {
+			return Event{}, false, ErrReplayEnded
+		}
+		msg = m
+	}
+	ev, ok := EventFromMessage(msg)
+	if !ok {
+		return Event{}, false, nil
+	}
+	if ev.Type == EventBlock && sub.skip != "" {
+		skip := sub.skip
+		sub.skip = ""
+		if strings.EqualFold(ev.ChainPos.BlockHash, skip) {
+			return Event{}, false, nil
+		}
+	}
+	if ev.Type == EventBlock || ev.Type == EventRollback {
+		s.mu.Lock()
+		seq, found := s.findLocked(ev.ChainPos)
+		s.mu.Unlock()
+		if found {
+			sub.next = seq + 1
+			sub.Close()
+			sub.replay = nil
+		}
+	}
+	return ev, true, nil
+}
+
+// Close stops the subscription's replay, if any, freeing its slot.
+func (sub *Subscription) Close() {
+	if sub.stopReplay != nil {
+		sub.stopReplay()
+		sub.stopReplay = nil
+		sub.server.releaseReplay()
+	}
+}
+
+// poll returns the next event, or a channel to wait on for one.
+func (sub *Subscription) poll() (Event, <-chan struct{}, error) {
+	s := sub.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.events) > 0 && sub.next < s.events[0].Seq {
+		return Event{}, nil, ErrFellBehind
+	}
+	if sub.next >= s.nextSeq {
+		if s.closed {
+			return Event{}, nil, ErrClosed
+		}
+		return Event{}, s.notify, nil
+	}
+	ev := s.events[len(s.events)-int(s.nextSeq-sub.next)]
+	sub.next++
+	return ev, nil, nil
+}
+
+// Filter applies the request's filters (not From) to an event.
+func (req *Request) Filter(ev Event) (Event, bool) {
+	if req.Types != nil && !slices.Contains(req.Types, ev.Type) {
+		return ev, false
+	}
+	if ev.Type == EventData && req.DataPrefixes != nil && !slices.ContainsFunc(req.DataPrefixes, func(prefix []byte) bool {
+		return bytes.HasPrefix(ev.Data.Data, prefix)
+	}) {
+		return ev, false
+	}
+	if ev.Block != nil && req.HeadersOnly {
+		header := *ev.Block
+		header.Tx = nil
+		ev.Block = &header
+	}
+	return ev, true
+}
+
+// ParseTypes checks a comma-separated list of event types ("" = all).
+func ParseTypes(list string) ([]string, error) {
+	if list == "" {
+		return nil, nil
+	}
+	var parsed []string
+	for _, t := range strings.Split(list, ",") {
+		t = strings.TrimSpace(t)
+		switch t {
+		case EventBlock, EventRollback, EventData, EventChainMismatch, EventCheckpointAlert, EventInvalidBlock,
+			EventStall, EventStallRecovered, EventLag, EventLagRecovered:
+			parsed = append(parsed, t)
+		default:
+			return nil, fmt.Errorf("stream: unknown event type %q", t)
+		}
+	}
+	return parsed, nil
+}
+
+// EventFromMessage converts a follower message to an event (without a
+// sequence number); other message types return false.
+func EventFromMessage(msg messages.Message) (Event, bool) {
+	switch m := msg.(type) {
+	case messages.BlockMessage:
+		return Event{Type: EventBlock, ChainPos: messages.Position(m), Block: m.Block}, true
+	case messages.RollbackMessage:
+		return Event{Type: EventRollback, ChainPos: copyPos(m.NewChainPos), OldChainPos: copyPos(m.OldChainPos)}, true
+	case messages.DataCarrierMessage:
+		return Event{Type: EventData, Data: &DataCarrier{
+			BlockHash:   m.BlockHash,
+			BlockHeight: m.BlockHeight,
+			TxIndex:     m.TxIndex,
+			TxID:        m.TxID,
+			VOut:        m.VOut,
+			Data:        m.Data,
+		}}, true
+	case messages.ChainMismatchMessage:
+		return Event{Type: EventChainMismatch, Error: errString(m.Err)}, true
+	case messages.CheckpointAlertMessage:
+		return Event{Type: EventCheckpointAlert, Error: errString(m.Err)}, true
+	case messages.InvalidBlockMessage:
+		return Event{Type: EventInvalidBlock, Error: errString(m.Err)}, true
+	case messages.StallMessage:
+		if !m.Active {
+			return Event{Type: EventStallRecovered, Error: fmt.Sprintf("%s stall recovered after %v at height %d", m.Reason, m.Duration.Round(time.Second), m.Height)}, true
+		}
+		return Event{Type: EventStall, Error: fmt.Sprintf("%s stalled for %v at height %d (Core tip %d)", m.Reason, m.Duration.Round(time.Second), m.Height, m.TipHeight)}, true
+	case messages.LagMessage:
+		if !m.Active {
+			return Event{Type: EventLagRecovered, Error: fmt.Sprintf("caught up to %d blocks behind at height %d", m.Lag, m.Height)}, true
+		}
+		return Event{Type: EventLag, Error: fmt.Sprintf("%d blocks behind at height %d (Core blocks %d, headers %d)", m.Lag, m.Height, m.TipHeight, m.HeaderHeight)}, true
+	}
+	return Event{}, false
+}
+
+func copyPos(pos *state.ChainPos) *state.ChainPos {
+	if pos == nil {
+		return &state.ChainPos{}
+	}
+	cp := *pos
+	return &cp
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
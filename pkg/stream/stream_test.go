@@ -0,0 +1,368 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/messages"
+	"github.com/dogecoinfoundation/chainfollower/pkg/rpc"
+	"github.com/dogecoinfoundation/chainfollower/pkg/state"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// testServer publishes blocks 1..5, a rollback to 3, block 4 again and a payload.
+func testServer() *Server {
+	s := NewServer()
+	for h := int64(1); h <= 5; h++ {
+		s.Publish(rpc.TestBlockMessage(h))
+	}
+	s.Publish(rpc.TestRollbackMessage(5, 3))
+	s.Publish(rpc.TestBlockMessage(4))
+	s.Publish(messages.DataCarrierMessage{BlockHash: rpc.TestBlockHash(4), BlockHeight: 4, TxID: rpc.TestBlockHash(4), Data: []byte("DOGE!")})
+	return s
+}
+
+func summary(ev Event) string {
+	switch ev.Type {
+	case EventBlock:
+		return fmt.Sprintf("block %d/%d", ev.Block.Height, len(ev.Block.Tx))
+	case EventRollback:
+		return fmt.Sprintf("rollback %d", ev.ChainPos.BlockHeight)
+	case EventData:
+		return fmt.Sprintf("data %s", ev.Data.Data)
+	}
+	return ev.Type
+}
+
+func readAll(t *testing.T, sub *Subscription) []string {
+	t.Helper()
+	var got []string
+	for {
+		ev, err := sub.Next(context.Background())
+		if errors.Is(err, ErrClosed) {
+			return got
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, summary(ev))
+	}
+}
+
+func TestResume(t *testing.T) {
+	s := testServer()
+	s.Close()
+
+	sub, err := s.Subscribe(Request{From: &state.ChainPos{BlockHash: rpc.TestBlockHash(2)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(readAll(t, sub), ", "); got != "block 3/1, block 4/1, block 5/1, rollback 3, block 4/1, data DOGE!" {
+		t.Errorf("got %v", got)
+	}
+
+	// resume from the rollback, with filters.
+	sub, _ = s.Subscribe(Request{
+		From:         &state.ChainPos{BlockHash: rpc.TestBlockHash(3)},
+		Types:        []string{EventBlock, EventData},
+		HeadersOnly:  true,
+		DataPrefixes: [][]byte{[]byte("DOGE")},
+	})
+	if got := strings.Join(readAll(t, sub), ", "); got != "block 4/0, data DOGE!" {
+		t.Errorf("got %v", got)
+	}
+	sub, _ = s.Subscribe(Request{From: &state.ChainPos{BlockHash: rpc.TestBlockHash(4)}, DataPrefixes: [][]byte{[]byte("XYZ")}})
+	if got := readAll(t, sub); len(got) != 0 {
+		t.Errorf("got %v", got)
+	}
+
+	if _, err := s.Subscribe(Request{From: &state.ChainPos{BlockHash: rpc.TestBlockHash(9)}}); !errors.Is(err, ErrPositionUnavailable) {
+		t.Errorf("expected ErrPositionUnavailable, got %v", err)
+	}
+}
+
+func TestFellBehind(t *testing.T) {
+	s := NewServer()
+	s.History = 3
+	s.Publish(rpc.TestBlockMessage(1))
+	sub, _ := s.Subscribe(Request{From: &state.ChainPos{BlockHash: rpc.TestBlockHash(1)}})
+	live, _ := s.Subscribe(Request{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := live.Next(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected to wait for a live event, got %v", err)
+	}
+	for h := int64(2); h <= 5; h++ {
+		s.Publish(rpc.TestBlockMessage(h))
+	}
+	if _, err := sub.Next(context.Background()); !errors.Is(err, ErrFellBehind) {
+		t.Errorf("expected ErrFellBehind, got %v", err)
+	}
+	if _, err := s.Subscribe(Request{From: &state.ChainPos{BlockHash: rpc.TestBlockHash(1)}}); !errors.Is(err, ErrPositionUnavailable) {
+		t.Errorf("expected ErrPositionUnavailable, got %v", err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	chain := rpc.NewTestChain(10)
+	s := NewServer()
+	s.History = 3
+	for h := int64(0); h < 10; h++ {
+		s.Publish(rpc.TestBlockMessage(h))
+	}
+	s.Replay = FollowerReplayer(chain)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sub, err := s.Subscribe(Request{From: &state.ChainPos{BlockHash: rpc.TestBlockHash(3), BlockHeight: 3, WaitingForNextHash: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	var got []string
+	for range 6 {
+		ev, err := sub.Next(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%d@%d", ev.Block.Height, ev.Seq))
+	}
+	// blocks 4-7 are replayed (no sequence number), then 8 and 9 come from the history.
+	if strings.Join(got, " ") != "4@0 5@0 6@0 7@0 8@9 9@10" {
+		t.Errorf("got %v", got)
+	}
+
+	sub, err = s.Subscribe(Request{From: &state.ChainPos{BlockHash: rpc.TestBlockHash(42), BlockHeight: 42}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	if _, err := sub.Next(ctx); !errors.Is(err, ErrReplayEnded) {
+		t.Errorf("expected ErrReplayEnded for a position Core does not have, got %v", err)
+	}
+}
+
+func TestMaxReplays(t *testing.T) {
+	s := testServer()
+	s.MaxReplays = 2
+	running := 0
+	s.Replay = func(from *state.ChainPos) (<-chan messages.Message, func(), error) {
+		running++
+		return make(chan messages.Message), func() { running-- }, nil
+	}
+	old := Request{From: &state.ChainPos{BlockHash: rpc.TestBlockHash(42), BlockHeight: 42}}
+	first, err := s.Subscribe(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Subscribe(old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Subscribe(old); !errors.Is(err, ErrTooManyReplays) {
+		t.Errorf("expected ErrTooManyReplays, got %v", err)
+	}
+	if _, err := s.Subscribe(Request{From: &state.ChainPos{BlockHash: rpc.TestBlockHash(4)}}); err != nil {
+		t.Errorf("a position in the history needs no replay slot: %v", err)
+	}
+
+	// gRPC clients are refused with ResourceExhausted.
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := s.NewGRPCServer()
+	go gs.Serve(lis)
+	defer gs.Stop()
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	sub, _ := SubscribeGRPC(context.Background(), conn, old)
+	if _, err := sub.Recv(); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted, got %v", err)
+	}
+
+	first.Close()
+	first.Close()
+	if running != 1 {
+		t.Errorf("expected one replay running after Close, got %d", running)
+	}
+	if _, err := s.Subscribe(old); err != nil {
+		t.Errorf("expected Close to free a replay slot, got %v", err)
+	}
+	if _, err := s.Subscribe(old); !errors.Is(err, ErrTooManyReplays) {
+		t.Errorf("expected a closed subscription to free only one slot, got %v", err)
+	}
+}
+
+// dialWebSocket performs a client handshake and returns the connection.
+func dialWebSocket(t *testing.T, url string) (net.Conn, *bufio.Reader, *http.Response) {
+	t.Helper()
+	addr := strings.TrimPrefix(url, "http://")
+	conn, err := net.Dial("tcp", addr[:strings.Index(addr, "/")])
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: test\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n"+
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n", addr[strings.Index(addr, "/"):])
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, r, resp
+}
+
+func readServerFrame(t *testing.T, r *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		t.Fatal(err)
+	}
+	n := uint64(head[1] & 0x7f)
+	if n == 126 {
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatal(err)
+	}
+	return head[0] & 0x0f, payload
+}
+
+func TestWebSocket(t *testing.T) {
+	s := testServer()
+	mux := http.NewServeMux()
+	s.Register(mux)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	conn, r, resp := dialWebSocket(t, ts.URL+"/stream?from_hash="+rpc.TestBlockHash(2)+"&types=block,rollback")
+	defer conn.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected handshake response %v %v", resp.Status, resp.Header)
+	}
+	var got []string
+	for range 5 {
+		op, payload := readServerFrame(t, r)
+		if op != opText {
+			t.Fatalf("unexpected opcode %d", op)
+		}
+		var ev Event
+		if err := json.Unmarshal(payload, &ev); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, summary(ev))
+	}
+	if strings.Join(got, ", ") != "block 3/1, block 4/1, block 5/1, rollback 3, block 4/1" {
+		t.Errorf("got %v", got)
+	}
+
+	// ping, then the server closing.
+	conn.Write([]byte{0x80 | opPing, 0x80 | 2, 1, 2, 3, 4, 'h' ^ 1, 'i' ^ 2})
+	if op, payload := readServerFrame(t, r); op != opPong || string(payload) != "hi" {
+		t.Errorf("expected pong, got %d %q", op, payload)
+	}
+	s.Close()
+	if op, payload := readServerFrame(t, r); op != opClose || binary.BigEndian.Uint16(payload) != closeGoingAway {
+		t.Errorf("expected close, got %d %q", op, payload)
+	}
+
+	_, _, resp = dialWebSocket(t, ts.URL+"/stream?from_hash="+rpc.TestBlockHash(9))
+	if resp.StatusCode != http.StatusGone {
+		t.Errorf("expected 410 for an unknown position, got %v", resp.Status)
+	}
+	if resp, _ := http.Get(ts.URL + "/stream?types=blocks"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown type, got %v", resp.Status)
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	s := testServer()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := s.NewGRPCServer()
+	go gs.Serve(lis)
+	defer gs.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ctx := context.Background()
+
+	sub, err := SubscribeGRPC(ctx, conn, Request{From: &state.ChainPos{BlockHash: rpc.TestBlockHash(2), BlockHeight: 2}, Types: []string{EventRollback, EventBlock, EventData}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for range 6 {
+		ev, err := sub.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, summary(ev))
+		if ev.Type == EventRollback && (ev.OldChainPos.BlockHeight != 5 || ev.ChainPos.BlockHash != rpc.TestBlockHash(3)) {
+			t.Errorf("unexpected rollback %+v %+v", ev.ChainPos, ev.OldChainPos)
+		}
+		if ev.Type == EventData && (ev.Data.BlockHeight != 4 || ev.Data.TxID != rpc.TestBlockHash(4)) {
+			t.Errorf("unexpected data %+v", ev.Data)
+		}
+	}
+	if strings.Join(got, ", ") != "block 3/1, block 4/1, block 5/1, rollback 3, block 4/1, data DOGE!" {
+		t.Errorf("got %v", got)
+	}
+	s.Close()
+	if _, err := sub.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable after close, got %v", err)
+	}
+
+	sub, _ = SubscribeGRPC(ctx, conn, Request{From: &state.ChainPos{BlockHash: rpc.TestBlockHash(9)}})
+	if _, err := sub.Recv(); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+}
+
+func TestBlockProto(t *testing.T) {
+	block := &types.Block{
+		Hash:       rpc.TestBlockHash(7),
+		Height:     7,
+		Difficulty: decimal.RequireFromString("12345.678901234"),
+		Tx: []types.RawTxn{{
+			TxID: rpc.TestBlockHash(7),
+			VIn:  []types.RawTxnVIn{{TxID: rpc.TestBlockHash(6), VOut: 1, ScriptSig: types.RawTxnScriptSig{Hex: "00"}, Sequence: 4294967295}},
+			VOut: []types.RawTxnVOut{{Value: decimal.RequireFromString("0.00000001"), N: 0, ScriptPubKey: types.RawTxnScriptPubKey{Type: "nulldata", Hex: "6a"}}},
+		}},
+	}
+	got, err := blockFromProto(blockToProto(block))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, block) {
+		t.Errorf("round trip changed the block:\n%+v\n%+v", got, block)
+	}
+}
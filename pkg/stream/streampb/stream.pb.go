@@ -0,0 +1,993 @@
+// The chainfollower event stream (see pkg/stream). Events are delivered in
+// order; resume after a reconnect by sending the chain_pos of the last event
+// handled as SubscribeRequest.from.
+//
+// Regenerate streampb with `go generate ./pkg/stream` (needs protoc,
+// protoc-gen-go and protoc-gen-go-grpc).
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: stream/stream.proto
+
+package streampb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ChainPos struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	BlockHash          string                 `protobuf:"bytes,1,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	BlockHeight        int64                  `protobuf:"varint,2,opt,name=block_height,json=blockHeight,proto3" json:"block_height,omitempty"`
+	WaitingForNextHash bool                   `protobuf:"varint,3,opt,name=waiting_for_next_hash,json=waitingForNextHash,proto3" json:"waiting_for_next_hash,omitempty"`
+	ChainName          string                 `protobuf:"bytes,4,opt,name=chain_name,json=chainName,proto3" json:"chain_name,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ChainPos) Reset() {
+	*x = ChainPos{}
+	mi := &file_stream_stream_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChainPos) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChainPos) ProtoMessage() {}
+
+func (x *ChainPos) ProtoReflect() protoreflect.Message {
+	mi := &file_stream_stream_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChainPos.ProtoReflect.Descriptor instead.
+func (*ChainPos) Descriptor() ([]byte, []int) {
+	return file_stream_stream_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ChainPos) GetBlockHash() string {
+	if x != nil {
+		return x.BlockHash
+	}
+	return ""
+}
+
+func (x *ChainPos) GetBlockHeight() int64 {
+	if x != nil {
+		return x.BlockHeight
+	}
+	return 0
+}
+
+func (x *ChainPos) GetWaitingForNextHash() bool {
+	if x != nil {
+		return x.WaitingForNextHash
+	}
+	return false
+}
+
+func (x *ChainPos) GetChainName() string {
+	if x != nil {
+		return x.ChainName
+	}
+	return ""
+}
+
+type SubscribeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	From  *ChainPos              `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`   // resume after this position (unset = live events only)
+	Types []string               `protobuf:"bytes,2,rep,name=types,proto3" json:"types,omitempty"` // block, rollback, data, chain_mismatch, checkpoint_alert, invalid_block,
+	// stall, stall_recovered, lag, lag_recovered (empty = all)
+	HeadersOnly   bool     `protobuf:"varint,3,opt,name=headers_only,json=headersOnly,proto3" json:"headers_only,omitempty"`   // omit transactions from blocks
+	DataPrefixes  [][]byte `protobuf:"bytes,4,rep,name=data_prefixes,json=dataPrefixes,proto3" json:"data_prefixes,omitempty"` // data events must start with one of these (empty = all)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_stream_stream_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stream_stream_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_stream_stream_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SubscribeRequest) GetFrom() *ChainPos {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *SubscribeRequest) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *SubscribeRequest) GetHeadersOnly() bool {
+	if x != nil {
+		return x.HeadersOnly
+	}
+	return false
+}
+
+func (x *SubscribeRequest) GetDataPrefixes() [][]byte {
+	if x != nil {
+		return x.DataPrefixes
+	}
+	return nil
+}
+
+type DataCarrier struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BlockHash     string                 `protobuf:"bytes,1,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	BlockHeight   int64                  `protobuf:"varint,2,opt,name=block_height,json=blockHeight,proto3" json:"block_height,omitempty"`
+	TxIndex       int32                  `protobuf:"varint,3,opt,name=tx_index,json=txIndex,proto3" json:"tx_index,omitempty"`
+	Txid          string                 `protobuf:"bytes,4,opt,name=txid,proto3" json:"txid,omitempty"`
+	Vout          int32                  `protobuf:"varint,5,opt,name=vout,proto3" json:"vout,omitempty"`
+	Data          []byte                 `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DataCarrier) Reset() {
+	*x = DataCarrier{}
+	mi := &file_stream_stream_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DataCarrier) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataCarrier) ProtoMessage() {}
+
+func (x *DataCarrier) ProtoReflect() protoreflect.Message {
+	mi := &file_stream_stream_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataCarrier.ProtoReflect.Descriptor instead.
+func (*DataCarrier) Descriptor() ([]byte, []int) {
+	return file_stream_stream_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DataCarrier) GetBlockHash() string {
+	if x != nil {
+		return x.BlockHash
+	}
+	return ""
+}
+
+func (x *DataCarrier) GetBlockHeight() int64 {
+	if x != nil {
+		return x.BlockHeight
+	}
+	return 0
+}
+
+func (x *DataCarrier) GetTxIndex() int32 {
+	if x != nil {
+		return x.TxIndex
+	}
+	return 0
+}
+
+func (x *DataCarrier) GetTxid() string {
+	if x != nil {
+		return x.Txid
+	}
+	return ""
+}
+
+func (x *DataCarrier) GetVout() int32 {
+	if x != nil {
+		return x.Vout
+	}
+	return 0
+}
+
+func (x *DataCarrier) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// A block as returned by Core's getblock (verbosity 2). Hashes and scripts
+// are hex; decimal amounts are strings so that they stay exact.
+type Block struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Hash              string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Confirmations     int64                  `protobuf:"varint,2,opt,name=confirmations,proto3" json:"confirmations,omitempty"`
+	Size              int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	StrippedSize      int64                  `protobuf:"varint,4,opt,name=stripped_size,json=strippedSize,proto3" json:"stripped_size,omitempty"`
+	Weight            int64                  `protobuf:"varint,5,opt,name=weight,proto3" json:"weight,omitempty"`
+	Height            int64                  `protobuf:"varint,6,opt,name=height,proto3" json:"height,omitempty"`
+	Version           int64                  `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	VersionHex        string                 `protobuf:"bytes,8,opt,name=version_hex,json=versionHex,proto3" json:"version_hex,omitempty"`
+	MerkleRoot        string                 `protobuf:"bytes,9,opt,name=merkle_root,json=merkleRoot,proto3" json:"merkle_root,omitempty"`
+	Time              int64                  `protobuf:"varint,10,opt,name=time,proto3" json:"time,omitempty"`
+	MedianTime        int64                  `protobuf:"varint,11,opt,name=median_time,json=medianTime,proto3" json:"median_time,omitempty"`
+	Nonce             int64                  `protobuf:"varint,12,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Bits              string                 `protobuf:"bytes,13,opt,name=bits,proto3" json:"bits,omitempty"`
+	Difficulty        string                 `protobuf:"bytes,14,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
+	ChainWork         string                 `protobuf:"bytes,15,opt,name=chain_work,json=chainWork,proto3" json:"chain_work,omitempty"`
+	PreviousBlockHash string                 `protobuf:"bytes,16,opt,name=previous_block_hash,json=previousBlockHash,proto3" json:"previous_block_hash,omitempty"`
+	NextBlockHash     string                 `protobuf:"bytes,17,opt,name=next_block_hash,json=nextBlockHash,proto3" json:"next_block_hash,omitempty"`
+	Tx                []*Transaction         `protobuf:"bytes,18,rep,name=tx,proto3" json:"tx,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Block) Reset() {
+	*x = Block{}
+	mi := &file_stream_stream_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Block) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Block) ProtoMessage() {}
+
+func (x *Block) ProtoReflect() protoreflect.Message {
+	mi := &file_stream_stream_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Block.ProtoReflect.Descriptor instead.
+func (*Block) Descriptor() ([]byte, []int) {
+	return file_stream_stream_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Block) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *Block) GetConfirmations() int64 {
+	if x != nil {
+		return x.Confirmations
+	}
+	return 0
+}
+
+func (x *Block) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Block) GetStrippedSize() int64 {
+	if x != nil {
+		return x.StrippedSize
+	}
+	return 0
+}
+
+func (x *Block) GetWeight() int64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *Block) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Block) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Block) GetVersionHex() string {
+	if x != nil {
+		return x.VersionHex
+	}
+	return ""
+}
+
+func (x *Block) GetMerkleRoot() string {
+	if x != nil {
+		return x.MerkleRoot
+	}
+	return ""
+}
+
+func (x *Block) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *Block) GetMedianTime() int64 {
+	if x != nil {
+		return x.MedianTime
+	}
+	return 0
+}
+
+func (x *Block) GetNonce() int64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+func (x *Block) GetBits() string {
+	if x != nil {
+		return x.Bits
+	}
+	return ""
+}
+
+func (x *Block) GetDifficulty() string {
+	if x != nil {
+		return x.Difficulty
+	}
+	return ""
+}
+
+func (x *Block) GetChainWork() string {
+	if x != nil {
+		return x.ChainWork
+	}
+	return ""
+}
+
+func (x *Block) GetPreviousBlockHash() string {
+	if x != nil {
+		return x.PreviousBlockHash
+	}
+	return ""
+}
+
+func (x *Block) GetNextBlockHash() string {
+	if x != nil {
+		return x.NextBlockHash
+	}
+	return ""
+}
+
+func (x *Block) GetTx() []*Transaction {
+	if x != nil {
+		return x.Tx
+	}
+	return nil
+}
+
+type Transaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Txid          string                 `protobuf:"bytes,1,opt,name=txid,proto3" json:"txid,omitempty"`
+	Hash          string                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	Vsize         int64                  `protobuf:"varint,4,opt,name=vsize,proto3" json:"vsize,omitempty"`
+	Version       int64                  `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	Locktime      int64                  `protobuf:"varint,6,opt,name=locktime,proto3" json:"locktime,omitempty"`
+	Vin           []*TxIn                `protobuf:"bytes,7,rep,name=vin,proto3" json:"vin,omitempty"`
+	Vout          []*TxOut               `protobuf:"bytes,8,rep,name=vout,proto3" json:"vout,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_stream_stream_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_stream_stream_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_stream_stream_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Transaction) GetTxid() string {
+	if x != nil {
+		return x.Txid
+	}
+	return ""
+}
+
+func (x *Transaction) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *Transaction) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Transaction) GetVsize() int64 {
+	if x != nil {
+		return x.Vsize
+	}
+	return 0
+}
+
+func (x *Transaction) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Transaction) GetLocktime() int64 {
+	if x != nil {
+		return x.Locktime
+	}
+	return 0
+}
+
+func (x *Transaction) GetVin() []*TxIn {
+	if x != nil {
+		return x.Vin
+	}
+	return nil
+}
+
+func (x *Transaction) GetVout() []*TxOut {
+	if x != nil {
+		return x.Vout
+	}
+	return nil
+}
+
+type TxIn struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Txid          string                 `protobuf:"bytes,1,opt,name=txid,proto3" json:"txid,omitempty"`
+	Vout          int32                  `protobuf:"varint,2,opt,name=vout,proto3" json:"vout,omitempty"`
+	ScriptSig     *Script                `protobuf:"bytes,3,opt,name=script_sig,json=scriptSig,proto3" json:"script_sig,omitempty"`
+	Txinwitness   []string               `protobuf:"bytes,4,rep,name=txinwitness,proto3" json:"txinwitness,omitempty"`
+	Sequence      int64                  `protobuf:"varint,5,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxIn) Reset() {
+	*x = TxIn{}
+	mi := &file_stream_stream_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxIn) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxIn) ProtoMessage() {}
+
+func (x *TxIn) ProtoReflect() protoreflect.Message {
+	mi := &file_stream_stream_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxIn.ProtoReflect.Descriptor instead.
+func (*TxIn) Descriptor() ([]byte, []int) {
+	return file_stream_stream_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TxIn) GetTxid() string {
+	if x != nil {
+		return x.Txid
+	}
+	return ""
+}
+
+func (x *TxIn) GetVout() int32 {
+	if x != nil {
+		return x.Vout
+	}
+	return 0
+}
+
+func (x *TxIn) GetScriptSig() *Script {
+	if x != nil {
+		return x.ScriptSig
+	}
+	return nil
+}
+
+func (x *TxIn) GetTxinwitness() []string {
+	if x != nil {
+		return x.Txinwitness
+	}
+	return nil
+}
+
+func (x *TxIn) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+type TxOut struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"` // DOGE
+	N             int32                  `protobuf:"varint,2,opt,name=n,proto3" json:"n,omitempty"`
+	ScriptPubKey  *Script                `protobuf:"bytes,3,opt,name=script_pub_key,json=scriptPubKey,proto3" json:"script_pub_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxOut) Reset() {
+	*x = TxOut{}
+	mi := &file_stream_stream_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxOut) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxOut) ProtoMessage() {}
+
+func (x *TxOut) ProtoReflect() protoreflect.Message {
+	mi := &file_stream_stream_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxOut.ProtoReflect.Descriptor instead.
+func (*TxOut) Descriptor() ([]byte, []int) {
+	return file_stream_stream_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TxOut) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *TxOut) GetN() int32 {
+	if x != nil {
+		return x.N
+	}
+	return 0
+}
+
+func (x *TxOut) GetScriptPubKey() *Script {
+	if x != nil {
+		return x.ScriptPubKey
+	}
+	return nil
+}
+
+type Script struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Asm           string                 `protobuf:"bytes,1,opt,name=asm,proto3" json:"asm,omitempty"`
+	Hex           string                 `protobuf:"bytes,2,opt,name=hex,proto3" json:"hex,omitempty"`
+	ReqSigs       int64                  `protobuf:"varint,3,opt,name=req_sigs,json=reqSigs,proto3" json:"req_sigs,omitempty"` // scriptPubKey only
+	Type          string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`                       // scriptPubKey only
+	Addresses     []string               `protobuf:"bytes,5,rep,name=addresses,proto3" json:"addresses,omitempty"`             // scriptPubKey only
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Script) Reset() {
+	*x = Script{}
+	mi := &file_stream_stream_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Script) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Script) ProtoMessage() {}
+
+func (x *Script) ProtoReflect() protoreflect.Message {
+	mi := &file_stream_stream_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Script.ProtoReflect.Descriptor instead.
+func (*Script) Descriptor() ([]byte, []int) {
+	return file_stream_stream_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Script) GetAsm() string {
+	if x != nil {
+		return x.Asm
+	}
+	return ""
+}
+
+func (x *Script) GetHex() string {
+	if x != nil {
+		return x.Hex
+	}
+	return ""
+}
+
+func (x *Script) GetReqSigs() int64 {
+	if x != nil {
+		return x.ReqSigs
+	}
+	return 0
+}
+
+func (x *Script) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Script) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	ChainPos      *ChainPos              `protobuf:"bytes,3,opt,name=chain_pos,json=chainPos,proto3" json:"chain_pos,omitempty"`            // block: resume from here; rollback: the new position
+	OldChainPos   *ChainPos              `protobuf:"bytes,4,opt,name=old_chain_pos,json=oldChainPos,proto3" json:"old_chain_pos,omitempty"` // rollback: the position rolled back from
+	Block         *Block                 `protobuf:"bytes,8,opt,name=block,proto3" json:"block,omitempty"`
+	Data          *DataCarrier           `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
+	Error         string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"` // alerts
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_stream_stream_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_stream_stream_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_stream_stream_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Event) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetChainPos() *ChainPos {
+	if x != nil {
+		return x.ChainPos
+	}
+	return nil
+}
+
+func (x *Event) GetOldChainPos() *ChainPos {
+	if x != nil {
+		return x.OldChainPos
+	}
+	return nil
+}
+
+func (x *Event) GetBlock() *Block {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+func (x *Event) GetData() *DataCarrier {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Event) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_stream_stream_proto protoreflect.FileDescriptor
+
+const file_stream_stream_proto_rawDesc = "" +
+	"\n" +
+	"\x13stream/stream.proto\x12\x17chainfollower.stream.v1\"\x9e\x01\n" +
+	"\bChainPos\x12\x1d\n" +
+	"\n" +
+	"block_hash\x18\x01 \x01(\tR\tblockHash\x12!\n" +
+	"\fblock_height\x18\x02 \x01(\x03R\vblockHeight\x121\n" +
+	"\x15waiting_for_next_hash\x18\x03 \x01(\bR\x12waitingForNextHash\x12\x1d\n" +
+	"\n" +
+	"chain_name\x18\x04 \x01(\tR\tchainName\"\xa7\x01\n" +
+	"\x10SubscribeRequest\x125\n" +
+	"\x04from\x18\x01 \x01(\v2!.chainfollower.stream.v1.ChainPosR\x04from\x12\x14\n" +
+	"\x05types\x18\x02 \x03(\tR\x05types\x12!\n" +
+	"\fheaders_only\x18\x03 \x01(\bR\vheadersOnly\x12#\n" +
+	"\rdata_prefixes\x18\x04 \x03(\fR\fdataPrefixes\"\xa6\x01\n" +
+	"\vDataCarrier\x12\x1d\n" +
+	"\n" +
+	"block_hash\x18\x01 \x01(\tR\tblockHash\x12!\n" +
+	"\fblock_height\x18\x02 \x01(\x03R\vblockHeight\x12\x19\n" +
+	"\btx_index\x18\x03 \x01(\x05R\atxIndex\x12\x12\n" +
+	"\x04txid\x18\x04 \x01(\tR\x04txid\x12\x12\n" +
+	"\x04vout\x18\x05 \x01(\x05R\x04vout\x12\x12\n" +
+	"\x04data\x18\x06 \x01(\fR\x04data\"\xb2\x04\n" +
+	"\x05Block\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\x12$\n" +
+	"\rconfirmations\x18\x02 \x01(\x03R\rconfirmations\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12#\n" +
+	"\rstripped_size\x18\x04 \x01(\x03R\fstrippedSize\x12\x16\n" +
+	"\x06weight\x18\x05 \x01(\x03R\x06weight\x12\x16\n" +
+	"\x06height\x18\x06 \x01(\x03R\x06height\x12\x18\n" +
+	"\aversion\x18\a \x01(\x03R\aversion\x12\x1f\n" +
+	"\vversion_hex\x18\b \x01(\tR\n" +
+	"versionHex\x12\x1f\n" +
+	"\vmerkle_root\x18\t \x01(\tR\n" +
+	"merkleRoot\x12\x12\n" +
+	"\x04time\x18\n" +
+	" \x01(\x03R\x04time\x12\x1f\n" +
+	"\vmedian_time\x18\v \x01(\x03R\n" +
+	"medianTime\x12\x14\n" +
+	"\x05nonce\x18\f \x01(\x03R\x05nonce\x12\x12\n" +
+	"\x04bits\x18\r \x01(\tR\x04bits\x12\x1e\n" +
+	"\n" +
+	"difficulty\x18\x0e \x01(\tR\n" +
+	"difficulty\x12\x1d\n" +
+	"\n" +
+	"chain_work\x18\x0f \x01(\tR\tchainWork\x12.\n" +
+	"\x13previous_block_hash\x18\x10 \x01(\tR\x11previousBlockHash\x12&\n" +
+	"\x0fnext_block_hash\x18\x11 \x01(\tR\rnextBlockHash\x124\n" +
+	"\x02tx\x18\x12 \x03(\v2$.chainfollower.stream.v1.TransactionR\x02tx\"\xfa\x01\n" +
+	"\vTransaction\x12\x12\n" +
+	"\x04txid\x18\x01 \x01(\tR\x04txid\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\tR\x04hash\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x14\n" +
+	"\x05vsize\x18\x04 \x01(\x03R\x05vsize\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\x03R\aversion\x12\x1a\n" +
+	"\blocktime\x18\x06 \x01(\x03R\blocktime\x12/\n" +
+	"\x03vin\x18\a \x03(\v2\x1d.chainfollower.stream.v1.TxInR\x03vin\x122\n" +
+	"\x04vout\x18\b \x03(\v2\x1e.chainfollower.stream.v1.TxOutR\x04vout\"\xac\x01\n" +
+	"\x04TxIn\x12\x12\n" +
+	"\x04txid\x18\x01 \x01(\tR\x04txid\x12\x12\n" +
+	"\x04vout\x18\x02 \x01(\x05R\x04vout\x12>\n" +
+	"\n" +
+	"script_sig\x18\x03 \x01(\v2\x1f.chainfollower.stream.v1.ScriptR\tscriptSig\x12 \n" +
+	"\vtxinwitness\x18\x04 \x03(\tR\vtxinwitness\x12\x1a\n" +
+	"\bsequence\x18\x05 \x01(\x03R\bsequence\"r\n" +
+	"\x05TxOut\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12\f\n" +
+	"\x01n\x18\x02 \x01(\x05R\x01n\x12E\n" +
+	"\x0escript_pub_key\x18\x03 \x01(\v2\x1f.chainfollower.stream.v1.ScriptR\fscriptPubKey\"y\n" +
+	"\x06Script\x12\x10\n" +
+	"\x03asm\x18\x01 \x01(\tR\x03asm\x12\x10\n" +
+	"\x03hex\x18\x02 \x01(\tR\x03hex\x12\x19\n" +
+	"\breq_sigs\x18\x03 \x01(\x03R\areqSigs\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12\x1c\n" +
+	"\taddresses\x18\x05 \x03(\tR\taddresses\"\xcc\x02\n" +
+	"\x05Event\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12>\n" +
+	"\tchain_pos\x18\x03 \x01(\v2!.chainfollower.stream.v1.ChainPosR\bchainPos\x12E\n" +
+	"\rold_chain_pos\x18\x04 \x01(\v2!.chainfollower.stream.v1.ChainPosR\voldChainPos\x124\n" +
+	"\x05block\x18\b \x01(\v2\x1e.chainfollower.stream.v1.BlockR\x05block\x128\n" +
+	"\x04data\x18\x06 \x01(\v2$.chainfollower.stream.v1.DataCarrierR\x04data\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05errorJ\x04\b\x05\x10\x06R\n" +
+	"block_json2b\n" +
+	"\x06Stream\x12X\n" +
+	"\tSubscribe\x12).chainfollower.stream.v1.SubscribeRequest\x1a\x1e.chainfollower.stream.v1.Event0\x01BAZ?github.com/dogecoinfoundation/chainfollower/pkg/stream/streampbb\x06proto3"
+
+var (
+	file_stream_stream_proto_rawDescOnce sync.Once
+	file_stream_stream_proto_rawDescData []byte
+)
+
+func file_stream_stream_proto_rawDescGZIP() []byte {
+	file_stream_stream_proto_rawDescOnce.Do(func() {
+		file_stream_stream_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_stream_stream_proto_rawDesc), len(file_stream_stream_proto_rawDesc)))
+	})
+	return file_stream_stream_proto_rawDescData
+}
+
+var file_stream_stream_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_stream_stream_proto_goTypes = []any{
+	(*ChainPos)(nil),         // 0: chainfollower.stream.v1.ChainPos
+	(*SubscribeRequest)(nil), // 1: chainfollower.stream.v1.SubscribeRequest
+	(*DataCarrier)(nil),      // 2: chainfollower.stream.v1.DataCarrier
+	(*Block)(nil),            // 3: chainfollower.stream.v1.Block
+	(*Transaction)(nil),      // 4: chainfollower.stream.v1.Transaction
+	(*TxIn)(nil),             // 5: chainfollower.stream.v1.TxIn
+	(*TxOut)(nil),            // 6: chainfollower.stream.v1.TxOut
+	(*Script)(nil),           // 7: chainfollower.stream.v1.Script
+	(*Event)(nil),            // 8: chainfollower.stream.v1.Event
+}
+var file_stream_stream_proto_depIdxs = []int32{
+	0,  // 0: chainfollower.stream.v1.SubscribeRequest.from:type_name -> chainfollower.stream.v1.ChainPos
+	4,  // 1: chainfollower.stream.v1.Block.tx:type_name -> chainfollower.stream.v1.Transaction
+	5,  // 2: chainfollower.stream.v1.Transaction.vin:type_name -> chainfollower.stream.v1.TxIn
+	6,  // 3: chainfollower.stream.v1.Transaction.vout:type_name -> chainfollower.stream.v1.TxOut
+	7,  // 4: chainfollower.stream.v1.TxIn.script_sig:type_name -> chainfollower.stream.v1.Script
+	7,  // 5: chainfollower.stream.v1.TxOut.script_pub_key:type_name -> chainfollower.stream.v1.Script
+	0,  // 6: chainfollower.stream.v1.Event.chain_pos:type_name -> chainfollower.stream.v1.ChainPos
+	0,  // 7: chainfollower.stream.v1.Event.old_chain_pos:type_name -> chainfollower.stream.v1.ChainPos
+	3,  // 8: chainfollower.stream.v1.Event.block:type_name -> chainfollower.stream.v1.Block
+	2,  // 9: chainfollower.stream.v1.Event.data:type_name -> chainfollower.stream.v1.DataCarrier
+	1,  // 10: chainfollower.stream.v1.Stream.Subscribe:input_type -> chainfollower.stream.v1.SubscribeRequest
+	8,  // 11: chainfollower.stream.v1.Stream.Subscribe:output_type -> chainfollower.stream.v1.Event
+	11, // [11:12] is the sub-list for method output_type
+	10, // [10:11] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_stream_stream_proto_init() }
+func file_stream_stream_proto_init() {
+	if File_stream_stream_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_stream_stream_proto_rawDesc), len(file_stream_stream_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_stream_stream_proto_goTypes,
+		DependencyIndexes: file_stream_stream_proto_depIdxs,
+		MessageInfos:      file_stream_stream_proto_msgTypes,
+	}.Build()
+	File_stream_stream_proto = out.File
+	file_stream_stream_proto_goTypes = nil
+	file_stream_stream_proto_depIdxs = nil
+}
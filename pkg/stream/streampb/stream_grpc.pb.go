@@ -0,0 +1,131 @@
+// The chainfollower event stream (see pkg/stream). Events are delivered in
+// order; resume after a reconnect by sending the chain_pos of the last event
+// handled as SubscribeRequest.from.
+//
+// Regenerate streampb with `go generate ./pkg/stream` (needs protoc,
+// protoc-gen-go and protoc-gen-go-grpc).
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: stream/stream.proto
+
+package streampb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Stream_Subscribe_FullMethodName = "/chainfollower.stream.v1.Stream/Subscribe"
+)
+
+// StreamClient is the client API for Stream service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type StreamClient interface {
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type streamClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStreamClient(cc grpc.ClientConnInterface) StreamClient {
+	return &streamClient{cc}
+}
+
+func (c *streamClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Stream_ServiceDesc.Streams[0], Stream_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Stream_SubscribeClient = grpc.ServerStreamingClient[Event]
+
+// StreamServer is the server API for Stream service.
+// All implementations must embed UnimplementedStreamServer
+// for forward compatibility.
+type StreamServer interface {
+	Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedStreamServer()
+}
+
+// UnimplementedStreamServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedStreamServer struct{}
+
+func (UnimplementedStreamServer) Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Error(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedStreamServer) mustEmbedUnimplementedStreamServer() {}
+func (UnimplementedStreamServer) testEmbeddedByValue()                {}
+
+// UnsafeStreamServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StreamServer will
+// result in compilation errors.
+type UnsafeStreamServer interface {
+	mustEmbedUnimplementedStreamServer()
+}
+
+func RegisterStreamServer(s grpc.ServiceRegistrar, srv StreamServer) {
+	// If the following call panics, it indicates UnimplementedStreamServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Stream_ServiceDesc, srv)
+}
+
+func _Stream_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StreamServer).Subscribe(m, &grpc.GenericServerStream[SubscribeRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Stream_SubscribeServer = grpc.ServerStreamingServer[Event]
+
+// Stream_ServiceDesc is the grpc.ServiceDesc for Stream service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Stream_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chainfollower.stream.v1.Stream",
+	HandlerType: (*StreamServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _Stream_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "stream/stream.proto",
+}
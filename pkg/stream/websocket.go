@@ -0,0 +1,279 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/state"
+)
+
+// WebSocket transport (RFC 6455): each event is a JSON text message.
+// Subscriptions are described by query parameters:
+//
+//	from_hash, from_height  resume after this block (the chain_pos of the last event handled)
+//	types                   comma-separated event types, e.g. "block,rollback"
+//	headers_only            "true" to omit transactions from blocks
+//	data_prefix             hex prefix for data events (repeatable)
+//
+// A position that is not in the history is replayed if the server has a
+// Replayer, and refused with 410 Gone otherwise (503 if the replay cannot
+// start or MaxReplays clients are already being replayed). The server closes the connection with code 4001 if the client
+// falls behind, and 1011 if a replay fails.
+
+const (
+	websocketGUID     = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	maxClientFrame    = 64 << 10 // clients only send control frames.
+	closeGoingAway    = 1001
+	closeInternal     = 1011
+	closeFellBehind   = 4001
+	websocketWriteTTL = 30 * time.Second
+)
+
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xA
+)
+
+// Register adds the WebSocket stream at /stream to an existing mux.
+func (s *Server) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/stream", s.ServeWebSocket)
+}
+
+// ServeWebSocket upgrades the request to a WebSocket and streams events.
+func (s *Server) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	req, err := RequestFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") || key == "" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "stream: expected a WebSocket upgrade", http.StatusUpgradeRequired)
+		return
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "stream: unsupported WebSocket version", http.StatusBadRequest)
+		return
+	}
+	sub, err := s.Subscribe(req)
+	if errors.Is(err, ErrPositionUnavailable) {
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer sub.Close()
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "stream: connection cannot be upgraded", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		s.Logger.Warn("stream: hijack failed", "remote", r.RemoteAddr, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	ws := &wsConn{conn: conn, r: rw.Reader, w: rw.Writer}
+	accept := sha1.Sum([]byte(key + websocketGUID))
+	fmt.Fprintf(ws.w, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		base64.StdEncoding.EncodeToString(accept[:]))
+	if err := ws.w.Flush(); err != nil {
+		return
+	}
+	s.Logger.Info("stream: WebSocket client connected", "remote", r.RemoteAddr, "from", req.From)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		defer cancel()
+		ws.readLoop()
+	}()
+
+	for {
+		ev, err := sub.Next(ctx)
+		switch {
+		case errors.Is(err, ErrClosed):
+			ws.close(closeGoingAway, "server closed")
+			return
+		case errors.Is(err, ErrReplayEnded):
+			s.Logger.Warn("stream: disconnecting WebSocket client", "remote", r.RemoteAddr, "error", err)
+			ws.close(closeInternal, err.Error())
+			return
+		case err != nil:
+			if !errors.Is(err, context.Canceled) {
+				s.Logger.Warn("stream: disconnecting WebSocket client", "remote", r.RemoteAddr, "error", err)
+				ws.close(closeFellBehind, err.Error())
+			}
+			return
+		}
+		data, err := json.Marshal(ev)
+		if err != nil {
+			s.Logger.Error("stream: encoding event", "seq", ev.Seq, "error", err)
+			return
+		}
+		if err := ws.writeFrame(opText, data); err != nil {
+			s.Logger.Info("stream: WebSocket client disconnected", "remote", r.RemoteAddr, "error", err)
+			return
+		}
+	}
+}
+
+// RequestFromQuery parses the WebSocket query parameters.
+func RequestFromQuery(q url.Values) (Request, error) {
+	var req Request
+	var err error
+	if hash := q.Get("from_hash"); hash != "" {
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != 32 {
+			return req, fmt.Errorf("stream: from_hash must be 64 hex characters: %q", hash)
+		}
+		req.From = &state.ChainPos{BlockHash: hash}
+		if height := q.Get("from_height"); height != "" {
+			if req.From.BlockHeight, err = strconv.ParseInt(height, 10, 64); err != nil {
+				return req, fmt.Errorf("stream: invalid from_height: %q", height)
+			}
+		}
+	}
+	if req.Types, err = ParseTypes(q.Get("types")); err != nil {
+		return req, err
+	}
+	if v := q.Get("headers_only"); v != "" {
+		if req.HeadersOnly, err = strconv.ParseBool(v); err != nil {
+			return req, fmt.Errorf("stream: invalid headers_only: %q", v)
+		}
+	}
+	for _, p := range q["data_prefix"] {
+		prefix, err := hex.DecodeString(p)
+		if err != nil {
+			return req, fmt.Errorf("stream: data_prefix must be hex: %q", p)
+		}
+		req.DataPrefixes = append(req.DataPrefixes, prefix)
+	}
+	return req, nil
+}
+
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type wsConn struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	writeMu sync.Mutex
+	w       *bufio.Writer
+}
+
+// writeFrame sends an unfragmented, unmasked frame.
+func (ws *wsConn) writeFrame(op byte, payload []byte) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	header := []byte{0x80 | op, 0}
+	switch n := len(payload); {
+	case n < 126:
+		header[1] = byte(n)
+	case n <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	ws.conn.SetWriteDeadline(time.Now().Add(websocketWriteTTL))
+	ws.w.Write(header)
+	ws.w.Write(payload)
+	return ws.w.Flush()
+}
+
+func (ws *wsConn) close(code uint16, reason string) {
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	ws.writeFrame(opClose, append(binary.BigEndian.AppendUint16(nil, code), reason...))
+}
+
+// readLoop answers pings and returns when the client closes the connection.
+func (ws *wsConn) readLoop() {
+	for {
+		op, payload, err := ws.readFrame()
+		if err != nil {
+			return
+		}
+		switch op {
+		case opPing:
+			if ws.writeFrame(opPong, payload) != nil {
+				return
+			}
+		case opClose:
+			ws.writeFrame(opClose, payload[:min(len(payload), 2)])
+			return
+		}
+	}
+}
+
+// readFrame reads one (masked) client frame.
+func (ws *wsConn) readFrame() (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(ws.r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	op := head[0] & 0x0f
+	masked := head[1]&0x80 != 0
+	n := uint64(head[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(ws.r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(ws.r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if !masked || n > maxClientFrame {
+		return 0, nil, errors.New("stream: invalid client frame")
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(ws.r, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(ws.r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return op, payload, nil
+}
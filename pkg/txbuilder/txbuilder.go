@@ -0,0 +1,457 @@
+package txbuilder
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/dogecoinfoundation/chainfollower/internal/doge"
+	"github.com/dogecoinfoundation/chainfollower/pkg/address"
+	"github.com/dogecoinfoundation/chainfollower/pkg/script"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+	"github.com/dogecoinfoundation/chainfollower/pkg/wif"
+	"github.com/shopspring/decimal"
+)
+
+// Builds and signs transactions spending P2PKH and P2SH multisig outputs
+// seen by the follower, serialized for Core's sendrawtransaction RPC.
+// Amounts are in koinu (1 DOGE = 100,000,000 koinu).
+
+const KoinuPerDoge = doge.KoinuPerDoge
+
+const DEFAULT_FEE_PER_KB = 1_000_000 // koinu per 1000 bytes (0.01 DOGE/kB, Core's recommended fee).
+const DEFAULT_DUST_LIMIT = 1_000_000 // koinu: smallest output Core relays by default (0.01 DOGE).
+
+// Largest signature (DER plus hash type) pushed by a scriptSig, used to
+// estimate the size of a transaction before it is signed.
+const maxSigLen = 72
+
+var (
+	ErrInsufficientFunds = errors.New("txbuilder: insufficient funds")
+	ErrDust              = errors.New("txbuilder: output below dust limit")
+	ErrUnsupportedScript = errors.New("txbuilder: can only spend P2PKH and P2SH multisig outputs")
+	ErrIncomplete        = errors.New("txbuilder: transaction is not fully signed")
+)
+
+// UTXO is an unspent output to spend.
+type UTXO struct {
+	TxID         string // Core display order
+	VOut         uint32
+	Value        int64 // koinu
+	ScriptPubKey []byte
+	RedeemScript []byte // P2SH outputs: the multisig redeem script
+}
+
+// KoinuFromDoge converts an exact DOGE amount (as in types.RawTxnVOut.Value).
+func KoinuFromDoge(value decimal.Decimal) (int64, error) {
+	koinu := value.Shift(8)
+	if !koinu.IsInteger() || koinu.IsNegative() {
+		return 0, fmt.Errorf("txbuilder: invalid amount %v DOGE", value)
+	}
+	return koinu.IntPart(), nil
+}
+
+// DogeFromKoinu converts koinu to an exact DOGE amount.
+func DogeFromKoinu(koinu int64) decimal.Decimal {
+	return decimal.New(koinu, -8)
+}
+
+// UTXOFromVOut makes a UTXO from an output of a transaction seen in a block.
+func UTXOFromVOut(txid string, vout types.RawTxnVOut) (UTXO, error) {
+	value, err := KoinuFromDoge(vout.Value)
+	if err != nil {
+		return UTXO{}, err
+	}
+	spk, err := hex.DecodeString(vout.ScriptPubKey.Hex)
+	if err != nil {
+		return UTXO{}, fmt.Errorf("txbuilder: invalid scriptPubKey hex: %v", err)
+	}
+	return UTXO{TxID: txid, VOut: uint32(vout.N), Value: value, ScriptPubKey: spk}, nil
+}
+
+// UTXOsFromTxn returns the outputs of a transaction that pay an address for
+// which `watches` is true (e.g. watchlist.Watchlist.Watches). P2SH outputs
+// need their RedeemScript set before they can be spent.
+func UTXOsFromTxn(tx types.RawTxn, net *address.Network, watches func(addr string) bool) ([]UTXO, error) {
+	var utxos []UTXO
+	for _, vout := range tx.VOut {
+		spk, err := hex.DecodeString(vout.ScriptPubKey.Hex)
+		if err != nil {
+			continue
+		}
+		addr, err := address.FromScript(net, spk)
+		if err != nil || !watches(addr) {
+			continue
+		}
+		utxo, err := UTXOFromVOut(tx.TxID, vout)
+		if err != nil {
+			return nil, err
+		}
+		utxos = append(utxos, utxo)
+	}
+	return utxos, nil
+}
+
+// Builder assembles an unsigned transaction. With a change address and no
+// outputs, Build sweeps every input to the change address.
+type Builder struct {
+	Net       *address.Network
+	FeePerKB  int64 // koinu per 1000 bytes of signed transaction
+	DustLimit int64 // smallest output value; smaller change is left as fee
+	LockTime  uint32
+	inputs    []input
+	outputs   []doge.TxOut
+	change    []byte
+	pubKeys   [][]byte // signing keys given to AddKeys, for size estimates
+}
+
+type input struct {
+	UTXO
+	prevTxID  []byte
+	subScript []byte   // script the signature hash commits to
+	pubKeys   [][]byte // multisig keys in redeem script order
+	pkHash    []byte   // P2PKH public key hash
+	reqSigs   int
+}
+
+func NewBuilder(net *address.Network) *Builder {
+	return &Builder{Net: net, FeePerKB: DEFAULT_FEE_PER_KB, DustLimit: DEFAULT_DUST_LIMIT}
+}
+
+// AddInput spends a P2PKH output, or a P2SH output whose RedeemScript is an
+// m-of-n multisig script.
+func (b *Builder) AddInput(u UTXO) error {
+	prev, err := doge.HexToHash(u.TxID)
+	if err != nil {
+		return fmt.Errorf("txbuilder: invalid txid %q: %v", u.TxID, err)
+	}
+	in := input{UTXO: u, prevTxID: prev}
+	spk := script.Classify(u.ScriptPubKey)
+	switch spk.Type {
+	case script.P2PKH:
+		in.subScript, in.pkHash, in.reqSigs = u.ScriptPubKey, spk.Hash, 1
+	case script.P2SH:
+		if !bytes.Equal(address.Hash160(u.RedeemScript), spk.Hash) {
+			return fmt.Errorf("txbuilder: redeem script does not match %v:%d", u.TxID, u.VOut)
+		}
+		redeem := script.Classify(u.RedeemScript)
+		if redeem.Type != script.MultiSig {
+			return ErrUnsupportedScript
+		}
+		in.subScript, in.pubKeys, in.reqSigs = u.RedeemScript, redeem.PubKeys, redeem.ReqSigs
+	default:
+		return ErrUnsupportedScript
+	}
+	b.inputs = append(b.inputs, in)
+	return nil
+}
+
+// AddOutput pays value koinu to a P2PKH or P2SH address.
+func (b *Builder) AddOutput(addr string, value int64) error {
+	spk, err := address.ToScript(b.Net, addr)
+	if err != nil {
+		return fmt.Errorf("txbuilder: %w", err)
+	}
+	if value < b.DustLimit {
+		return fmt.Errorf("%w: %d koinu to %v", ErrDust, value, addr)
+	}
+	b.outputs = append(b.outputs, doge.TxOut{Value: value, Script: spk})
+	return nil
+}
+
+// SetChangeAddress receives whatever the inputs provide beyond the outputs
+// and fee. Without one, Build fails if there is more than dust left over.
+func (b *Builder) SetChangeAddress(addr string) error {
+	spk, err := address.ToScript(b.Net, addr)
+	if err != nil {
+		return fmt.Errorf("txbuilder: %w", err)
+	}
+	b.change = spk
+	return nil
+}
+
+// AddKeys tells the size estimate which keys will sign the P2PKH inputs.
+// Inputs without a known key are estimated with an uncompressed public key,
+// the larger kind, so that the fee is never too low.
+func (b *Builder) AddKeys(keys ...*wif.Key) {
+	for _, key := range keys {
+		b.pubKeys = append(b.pubKeys, key.PubKey())
+	}
+}
+
+// SelectInputs adds inputs from utxos, largest first, until they pay for
+// the outputs and fee. UTXOs it cannot spend (ErrUnsupportedScript) are
+// skipped; on any error, the inputs added so far are removed again.
+func (b *Builder) SelectInputs(utxos []UTXO) error {
+	sorted := append([]UTXO(nil), utxos...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Value > sorted[j].Value })
+	selected := len(b.inputs)
+	for _, u := range sorted {
+		if b.inputTotal() >= b.outputTotal()+b.Fee(b.estimateSize(false)) {
+			return nil
+		}
+		if err := b.AddInput(u); errors.Is(err, ErrUnsupportedScript) {
+			continue
+		} else if err != nil {
+			b.inputs = b.inputs[:selected]
+			return err
+		}
+	}
+	if b.inputTotal() < b.outputTotal()+b.Fee(b.estimateSize(false)) {
+		b.inputs = b.inputs[:selected]
+		return ErrInsufficientFunds
+	}
+	return nil
+}
+
+// Fee returns the fee for a transaction of size bytes, rounded up.
+func (b *Builder) Fee(size int) int64 {
+	return (int64(size)*b.FeePerKB + 999) / 1000
+}
+
+// Build returns the unsigned transaction, adding a change output unless the
+// change would be dust.
+func (b *Builder) Build() (*Tx, error) {
+	if len(b.inputs) == 0 {
+		return nil, errors.New("txbuilder: no inputs")
+	}
+	if len(b.outputs) == 0 && b.change == nil {
+		return nil, errors.New("txbuilder: no outputs or change address")
+	}
+	in, out := b.inputTotal(), b.outputTotal()
+	fee := b.Fee(b.estimateSize(false))
+	if in < out+fee {
+		return nil, fmt.Errorf("%w: inputs %d koinu, outputs %d koinu, fee %d koinu", ErrInsufficientFunds, in, out, fee)
+	}
+
+	outputs := append([]doge.TxOut(nil), b.outputs...)
+	if b.change != nil {
+		changeFee := b.Fee(b.estimateSize(true))
+		if change := in - out - changeFee; change >= b.DustLimit {
+			outputs = append(outputs, doge.TxOut{Value: change, Script: b.change})
+		} else if len(outputs) == 0 {
+			return nil, fmt.Errorf("%w: sweep of %d koinu leaves dust", ErrInsufficientFunds, in)
+		}
+	} else if in-out-fee >= b.DustLimit {
+		return nil, fmt.Errorf("txbuilder: %d koinu of change needs a change address", in-out-fee)
+	}
+
+	tx := &Tx{
+		net:    b.Net,
+		tx:     &doge.Tx{Version: doge.TxVersion, Outputs: outputs, LockTime: b.LockTime},
+		inputs: append([]input(nil), b.inputs...),
+		sigs:   make([][][]byte, len(b.inputs)),
+		fee:    in - out,
+	}
+	if len(outputs) > len(b.outputs) {
+		tx.fee -= outputs[len(outputs)-1].Value
+	}
+	for i, in := range b.inputs {
+		sequence := uint32(doge.SequenceFinal)
+		if b.LockTime != 0 {
+			sequence-- // locktime is ignored if every input is final.
+		}
+		tx.tx.Inputs = append(tx.tx.Inputs, doge.TxIn{PrevTxID: in.prevTxID, PrevIndex: in.VOut, Sequence: sequence})
+		if in.pubKeys != nil {
+			tx.sigs[i] = make([][]byte, len(in.pubKeys))
+		} else {
+			tx.sigs[i] = make([][]byte, 1)
+		}
+	}
+	return tx, nil
+}
+
+func (b *Builder) inputTotal() int64 {
+	var total int64
+	for _, in := range b.inputs {
+		total += in.Value
+	}
+	return total
+}
+
+func (b *Builder) outputTotal() int64 {
+	var total int64
+	for _, out := range b.outputs {
+		total += out.Value
+	}
+	return total
+}
+
+// estimateSize returns the signed size, assuming maximum-length signatures
+// (and public keys, see AddKeys).
+func (b *Builder) estimateSize(withChange bool) int {
+	tx := doge.Tx{Outputs: b.outputs}
+	if withChange {
+		tx.Outputs = append(append([]doge.TxOut(nil), b.outputs...), doge.TxOut{Script: b.change})
+	}
+	for _, in := range b.inputs {
+		tx.Inputs = append(tx.Inputs, doge.TxIn{ScriptSig: make([]byte, b.scriptSigSize(in))})
+	}
+	return tx.SerializeSize()
+}
+
+func (b *Builder) scriptSigSize(in input) int {
+	if in.pubKeys == nil {
+		pubKeyLen := doge.ECPubKeyUncompressedLen
+		for _, pub := range b.pubKeys {
+			if bytes.Equal(address.Hash160(pub), in.pkHash) {
+				pubKeyLen = len(pub)
+			}
+		}
+		return 1 + maxSigLen + 1 + pubKeyLen
+	}
+	return 1 + in.reqSigs*(1+maxSigLen) + len(script.PushData(nil, in.subScript))
+}
+
+// Tx is a transaction being signed. Multisig inputs collect signatures over
+// one or more calls to Sign.
+type Tx struct {
+	net    *address.Network
+	tx     *doge.Tx
+	inputs []input
+	sigs   [][][]byte // per input: signature per public key (P2PKH: one)
+	fee    int64
+}
+
+// Fee returns the fee paid, in koinu.
+func (t *Tx) Fee() int64 {
+	return t.fee
+}
+
+// Sign signs every input that one of the keys can spend (SIGHASH_ALL).
+func (t *Tx) Sign(keys ...*wif.Key) error {
+	for _, key := range keys {
+		if key.Chain != t.net {
+			return fmt.Errorf("txbuilder: %w", wif.ErrWrongNetwork)
+		}
+		pub := key.PubKey()
+		for i, in := range t.inputs {
+			slot := -1
+			if in.pubKeys == nil {
+				if bytes.Equal(address.Hash160(pub), in.pkHash) {
+					slot = 0
+				}
+			} else {
+				for j, k := range in.pubKeys {
+					if bytes.Equal(k, pub) {
+						slot = j
+					}
+				}
+			}
+			if slot < 0 {
+				continue
+			}
+			hash, err := t.tx.SignatureHash(i, in.subScript, doge.SigHashAll)
+			if err != nil {
+				return err
+			}
+			sig, err := doge.SignTxHash(key.PrivKey, hash, doge.SigHashAll)
+			if err != nil {
+				return fmt.Errorf("txbuilder: input %d: %w", i, err)
+			}
+			t.sigs[i][slot] = sig
+			t.tx.Inputs[i].ScriptSig = t.scriptSig(i, pub)
+		}
+	}
+	return nil
+}
+
+// Complete reports whether every input has enough signatures.
+func (t *Tx) Complete() bool {
+	for i := range t.inputs {
+		if !t.inputComplete(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify checks every input's signatures against the spent output.
+func (t *Tx) Verify() error {
+	if !t.Complete() {
+		return ErrIncomplete
+	}
+	for i, in := range t.inputs {
+		hash, err := t.tx.SignatureHash(i, in.subScript, doge.SigHashAll)
+		if err != nil {
+			return err
+		}
+		ins, err := script.Parse(t.tx.Inputs[i].ScriptSig)
+		if err != nil {
+			return fmt.Errorf("txbuilder: input %d: %w", i, err)
+		}
+		if in.pubKeys == nil {
+			if len(ins) != 2 || !bytes.Equal(address.Hash160(ins[1].Data), in.pkHash) || !doge.VerifyTxSignature(ins[1].Data, hash, ins[0].Data) {
+				return fmt.Errorf("txbuilder: input %d: invalid signature", i)
+			}
+			continue
+		}
+		for j, sig := range t.sigs[i] {
+			if sig != nil && !doge.VerifyTxSignature(in.pubKeys[j], hash, sig) {
+				return fmt.Errorf("txbuilder: input %d: invalid signature for key %d", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// Serialize returns the signed transaction.
+func (t *Tx) Serialize() ([]byte, error) {
+	if !t.Complete() {
+		return nil, ErrIncomplete
+	}
+	return t.tx.Serialize(), nil
+}
+
+// Hex returns the signed transaction for sendrawtransaction.
+func (t *Tx) Hex() (string, error) {
+	raw, err := t.Serialize()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// TxID returns the id of the signed transaction.
+func (t *Tx) TxID() (string, error) {
+	if !t.Complete() {
+		return "", ErrIncomplete
+	}
+	return t.tx.TxID(), nil
+}
+
+// Size returns the serialized size in bytes (final once Complete).
+func (t *Tx) Size() int {
+	return t.tx.SerializeSize()
+}
+
+func (t *Tx) inputComplete(i int) bool {
+	n := 0
+	for _, sig := range t.sigs[i] {
+		if sig != nil {
+			n++
+		}
+	}
+	return n >= t.inputs[i].reqSigs
+}
+
+// scriptSig builds the input's scriptSig from the signatures so far.
+func (t *Tx) scriptSig(i int, pub []byte) []byte {
+	in := t.inputs[i]
+	if in.pubKeys == nil {
+		s := script.PushData(nil, t.sigs[i][0])
+		return script.PushData(s, pub)
+	}
+	// OP_0 works around CHECKMULTISIG popping one item too many.
+	s := []byte{byte(script.OP_0)}
+	n := 0
+	for _, sig := range t.sigs[i] {
+		if sig != nil && n < in.reqSigs {
+			s = script.PushData(s, sig)
+			n++
+		}
+	}
+	return script.PushData(s, in.subScript)
+}
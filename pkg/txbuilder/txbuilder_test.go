@@ -0,0 +1,320 @@
+package txbuilder
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/dogecoinfoundation/chainfollower/internal/doge"
+	"github.com/dogecoinfoundation/chainfollower/pkg/address"
+	"github.com/dogecoinfoundation/chainfollower/pkg/script"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+	"github.com/dogecoinfoundation/chainfollower/pkg/wif"
+	"github.com/shopspring/decimal"
+)
+
+const prevTxID = "5b2a3f53f605d62c53e62932dac6925e3d74afa5a4b459745c36d42d0ed26a69"
+
+func testKey(t *testing.T, n byte) *wif.Key {
+	privKey := make([]byte, 32)
+	privKey[31] = n
+	encoded, err := wif.Encode(address.MainNet, privKey, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := wif.Decode(encoded, address.MainNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func p2pkhUTXO(t *testing.T, key *wif.Key, vout int, value string) UTXO {
+	addr, _ := key.Address()
+	spk, _ := address.ToScript(address.MainNet, addr)
+	utxo, err := UTXOFromVOut(prevTxID, types.RawTxnVOut{
+		Value:        decimal.RequireFromString(value),
+		N:            vout,
+		ScriptPubKey: types.RawTxnScriptPubKey{Hex: hex.EncodeToString(spk)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return utxo
+}
+
+// checkSignatures re-parses the serialized transaction and checks each
+// input's first signature independently of Tx.Verify.
+func checkSignatures(t *testing.T, tx *Tx, subScripts [][]byte, pubKeys [][]byte) {
+	raw, err := tx.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := doge.ParseTx(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, in := range parsed.Inputs {
+		ins, err := script.Parse(in.ScriptSig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig := ins[0].Data
+		if len(sig) == 0 { // multisig: skip the OP_0 dummy
+			sig = ins[1].Data
+		}
+		hash, _ := parsed.SignatureHash(i, subScripts[i], doge.SigHashAll)
+		if !doge.VerifyTxSignature(pubKeys[i], hash, sig) {
+			t.Errorf("input %d: signature does not verify", i)
+		}
+	}
+}
+
+func TestP2PKHSpend(t *testing.T) {
+	key := testKey(t, 1)
+	utxo := p2pkhUTXO(t, key, 1, "10.5")
+	if utxo.Value != 1_050_000_000 {
+		t.Fatalf("value %d", utxo.Value)
+	}
+	payee, _ := testKey(t, 2).Address()
+	change, _ := key.Address()
+
+	b := NewBuilder(address.MainNet)
+	b.AddKeys(key)
+	if err := b.AddInput(utxo); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AddOutput(payee, 5*KoinuPerDoge); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetChangeAddress(change); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	estimated := tx.Size() + 1 + maxSigLen + 1 + 33
+	if tx.Fee() != b.Fee(estimated) {
+		t.Errorf("fee %d, expected %d", tx.Fee(), b.Fee(estimated))
+	}
+	if _, err := tx.Hex(); err != ErrIncomplete {
+		t.Errorf("expected ErrIncomplete before signing, got %v", err)
+	}
+
+	if err := tx.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if tx.Size() > estimated {
+		t.Errorf("signed size %d exceeds estimate %d", tx.Size(), estimated)
+	}
+	checkSignatures(t, tx, [][]byte{utxo.ScriptPubKey}, [][]byte{key.PubKey()})
+
+	raw, _ := tx.Serialize()
+	parsed, _ := doge.ParseTx(raw)
+	if len(parsed.Outputs) != 2 || parsed.Outputs[1].Value != utxo.Value-5*KoinuPerDoge-tx.Fee() {
+		t.Errorf("unexpected outputs %+v", parsed.Outputs)
+	}
+	if doge.HashToHex(parsed.Inputs[0].PrevTxID) != prevTxID || parsed.Inputs[0].PrevIndex != 1 {
+		t.Errorf("unexpected input %+v", parsed.Inputs[0])
+	}
+	txid, _ := tx.TxID()
+	if txid != parsed.TxID() {
+		t.Errorf("txid %v, expected %v", txid, parsed.TxID())
+	}
+}
+
+func TestUncompressedKeySize(t *testing.T) {
+	privKey := make([]byte, 32)
+	privKey[31] = 1
+	encoded, _ := wif.Encode(address.MainNet, privKey, false)
+	key, err := wif.Decode(encoded, address.MainNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utxo := p2pkhUTXO(t, key, 0, "10")
+	to, _ := testKey(t, 2).Address()
+	for _, known := range []bool{false, true} {
+		b := NewBuilder(address.MainNet)
+		if known {
+			b.AddKeys(key)
+		}
+		b.AddInput(utxo)
+		b.SetChangeAddress(to)
+		tx, err := b.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		estimated := tx.Size() + 1 + maxSigLen + 1 + doge.ECPubKeyUncompressedLen
+		if tx.Fee() != b.Fee(estimated) {
+			t.Errorf("fee %d, expected %d", tx.Fee(), b.Fee(estimated))
+		}
+		if err := tx.Sign(key); err != nil {
+			t.Fatal(err)
+		}
+		if tx.Size() > estimated {
+			t.Errorf("signed size %d exceeds estimate %d", tx.Size(), estimated)
+		}
+	}
+}
+
+func TestMultiSigSpend(t *testing.T) {
+	keys := []*wif.Key{testKey(t, 1), testKey(t, 2), testKey(t, 3)}
+	redeem, err := script.MultiSigScript(2, [][]byte{keys[0].PubKey(), keys[1].PubKey(), keys[2].PubKey()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2sh, _ := address.FromRedeemScript(address.MainNet, redeem)
+	spk, _ := address.ToScript(address.MainNet, p2sh)
+	utxo := UTXO{TxID: prevTxID, VOut: 0, Value: 100 * KoinuPerDoge, ScriptPubKey: spk, RedeemScript: redeem}
+
+	b := NewBuilder(address.MainNet)
+	if err := b.AddInput(utxo); err != nil {
+		t.Fatal(err)
+	}
+	payee, _ := keys[0].Address()
+	if err := b.SetChangeAddress(payee); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.Sign(keys[2]); err != nil {
+		t.Fatal(err)
+	}
+	if tx.Complete() {
+		t.Fatal("complete with one of two signatures")
+	}
+	if err := tx.Sign(keys[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	checkSignatures(t, tx, [][]byte{redeem}, [][]byte{keys[0].PubKey()})
+
+	raw, _ := tx.Serialize()
+	parsed, _ := doge.ParseTx(raw)
+	ins, _ := script.Parse(parsed.Inputs[0].ScriptSig)
+	if len(ins) != 4 || ins[0].Op != script.OP_0 || hex.EncodeToString(ins[3].Data) != hex.EncodeToString(redeem) {
+		t.Errorf("unexpected scriptSig %v", script.Disasm(parsed.Inputs[0].ScriptSig))
+	}
+
+	utxo.RedeemScript = redeem[1:]
+	if err := NewBuilder(address.MainNet).AddInput(utxo); err == nil {
+		t.Error("expected error for a mismatched redeem script")
+	}
+}
+
+func TestSweepAndSelect(t *testing.T) {
+	key := testKey(t, 1)
+	utxos := []UTXO{p2pkhUTXO(t, key, 0, "1"), p2pkhUTXO(t, key, 1, "50"), p2pkhUTXO(t, key, 2, "20")}
+	to, _ := testKey(t, 2).Address()
+
+	sweep := NewBuilder(address.MainNet)
+	for _, u := range utxos {
+		sweep.AddInput(u)
+	}
+	sweep.SetChangeAddress(to)
+	tx, err := sweep.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Sign(key)
+	raw, _ := tx.Serialize()
+	parsed, _ := doge.ParseTx(raw)
+	if len(parsed.Outputs) != 1 || parsed.Outputs[0].Value+tx.Fee() != 71*KoinuPerDoge {
+		t.Errorf("unexpected sweep outputs %+v (fee %d)", parsed.Outputs, tx.Fee())
+	}
+
+	b := NewBuilder(address.MainNet)
+	b.AddOutput(to, 60*KoinuPerDoge)
+	if err := b.SelectInputs(utxos); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.inputs) != 2 || b.inputs[0].VOut != 1 || b.inputs[1].VOut != 2 {
+		t.Errorf("unexpected selection %+v", b.inputs)
+	}
+	b = NewBuilder(address.MainNet)
+	b.AddOutput(to, 71*KoinuPerDoge)
+	if err := b.SelectInputs(utxos); !errors.Is(err, ErrInsufficientFunds) || len(b.inputs) != 0 {
+		t.Errorf("expected ErrInsufficientFunds and no inputs, got %v and %d inputs", err, len(b.inputs))
+	}
+
+	// outputs the builder cannot spend are skipped.
+	unsupported := UTXO{TxID: prevTxID, VOut: 3, Value: 100 * KoinuPerDoge, ScriptPubKey: []byte{0x6a}}
+	b = NewBuilder(address.MainNet)
+	b.AddOutput(to, 60*KoinuPerDoge)
+	if err := b.SelectInputs(append([]UTXO{unsupported}, utxos...)); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.inputs) != 2 || b.inputs[0].VOut != 1 {
+		t.Errorf("unexpected selection %+v", b.inputs)
+	}
+	invalid := UTXO{TxID: "zz", Value: 30 * KoinuPerDoge, ScriptPubKey: utxos[0].ScriptPubKey}
+	b = NewBuilder(address.MainNet)
+	b.AddOutput(to, 60*KoinuPerDoge)
+	if err := b.SelectInputs(append(utxos, invalid)); err == nil || len(b.inputs) != 0 {
+		t.Errorf("expected an error and no inputs, got %v and %d inputs", err, len(b.inputs))
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	key := testKey(t, 1)
+	to, _ := testKey(t, 2).Address()
+	utxo := p2pkhUTXO(t, key, 0, "10")
+
+	b := NewBuilder(address.MainNet)
+	if err := b.AddOutput(to, DEFAULT_DUST_LIMIT-1); !errors.Is(err, ErrDust) {
+		t.Errorf("expected ErrDust, got %v", err)
+	}
+	testnet, _ := address.FromPubKey(address.TestNet, key.PubKey())
+	if err := b.AddOutput(testnet, KoinuPerDoge); err == nil {
+		t.Error("expected error for a testnet address")
+	}
+	if err := b.AddInput(UTXO{TxID: prevTxID, ScriptPubKey: []byte{0x6a}}); err != ErrUnsupportedScript {
+		t.Errorf("expected ErrUnsupportedScript, got %v", err)
+	}
+
+	b.AddInput(utxo)
+	b.AddOutput(to, 10*KoinuPerDoge)
+	if _, err := b.Build(); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("expected ErrInsufficientFunds, got %v", err)
+	}
+
+	b = NewBuilder(address.MainNet)
+	b.AddInput(utxo)
+	b.AddOutput(to, 5*KoinuPerDoge)
+	if _, err := b.Build(); err == nil {
+		t.Error("expected error for change without a change address")
+	}
+	b.SetChangeAddress(to)
+	tx, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	privKey := make([]byte, 32)
+	privKey[31] = 1
+	encoded, _ := wif.Encode(address.TestNet, privKey, true)
+	testKey, _ := wif.Decode(encoded, address.TestNet)
+	if err := tx.Sign(testKey); !errors.Is(err, wif.ErrWrongNetwork) {
+		t.Errorf("expected ErrWrongNetwork, got %v", err)
+	}
+}
+
+func TestKoinuFromDoge(t *testing.T) {
+	if k, err := KoinuFromDoge(decimal.RequireFromString("0.00000001")); err != nil || k != 1 {
+		t.Errorf("got %d, %v", k, err)
+	}
+	if _, err := KoinuFromDoge(decimal.RequireFromString("0.000000001")); err == nil {
+		t.Error("expected error for a fraction of a koinu")
+	}
+	if d := DogeFromKoinu(123_456_789); d.String() != "1.23456789" {
+		t.Errorf("got %v", d)
+	}
+}
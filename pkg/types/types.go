@@ -70,7 +70,7 @@ type RawTxnScriptPubKey struct {
 	Asm       string   `json:"asm"`       // The script disassembly
 	Hex       string   `json:"hex"`       // The script hex
 	ReqSigs   int64    `json:"reqSigs"`   // Number of required signatures
-	Type      string   `json:"type"`      // Core RPC Script Type (see DecodeCoreRPCScriptType) NB. does NOT match our ScriptType enum!
+	Type      string   `json:"type"`      // Core RPC Script Type (see script.DecodeCoreRPCScriptType to map onto script.ScriptType)
 	Addresses []string `json:"addresses"` // Array of dogecoin addresses accepted by the script
 }
 
@@ -0,0 +1,170 @@
+package watchlist
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/address"
+	"github.com/dogecoinfoundation/chainfollower/pkg/hdwallet"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+)
+
+// A watch-list of addresses (and HD accounts) used as a follower block filter:
+// blocks are delivered with only the transactions that pay a watched address
+// or spend an output that did. Use with chainfollower.WithBlockFilter.
+//
+// Outputs seen in blocks that are later rolled back stay watched; at worst
+// this matches a spend that can no longer happen.
+
+type outpoint struct {
+	txid string
+	vout int
+}
+
+type Watchlist struct {
+	mu        sync.Mutex
+	net       *address.Network
+	addresses map[string]*hdwallet.Account // watched address -> account (nil for plain addresses)
+	accounts  []*hdwallet.Account
+	outpoints map[outpoint]string // unspent outputs paying a watched address
+}
+
+func NewWatchlist(net *address.Network) *Watchlist {
+	return &Watchlist{
+		net:       net,
+		addresses: map[string]*hdwallet.Account{},
+		outpoints: map[outpoint]string{},
+	}
+}
+
+// AddAddress watches a single address.
+func (w *Watchlist) AddAddress(addr string) error {
+	if err := address.Validate(addr, w.net); err != nil {
+		return fmt.Errorf("watchlist: %w", err)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, found := w.addresses[addr]; !found {
+		w.addresses[addr] = nil
+	}
+	return nil
+}
+
+// AddAccount watches the receive and change addresses of an extended public
+// key (dgub), extending each branch as addresses are used so that gapLimit
+// unused addresses are always watched (gapLimit <= 0 uses the BIP44 default).
+func (w *Watchlist) AddAccount(key string, gapLimit int) (*hdwallet.Account, error) {
+	account, err := hdwallet.NewAccount(key, w.net, gapLimit)
+	if err != nil {
+		return nil, fmt.Errorf("watchlist: %w", err)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.accounts = append(w.accounts, account)
+	for _, addr := range account.Receive {
+		w.addresses[addr] = account
+	}
+	for _, addr := range account.Change {
+		w.addresses[addr] = account
+	}
+	return account, nil
+}
+
+// AddOutpoint watches an existing output (e.g. restored from storage) so
+// that spending it is matched.
+func (w *Watchlist) AddOutpoint(txid string, vout int, addr string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.outpoints[outpoint{txid, vout}] = addr
+}
+
+// Watches reports whether the address is on the watch-list.
+func (w *Watchlist) Watches(addr string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, found := w.addresses[addr]
+	return found
+}
+
+// FilterBlock returns a copy of the block containing only the transactions
+// that pay a watched address or spend a watched output (in block order).
+func (w *Watchlist) FilterBlock(block *types.Block) *types.Block {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	filtered := *block
+	filtered.Tx = nil
+	for _, tx := range block.Tx {
+		if w.matchTx(tx) {
+			filtered.Tx = append(filtered.Tx, tx)
+		}
+	}
+	return &filtered
+}
+
+// MatchTx reports whether the transaction pays a watched address or spends a
+// watched output, updating the watched outputs and gap-limited accounts.
+func (w *Watchlist) MatchTx(tx types.RawTxn) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.matchTx(tx)
+}
+
+func (w *Watchlist) matchTx(tx types.RawTxn) bool {
+	matched := false
+	for _, vin := range tx.VIn {
+		if vin.TxID == "" {
+			continue // coinbase
+		}
+		op := outpoint{vin.TxID, vin.VOut}
+		if _, found := w.outpoints[op]; found {
+			delete(w.outpoints, op)
+			matched = true
+		}
+	}
+	for _, vout := range tx.VOut {
+		addr := w.outputAddress(vout)
+		if addr == "" {
+			continue
+		}
+		account, found := w.addresses[addr]
+		if !found {
+			continue
+		}
+		matched = true
+		w.outpoints[outpoint{tx.TxID, vout.N}] = addr
+		if account != nil {
+			w.markUsed(account, addr)
+		}
+	}
+	return matched
+}
+
+func (w *Watchlist) outputAddress(vout types.RawTxnVOut) string {
+	// Core decodes standard scripts already; fall back to the script itself.
+	if len(vout.ScriptPubKey.Addresses) == 1 {
+		return vout.ScriptPubKey.Addresses[0]
+	}
+	script, err := hex.DecodeString(vout.ScriptPubKey.Hex)
+	if err != nil {
+		return ""
+	}
+	addr, err := address.FromScript(w.net, script)
+	if err != nil {
+		return ""
+	}
+	return addr
+}
+
+func (w *Watchlist) markUsed(account *hdwallet.Account, addr string) {
+	branch, index, ok := account.Lookup(addr)
+	if !ok {
+		return
+	}
+	// derivation only fails for invalid child keys (probability < 2^-127).
+	added, _ := account.MarkUsed(branch, index)
+	for _, a := range added {
+		w.addresses[a] = account
+	}
+}
@@ -0,0 +1,90 @@
+package watchlist
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/dogecoinfoundation/chainfollower/pkg/address"
+	"github.com/dogecoinfoundation/chainfollower/pkg/hdwallet"
+	"github.com/dogecoinfoundation/chainfollower/pkg/types"
+)
+
+func p2pkhOutput(t *testing.T, n int, addr string) types.RawTxnVOut {
+	_, hash, err := address.Decode(addr, address.MainNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return types.RawTxnVOut{
+		N:            n,
+		ScriptPubKey: types.RawTxnScriptPubKey{Hex: "76a914" + hex.EncodeToString(hash) + "88ac"},
+	}
+}
+
+func testAccountKey(t *testing.T) string {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := hdwallet.NewMasterKey(address.MainNet, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := master.DerivePath(44+hdwallet.HardenedKeyStart, 3+hdwallet.HardenedKeyStart, hdwallet.HardenedKeyStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return account.Neuter().String()
+}
+
+func TestFilterBlock(t *testing.T) {
+	w := NewWatchlist(address.MainNet)
+	account, err := w.AddAccount(testAccountKey(t), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(account.Receive) != 5 || len(account.Change) != 5 {
+		t.Fatalf("expected 5 receive and change addresses, got %d and %d", len(account.Receive), len(account.Change))
+	}
+
+	unrelated, _ := address.EncodeP2PKH(address.MainNet, make([]byte, 20))
+	lastReceive := account.Receive[4]
+	block := &types.Block{
+		Hash: "00",
+		Tx: []types.RawTxn{
+			{TxID: "aa", VOut: []types.RawTxnVOut{p2pkhOutput(t, 0, unrelated)}},
+			{TxID: "bb", VOut: []types.RawTxnVOut{p2pkhOutput(t, 0, unrelated), p2pkhOutput(t, 1, lastReceive)}},
+		},
+	}
+	filtered := w.FilterBlock(block)
+	if len(filtered.Tx) != 1 || filtered.Tx[0].TxID != "bb" {
+		t.Fatalf("expected only tx bb, got %+v", filtered.Tx)
+	}
+	if len(block.Tx) != 2 {
+		t.Error("FilterBlock modified the original block")
+	}
+
+	// using m/0/4 extends the receive branch to keep 5 unused addresses.
+	if len(account.Receive) != 10 {
+		t.Errorf("expected gap extension to 10 receive addresses, got %d", len(account.Receive))
+	}
+	if !w.Watches(account.Receive[9]) {
+		t.Error("extended address is not watched")
+	}
+
+	// spending the watched output matches.
+	spend := &types.Block{
+		Tx: []types.RawTxn{
+			{TxID: "cc", VIn: []types.RawTxnVIn{{TxID: "bb", VOut: 0}}},
+			{TxID: "dd", VIn: []types.RawTxnVIn{{TxID: "bb", VOut: 1}}},
+		},
+	}
+	filtered = w.FilterBlock(spend)
+	if len(filtered.Tx) != 1 || filtered.Tx[0].TxID != "dd" {
+		t.Fatalf("expected only tx dd, got %+v", filtered.Tx)
+	}
+}
+
+func TestAddAddressWrongNetwork(t *testing.T) {
+	w := NewWatchlist(address.MainNet)
+	testnet, _ := address.EncodeP2PKH(address.TestNet, make([]byte, 20))
+	if err := w.AddAddress(testnet); err == nil {
+		t.Error("expected error for a testnet address")
+	}
+}
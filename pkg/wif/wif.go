@@ -0,0 +1,29 @@
+package wif
+
+import (
+	"github.com/dogecoinfoundation/chainfollower/internal/doge"
+	"github.com/dogecoinfoundation/chainfollower/pkg/address"
+)
+
+// Wallet Import Format (WIF) private key import and export.
+
+type Key = doge.WIFKey
+
+var (
+	ErrUnknownVersion = doge.ErrUnknownVersion
+	ErrWrongNetwork   = doge.ErrWIFWrongNetwork
+	ErrInvalidLength  = doge.ErrWIFInvalidLength
+	ErrBadChecksum    = doge.ErrBadChecksum
+)
+
+// Encode a 32-byte private key. Compressed keys pay to the address of the
+// compressed public key (the default for modern wallets).
+func Encode(net *address.Network, privKey []byte, compressed bool) (string, error) {
+	return doge.EncodeWIF(net, privKey, compressed)
+}
+
+// Decode a WIF private key. If net is nil the network is identified from the
+// version byte; otherwise the key must belong to net (ErrWrongNetwork).
+func Decode(wif string, net *address.Network) (*Key, error) {
+	return doge.DecodeWIF(wif, net)
+}